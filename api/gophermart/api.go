@@ -2,76 +2,132 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"sync"
-	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/rs/zerolog/log"
 	"github.com/ulixes-bloom/ya-gophermart/internal/app"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/scheduler"
 )
 
 type API struct {
 	router *chi.Mux
 	app    *app.App
 	conf   *config.Config
+	poller *scheduler.Scheduler // обновление информации по необработанным заказам
+	relay  *scheduler.Scheduler // доставка событий из outbox
+	hooks  *scheduler.Scheduler // доставка на вебхуки пользователей
 }
 
 func New(conf *config.Config, app *app.App) *API {
+	poller := scheduler.New("accrual-poller", app.UpdateNotProcessedOrders, scheduler.Options{
+		Interval: conf.OrderInfoUpdateInterval,
+		Timeout:  conf.OrderInfoUpdateTimeout,
+		Jitter:   conf.OrderInfoUpdateJitter,
+	})
+
+	relay := scheduler.New("outbox-relay", app.DeliverOutboxEvents, scheduler.Options{
+		Interval: conf.OutboxRelayInterval,
+	})
+
+	hooks := scheduler.New("webhook-dispatcher", app.DeliverWebhooks, scheduler.Options{
+		Interval: conf.WebhookDeliveryInterval,
+	})
+
 	return &API{
-		router: NewRouter(app, conf),
+		router: NewRouter(app, conf, poller),
 		app:    app,
 		conf:   conf,
+		poller: poller,
+		relay:  relay,
+		hooks:  hooks,
 	}
 }
 
+// Запуск сервиса до отмены контекста. После отмены сервер перестает принимать
+// соединения и в течение conf.ShutdownTimeout завершает обработку текущих запросов,
+// обновление информации по заказам и доставку событий, после чего закрывается хранилище.
 func (a *API) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", a.conf.RunAddr)
+	if err != nil {
+		return fmt.Errorf("api.run.listen: %w", err)
+	}
+
+	return a.serve(ctx, ln)
+}
+
+func (a *API) serve(ctx context.Context, ln net.Listener) error {
+	// Ошибка HTTP сервера останавливает фоновые задачи так же, как сигнал остановки
+	ctx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+
+	srv := &http.Server{Handler: a.router}
+	// Потоки событий не завершаются сами и закрываются в начале остановки сервера
+	srv.RegisterOnShutdown(a.app.CloseUserEvents)
 	errCh := make(chan error, 1)
-	var wg sync.WaitGroup
 
 	// Запуск HTTP сервера
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
-		errCh <- http.ListenAndServe(a.conf.RunAddr, a.router)
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
 	}()
 
-	// Обновление информации по необработанным заказам
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		a.updateNotProcessedOrders(ctx)
-	}()
+	// Обновление информации по необработанным заказам, доставка событий и вебхуков.
+	// Начатые запуски не прерываются сигналом остановки и отменяются только
+	// по истечении времени на завершение работы.
+	updateCtx, cancelUpdate := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelUpdate()
 
-	// Ожидание завершения работы
-	for {
-		select {
-		case err := <-errCh:
-			return err
-		case <-ctx.Done():
-			wg.Wait()
-			return a.app.Shutdown()
-		}
+	var wg sync.WaitGroup
+	for _, s := range []*scheduler.Scheduler{a.poller, a.relay, a.hooks} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Run(ctx, updateCtx)
+		}()
 	}
-}
 
-func (a *API) updateNotProcessedOrders(ctx context.Context) {
-	updateNotProcessedOrdersTicker := time.NewTicker(a.conf.OrderInfoUpdateInterval)
-	defer updateNotProcessedOrdersTicker.Stop()
+	// Ожидание завершения работы
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+		log.Error().Err(serveErr).Msg("http server failed")
+		cancelRun()
+	case <-ctx.Done():
+		log.Info().Msg("shutting down")
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.conf.ShutdownTimeout)
 	defer cancel()
 
-	for {
-		select {
-		case <-updateNotProcessedOrdersTicker.C:
-			err := a.app.UpdateNotProcessedOrders(ctx)
-			if err != nil {
-				log.Error().Err(err).Msg("error during updating not processed orders")
-			}
-		case <-ctx.Done():
-			return
-		}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to finish in-flight requests")
+		srv.Close()
 	}
+
+	// Ожидание завершения текущего обновления информации по заказам и доставки событий
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		log.Warn().Msg("background tasks did not finish in time, cancelling them")
+		cancelUpdate()
+		<-done
+	}
+
+	if err := a.app.Shutdown(); err != nil {
+		return errors.Join(serveErr, fmt.Errorf("api.serve.shutdown: %w", err))
+	}
+
+	return serveErr
 }
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/app"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAPI_GracefulShutdown(t *testing.T) {
+	conf := config.GetDefault()
+	conf.ShutdownTimeout = 5 * time.Second
+	a := New(conf, app.New(memory.NewStorage(), security.NewHMACKeyring(conf.TokenSecretKey), conf))
+
+	// Медленный обработчик, в процессе работы которого приходит сигнал остановки
+	started := make(chan struct{})
+	a.router.Get("/slow", func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		rw.Write([]byte("done"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := "http://" + ln.Addr().String()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- a.serve(ctx, ln)
+	}()
+
+	type response struct {
+		body string
+		err  error
+	}
+	respCh := make(chan response, 1)
+	go func() {
+		resp, err := http.Get(addr + "/slow")
+		if err != nil {
+			respCh <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		respCh <- response{body: string(body), err: err}
+	}()
+
+	<-started
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	// Начатый запрос обрабатывается до конца
+	resp := <-respCh
+	require.NoError(t, resp.err)
+	assert.Equal(t, "done", resp.body)
+
+	select {
+	case err := <-serveErr:
+		assert.NoError(t, err)
+	case <-time.After(conf.ShutdownTimeout):
+		t.Fatal("service did not stop after signal")
+	}
+
+	// Новые соединения не принимаются
+	_, err = http.Get(addr + "/slow")
+	assert.Error(t, err)
+}
+
+func TestAPI_ServeError(t *testing.T) {
+	conf := config.GetDefault()
+	conf.ShutdownTimeout = 5 * time.Second
+	a := New(conf, app.New(memory.NewStorage(), security.NewHMACKeyring(conf.TokenSecretKey), conf))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- a.serve(context.Background(), ln)
+	}()
+
+	// Закрытие слушателя завершает HTTP сервер с ошибкой, после чего
+	// останавливаются фоновые задачи и ошибка возвращается
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, ln.Close())
+
+	select {
+	case err := <-serveErr:
+		assert.Error(t, err)
+	case <-time.After(conf.ShutdownTimeout):
+		t.Fatal("service did not stop after http server failure")
+	}
+}
+
+func TestAPI_Health(t *testing.T) {
+	conf := config.GetDefault()
+	a := New(conf, app.New(memory.NewStorage(), security.NewHMACKeyring(conf.TokenSecretKey), conf))
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rw := httptest.NewRecorder()
+	a.router.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"status":"ok"`)
+	assert.Contains(t, rw.Body.String(), `"name":"accrual-poller"`)
+}
+
+func TestAPI_Metrics(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	gophermart := app.New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+	a := New(conf, gophermart)
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	tokens, err := gophermart.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	// Запрос учитывается по шаблону маршрута, а не по пути
+	req := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	a.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Поток событий завершается сразу после подключения из-за отмененного контекста
+	streamCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	req = httptest.NewRequest(http.MethodGet, "/api/user/events", nil).WithContext(streamCtx)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	a.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rw := httptest.NewRecorder()
+	a.router.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	body := rw.Body.String()
+	assert.Contains(t, body, `gophermart_http_requests_total{code="200",method="GET",route="/api/user/orders/{number}"}`)
+	assert.NotContains(t, body, `route="/api/user/orders/12345678903"`)
+	// Длительность потока событий не учитывается в гистограмме
+	assert.Contains(t, body, `gophermart_http_requests_total{code="200",method="GET",route="/api/user/events"}`)
+	assert.NotContains(t, body, `gophermart_http_request_duration_seconds_count{code="200",method="GET",route="/api/user/events"}`)
+	assert.Contains(t, body, `gophermart_orders{status="NEW"} 1`)
+	assert.Contains(t, body, `gophermart_orders{status="PROCESSED"} 0`)
+	assert.Contains(t, body, "gophermart_accrual_throttled 0")
+	assert.Contains(t, body, "gophermart_orders_accrual_failing 0")
+}
+
+func TestAPI_Tracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	gophermart := app.New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+	a := New(conf, gophermart)
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	tokens, err := gophermart.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	// Запрос продолжает трассу вызывающей системы
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw",
+		strings.NewReader(`{"order":"2377225624","sum":100}`))
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rw := httptest.NewRecorder()
+	a.router.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusPaymentRequired, rw.Code)
+
+	ended := spans.Ended()
+	require.NotEmpty(t, ended)
+	server := ended[len(ended)-1]
+	assert.Equal(t, "POST /api/user/balance/withdraw", server.Name())
+	assert.Equal(t, traceID, server.SpanContext().TraceID().String())
+	assert.Contains(t, server.Attributes(), attribute.Int("http.response.status_code", http.StatusPaymentRequired))
+
+	var withdraw sdktrace.ReadOnlySpan
+	for _, span := range ended {
+		if span.Name() == "app.WithdrawFromUserBalance" {
+			withdraw = span
+		}
+	}
+	require.NotNil(t, withdraw)
+	assert.Equal(t, server.SpanContext().SpanID(), withdraw.Parent().SpanID())
+}
@@ -3,7 +3,9 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
@@ -46,6 +48,7 @@ func (h *HTTPHandler) GetUserBalance(rw http.ResponseWriter, req *http.Request)
 // @ID			WithdrawFromUserBalance
 // @Produce	json
 // @Success	200	"успешная обработка запроса"
+// @Failure	400	"неверный формат запроса или неположительная сумма списания"
 // @Failure	401	"пользователь не авторизован"
 // @Failure	402	"на счету недостаточно средств"
 // @Failure	422	"неверный номер заказа"
@@ -88,9 +91,12 @@ func (h *HTTPHandler) WithdrawFromUserBalance(rw http.ResponseWriter, req *http.
 
 	err := h.app.WithdrawFromUserBalance(ctx, userID, withdrawalReq)
 	if err != nil {
-		if errors.Is(err, appErrors.ErrNegativeBalance) {
+		switch {
+		case errors.Is(err, appErrors.ErrNegativeBalance):
 			h.handleError(rw, err, appErrors.ErrNegativeBalance.Error(), http.StatusPaymentRequired)
-		} else {
+		case errors.Is(err, appErrors.ErrInvalidWithdrawalSum):
+			h.handleError(rw, err, appErrors.ErrInvalidWithdrawalSum.Error(), http.StatusBadRequest)
+		default:
 			h.handleError(rw, err, err.Error(), http.StatusInternalServerError)
 		}
 		return
@@ -100,14 +106,25 @@ func (h *HTTPHandler) WithdrawFromUserBalance(rw http.ResponseWriter, req *http.
 }
 
 // @Summary	Получение информации о выводе средств
+// @Description	Без параметров возвращает массив всех списаний пользователя (204, если списаний нет).
+// @Description	С любым из параметров выборки возвращает страницу списаний models.WithdrawalsPage
+// @Description	с итогами по всем списаниям, подходящим под фильтры.
 // @ID			GetUserWithdrawals
 // @Produce	json
-// @Success	200	"успешная обработка запроса"
+// @Success	200	{object}	models.WithdrawalsPage	"успешная обработка запроса"
 // @Success	204	"нет ни одного списания"
+// @Failure	400	"неверные параметры выборки"
 // @Failure	401	"пользователь не авторизован"
 // @Failure	500	"внутренняя ошибка сервера"
 // @Router		/api/user/withdrawals [get]
 // @Param		Authorization	header	string	false	"Bearer"
+// @Param		limit			query	int		false	"размер страницы, от 1 до 1000 (по умолчанию 50)"
+// @Param		cursor			query	string	false	"позиция следующей страницы из next_cursor"
+// @Param		processed_from	query	string	false	"начало периода списания включительно, RFC 3339"
+// @Param		processed_to	query	string	false	"конец периода списания не включительно, RFC 3339"
+// @Param		min_sum			query	number	false	"минимальная сумма списания включительно"
+// @Param		max_sum			query	number	false	"максимальная сумма списания включительно"
+// @Param		sort			query	string	false	"processed_at (по умолчанию) или -processed_at"
 func (h *HTTPHandler) GetUserWithdrawals(rw http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 
@@ -120,6 +137,11 @@ func (h *HTTPHandler) GetUserWithdrawals(rw http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	if hasQueryParams(req.URL.Query(), withdrawalsQueryParams) {
+		h.getUserWithdrawalsPage(rw, req, userID)
+		return
+	}
+
 	dbWithdrawals, err := h.app.GetUserWithdrawals(ctx, userID)
 	if err != nil {
 		h.handleError(rw, err, "failed to get user waithdrawals", http.StatusInternalServerError)
@@ -136,3 +158,107 @@ func (h *HTTPHandler) GetUserWithdrawals(rw http.ResponseWriter, req *http.Reque
 		return
 	}
 }
+
+// Параметры постраничной выборки списаний
+var withdrawalsQueryParams = []string{"limit", "cursor", "processed_from", "processed_to", "min_sum", "max_sum", "sort"}
+
+func (h *HTTPHandler) getUserWithdrawalsPage(rw http.ResponseWriter, req *http.Request, userID int64) {
+	query, err := parseWithdrawalsQuery(req.URL.Query())
+	if err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.app.GetUserWithdrawalsPage(req.Context(), userID, query)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidWithdrawalsQuery) || errors.Is(err, appErrors.ErrInvalidWithdrawalsCursor) {
+			h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.handleError(rw, err, "failed to get user waithdrawals", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(page); err != nil {
+		h.handleError(rw, err, "failed to encode waithdrawals", http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseWithdrawalsQuery(values url.Values) (*models.WithdrawalsQuery, error) {
+	limit, err := parseLimitParam(values, models.DefaultWithdrawalsPageLimit, appErrors.ErrInvalidWithdrawalsQuery)
+	if err != nil {
+		return nil, err
+	}
+	query := &models.WithdrawalsQuery{Limit: limit}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := models.ParseWithdrawalCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	if query.ProcessedFrom, err = parseTimeParam(values, "processed_from", appErrors.ErrInvalidWithdrawalsQuery); err != nil {
+		return nil, err
+	}
+	if query.ProcessedTo, err = parseTimeParam(values, "processed_to", appErrors.ErrInvalidWithdrawalsQuery); err != nil {
+		return nil, err
+	}
+	if query.MinSum, err = parseMoneyParam(values, "min_sum", appErrors.ErrInvalidWithdrawalsQuery); err != nil {
+		return nil, err
+	}
+	if query.MaxSum, err = parseMoneyParam(values, "max_sum", appErrors.ErrInvalidWithdrawalsQuery); err != nil {
+		return nil, err
+	}
+
+	switch values.Get("sort") {
+	case "", "processed_at":
+	case "-processed_at":
+		query.Descending = true
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", appErrors.ErrInvalidWithdrawalsQuery, values.Get("sort"))
+	}
+
+	return query, nil
+}
+
+// @Summary	Получение истории движения баллов
+// @ID			GetUserBalanceHistory
+// @Produce	json
+// @Success	200	{array}	models.LedgerEntry	"успешная обработка запроса"
+// @Success	204	"нет ни одной проводки"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/balance/history [get]
+// @Param		Authorization	header	string	false	"Bearer"
+func (h *HTTPHandler) GetUserBalanceHistory(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := h.app.GetUserBalanceHistory(ctx, userID)
+	if err != nil {
+		h.handleError(rw, err, "failed to get user balance history", http.StatusInternalServerError)
+		return
+	}
+
+	if len(entries) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := json.NewEncoder(rw).Encode(entries); err != nil {
+		h.handleError(rw, err, "failed to encode balance history", http.StatusInternalServerError)
+		return
+	}
+}
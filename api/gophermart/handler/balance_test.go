@@ -91,7 +91,7 @@ func TestHandler_WithdrawFromUserBalance(t *testing.T) {
 			mockService: func() *mocks.MockApp {
 				withdrawalReq := &models.WithdrawalRequest{
 					Order: "2377225624",
-					Sum:   200,
+					Sum:   models.NewMoney(200, 0),
 				}
 				mockService := mocks.NewMockApp(ctrl)
 				mockService.EXPECT().WithdrawFromUserBalance(gomock.Any(), int64(1), withdrawalReq).Return(nil)
@@ -107,7 +107,7 @@ func TestHandler_WithdrawFromUserBalance(t *testing.T) {
 			mockService: func() *mocks.MockApp {
 				withdrawalReq := &models.WithdrawalRequest{
 					Order: "2377225624",
-					Sum:   200,
+					Sum:   models.NewMoney(200, 0),
 				}
 				err := appErrors.ErrNegativeBalance
 
@@ -120,6 +120,24 @@ func TestHandler_WithdrawFromUserBalance(t *testing.T) {
 			ctx:                context.WithValue(context.Background(), middleware.UserIDContext, int64(1)),
 			expectedStatusCode: http.StatusPaymentRequired,
 		},
+		{
+			name: "Not positive sum Case",
+			mockService: func() *mocks.MockApp {
+				withdrawalReq := &models.WithdrawalRequest{
+					Order: "2377225624",
+					Sum:   models.NewMoney(-200, 0),
+				}
+				err := appErrors.ErrInvalidWithdrawalSum
+
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().WithdrawFromUserBalance(gomock.Any(), int64(1), withdrawalReq).Return(err)
+				mockService.EXPECT().ValidateOrderNumber("2377225624").Return(true)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"order\":\"2377225624\",\"sum\":-200}\n")),
+			ctx:                context.WithValue(context.Background(), middleware.UserIDContext, int64(1)),
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "Not valid order number Case",
 			mockService: func() *mocks.MockApp {
@@ -136,7 +154,7 @@ func TestHandler_WithdrawFromUserBalance(t *testing.T) {
 			mockService: func() *mocks.MockApp {
 				withdrawalReq := &models.WithdrawalRequest{
 					Order: "2377225624",
-					Sum:   200,
+					Sum:   models.NewMoney(200, 0),
 				}
 				err := errors.New("Table witdrawals does not exist")
 
@@ -183,7 +201,7 @@ func TestHandler_GetUserWithdrawals(t *testing.T) {
 				withdrawals := []models.Withdrawal{
 					{
 						Order:       "2377225624",
-						Sum:         200,
+						Sum:         models.NewMoney(200, 0),
 						ProcessedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -236,3 +254,212 @@ func TestHandler_GetUserWithdrawals(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetUserWithdrawalsPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userCtx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minSum, maxSum := models.NewMoney(100, 0), models.NewMoney(500, 50)
+	cursor := &models.WithdrawalCursor{ProcessedAt: from, ID: 7, Descending: true}
+	page := &models.WithdrawalsPage{
+		Withdrawals: []models.Withdrawal{{
+			Order:       "2377225624",
+			ProcessedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Sum:         models.NewMoney(200, 0),
+		}},
+		Summary:    models.WithdrawalsSummary{Total: models.NewMoney(729, 98), Count: 3},
+		NextCursor: "next",
+	}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		target             string
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.WithdrawalsQuery{
+					ProcessedFrom: &from,
+					MinSum:        &minSum,
+					MaxSum:        &maxSum,
+					Descending:    true,
+					Limit:         1,
+					After:         cursor,
+				}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserWithdrawalsPage(gomock.Any(), int64(1), query).Return(page, nil)
+				return mockService
+			},
+			target: "/api/user/withdrawals?limit=1&processed_from=2024-01-01T00:00:00Z&min_sum=100&max_sum=500.5" +
+				"&sort=-processed_at&cursor=" + cursor.Encode(),
+			expectedBody: "{\"withdrawals\":[{\"order\":\"2377225624\",\"processed_at\":\"2024-01-02T00:00:00Z\",\"sum\":200}]," +
+				"\"summary\":{\"total\":729.98,\"count\":3},\"next_cursor\":\"next\"}\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Default limit Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.WithdrawalsQuery{Limit: models.DefaultWithdrawalsPageLimit}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserWithdrawalsPage(gomock.Any(), int64(1), query).
+					Return(&models.WithdrawalsPage{Withdrawals: []models.Withdrawal{}}, nil)
+				return mockService
+			},
+			target:             "/api/user/withdrawals?sort=processed_at",
+			expectedBody:       "{\"withdrawals\":[],\"summary\":{\"total\":0,\"count\":0}}\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid sum Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/withdrawals?min_sum=many",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid time Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/withdrawals?processed_to=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown sort Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/withdrawals?sort=sum",
+			expectedBody:       "invalid withdrawals query: unknown sort \"sum\"\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid cursor Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/withdrawals?cursor=broken",
+			expectedBody:       "models.parseWithdrawalCursor: invalid withdrawals cursor\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid range Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.WithdrawalsQuery{MinSum: &maxSum, MaxSum: &minSum, Limit: models.DefaultWithdrawalsPageLimit}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserWithdrawalsPage(gomock.Any(), int64(1), query).
+					Return(nil, appErrors.ErrInvalidWithdrawalsQuery)
+				return mockService
+			},
+			target:             "/api/user/withdrawals?min_sum=500.50&max_sum=100",
+			expectedBody:       "invalid withdrawals query\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserWithdrawalsPage(gomock.Any(), int64(1), gomock.Any()).
+					Return(nil, errors.New("storage error"))
+				return mockService
+			},
+			target:             "/api/user/withdrawals?limit=10",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req = req.WithContext(userCtx)
+			rw := httptest.NewRecorder()
+
+			handler.GetUserWithdrawals(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetUserBalanceHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		expectedBody       string
+		ctx                context.Context
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				accrual := models.NewAccrualEntry(1, "12345678903", models.NewMoney(300, 50))
+				accrual.CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+				withdrawal := models.NewWithdrawalEntry(1, "2377225624", models.NewMoney(200, 0))
+				withdrawal.CreatedAt = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserBalanceHistory(gomock.Any(), int64(1)).
+					Return([]models.LedgerEntry{accrual, withdrawal}, nil)
+				return mockService
+			},
+			expectedBody: "[{\"kind\":\"ACCRUAL\",\"debit_account\":\"accruals\",\"credit_account\":\"user\"," +
+				"\"amount\":300.5,\"order\":\"12345678903\",\"created_at\":\"2024-01-01T00:00:00Z\"}," +
+				"{\"kind\":\"WITHDRAWAL\",\"debit_account\":\"user\",\"credit_account\":\"withdrawals\"," +
+				"\"amount\":200,\"order\":\"2377225624\",\"created_at\":\"2024-01-02T00:00:00Z\"}]\n",
+			ctx:                context.WithValue(context.Background(), middleware.UserIDContext, int64(1)),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "No entries Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserBalanceHistory(gomock.Any(), int64(1)).Return([]models.LedgerEntry{}, nil)
+				return mockService
+			},
+			expectedBody:       "",
+			ctx:                context.WithValue(context.Background(), middleware.UserIDContext, int64(1)),
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "Data base error Case",
+			mockService: func() *mocks.MockApp {
+				err := errors.New("Table ledger_entries does not exist")
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserBalanceHistory(gomock.Any(), int64(1)).Return(nil, err)
+				return mockService
+			},
+			expectedBody:       "failed to get user balance history\n",
+			ctx:                context.WithValue(context.Background(), middleware.UserIDContext, int64(1)),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			req := httptest.NewRequest("GET", "/api/user/balance/history", nil)
+			req = req.WithContext(tt.ctx)
+			rw := httptest.NewRecorder()
+
+			handler.GetUserBalanceHistory(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			assert.Equal(t, tt.expectedBody, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// @Summary	Поток обновлений заказов и баланса
+// @Description	Server-Sent Events поток: событие order при смене статуса заказа, balance при начислении баллов.
+// @Description	При переподключении с заголовком Last-Event-ID передаются пропущенные события из буфера сервиса.
+// @Description	Если пропущенные события недоступны, передается событие reset, после которого состояние нужно запросить заново.
+// @ID			StreamUserEvents
+// @Produce	text/event-stream
+// @Success	200	"поток событий"
+// @Failure	400	"неверный заголовок Last-Event-ID"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/events [get]
+// @Param		Authorization	header	string	false	"Bearer"
+// @Param		Last-Event-ID	header	int		false	"ID последнего полученного события"
+func (h *HTTPHandler) StreamUserEvents(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		h.handleError(rw, nil, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var lastEventID int64
+	if header := req.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			h.handleError(rw, err, "invalid Last-Event-ID header", http.StatusBadRequest)
+			return
+		}
+		lastEventID = id
+	}
+
+	sub := h.app.SubscribeUserEvents(userID, lastEventID)
+	defer h.app.UnsubscribeUserEvents(sub)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+
+	// Неполный набор пропущенных событий не передается, клиент запрашивает состояние заново
+	if sub.Gap {
+		writeUserEvent(rw, models.UserEvent{ID: sub.LastID, Type: models.UserEventReset, Data: []byte("{}")})
+	} else {
+		for _, event := range sub.Missed {
+			writeUserEvent(rw, event)
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.conf.EventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			writeUserEvent(rw, event)
+		case <-heartbeat.C:
+			fmt.Fprint(rw, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func writeUserEvent(rw http.ResponseWriter, event models.UserEvent) {
+	fmt.Fprintf(rw, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/events"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func TestHandler_StreamUserEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	order := models.NewOrderUserEvent(models.Order{
+		UserID: 1, Number: "2377225624", Status: models.OrderStatusProcessed, Accrual: models.NewMoney(500, 0),
+	})
+	balance := models.NewBalanceUserEvent(1, models.Balance{Current: models.NewMoney(500, 0)})
+
+	tests := []struct {
+		name               string
+		lastEventID        string
+		expectedStatusCode int
+		expectedBody       func(startID int64) string
+	}{
+		{
+			name:               "New Events Case",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: func(startID int64) string {
+				return "id: " + itoa(startID+2) + "\nevent: balance\ndata: {\"withdrawn\":0,\"current\":500}\n\n"
+			},
+		},
+		{
+			name:               "Resume Case",
+			lastEventID:        "START",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: func(startID int64) string {
+				return "id: " + itoa(startID+1) + "\nevent: order\n" +
+					"data: {\"number\":\"2377225624\",\"status\":\"PROCESSED\",\"accrual\":500}\n\n" +
+					"id: " + itoa(startID+2) + "\nevent: balance\ndata: {\"withdrawn\":0,\"current\":500}\n\n"
+			},
+		},
+		{
+			name:               "Reset Case",
+			lastEventID:        "1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: func(startID int64) string {
+				return "id: " + itoa(startID+1) + "\nevent: reset\ndata: {}\n\n" +
+					"id: " + itoa(startID+2) + "\nevent: balance\ndata: {\"withdrawn\":0,\"current\":500}\n\n"
+			},
+		},
+		{
+			name:               "Invalid Last-Event-ID Case",
+			lastEventID:        "abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Заказ публикуется до подключения, баланс после
+			broker := events.NewBroker(10)
+			startID := broker.Subscribe(1, 0).LastID
+			broker.Publish(order)
+
+			lastEventID := tt.lastEventID
+			if lastEventID == "START" {
+				lastEventID = itoa(startID)
+			}
+
+			subscribed := make(chan struct{})
+			mockService := mocks.NewMockApp(ctrl)
+			if tt.expectedStatusCode == http.StatusOK {
+				mockService.EXPECT().SubscribeUserEvents(int64(1), gomock.Any()).
+					DoAndReturn(func(userID, lastEventID int64) *events.Subscription {
+						defer close(subscribed)
+						return broker.Subscribe(userID, lastEventID)
+					})
+				mockService.EXPECT().UnsubscribeUserEvents(gomock.Any()).Do(broker.Unsubscribe)
+			}
+			handler := HTTPHandler{app: mockService, conf: config.GetDefault()}
+
+			ctx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+			req := httptest.NewRequest("GET", "/api/user/events", nil).WithContext(ctx)
+			if lastEventID != "" {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+			rw := httptest.NewRecorder()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				handler.StreamUserEvents(rw, req)
+			}()
+			if tt.expectedStatusCode == http.StatusOK {
+				<-subscribed
+				broker.Publish(balance)
+				broker.Close()
+			}
+			<-done
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != nil {
+				assert.Equal(t, "text/event-stream", rw.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody(startID), rw.Body.String())
+			}
+		})
+	}
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
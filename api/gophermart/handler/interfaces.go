@@ -3,18 +3,41 @@ package handler
 import (
 	"context"
 
+	"github.com/ulixes-bloom/ya-gophermart/internal/events"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
 )
 
 type App interface {
 	GetUserBalance(ctx context.Context, userID int64) (*models.Balance, error)
 	GetUserWithdrawals(ctx context.Context, userID int64) ([]models.Withdrawal, error)
+	GetUserWithdrawalsPage(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsPage, error)
 	WithdrawFromUserBalance(ctx context.Context, userID int64, withdrawalReq *models.WithdrawalRequest) error
+	GetUserBalanceHistory(ctx context.Context, userID int64) ([]models.LedgerEntry, error)
 
 	RegisterOrder(ctx context.Context, userID int64, orderNumber string) error
+	RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error)
+	GetUserOrder(ctx context.Context, userID int64, orderNumber string) (*models.OrderDetails, error)
 	GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error)
+	GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) (*models.OrdersPage, error)
 	ValidateOrderNumber(orderNumber string) bool
 
-	ValidateUser(ctx context.Context, user *models.User) (*models.User, error)
+	AddUserWebhook(ctx context.Context, userID int64, webhookReq *models.WebhookRequest) (*models.Webhook, error)
+	GetUserWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error)
+	DeleteUserWebhook(ctx context.Context, userID, webhookID int64) error
+	GetUserWebhookDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]models.WebhookDelivery, error)
+
+	SubscribeUserEvents(userID, lastEventID int64) *events.Subscription
+	UnsubscribeUserEvents(sub *events.Subscription)
+
+	ValidateUser(ctx context.Context, user *models.User, clientIP string) (*models.User, error)
 	RegisterUser(ctx context.Context, user *models.User) (int64, error)
+	ChangePassword(ctx context.Context, userID int64, changeReq *models.PasswordChangeRequest, clientIP string) error
+	RequestPasswordReset(ctx context.Context, resetReq *models.PasswordResetRequest, clientIP string) error
+	ResetPassword(ctx context.Context, confirmReq *models.PasswordResetConfirmRequest) error
+
+	IssueTokens(ctx context.Context, userID int64) (*models.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, claims *security.Claims, refreshToken string) error
+	JWKS() security.JWKSet
 }
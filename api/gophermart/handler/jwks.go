@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary	Получение открытых ключей проверки токенов
+// @ID			GetJWKS
+// @Produce	json
+// @Success	200	{object}	security.JWKSet	"набор открытых ключей"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/.well-known/jwks.json [get]
+func (h *HTTPHandler) GetJWKS(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := json.NewEncoder(rw).Encode(h.app.JWKS()); err != nil {
+		h.handleError(rw, err, "failed to encode jwks", http.StatusInternalServerError)
+		return
+	}
+}
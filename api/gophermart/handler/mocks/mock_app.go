@@ -9,7 +9,9 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	events "github.com/ulixes-bloom/ya-gophermart/internal/events"
 	models "github.com/ulixes-bloom/ya-gophermart/internal/models"
+	security "github.com/ulixes-bloom/ya-gophermart/internal/security"
 )
 
 // MockApp is a mock of App interface.
@@ -35,6 +37,49 @@ func (m *MockApp) EXPECT() *MockAppMockRecorder {
 	return m.recorder
 }
 
+// AddUserWebhook mocks base method.
+func (m *MockApp) AddUserWebhook(ctx context.Context, userID int64, webhookReq *models.WebhookRequest) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUserWebhook", ctx, userID, webhookReq)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUserWebhook indicates an expected call of AddUserWebhook.
+func (mr *MockAppMockRecorder) AddUserWebhook(ctx, userID, webhookReq interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserWebhook", reflect.TypeOf((*MockApp)(nil).AddUserWebhook), ctx, userID, webhookReq)
+}
+
+// ChangePassword mocks base method.
+func (m *MockApp) ChangePassword(ctx context.Context, userID int64, changeReq *models.PasswordChangeRequest, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, changeReq, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAppMockRecorder) ChangePassword(ctx, userID, changeReq, clientIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockApp)(nil).ChangePassword), ctx, userID, changeReq, clientIP)
+}
+
+// DeleteUserWebhook mocks base method.
+func (m *MockApp) DeleteUserWebhook(ctx context.Context, userID, webhookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserWebhook", ctx, userID, webhookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserWebhook indicates an expected call of DeleteUserWebhook.
+func (mr *MockAppMockRecorder) DeleteUserWebhook(ctx, userID, webhookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserWebhook", reflect.TypeOf((*MockApp)(nil).DeleteUserWebhook), ctx, userID, webhookID)
+}
+
 // GetOrdersByUser mocks base method.
 func (m *MockApp) GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +95,21 @@ func (mr *MockAppMockRecorder) GetOrdersByUser(ctx, userID interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUser", reflect.TypeOf((*MockApp)(nil).GetOrdersByUser), ctx, userID)
 }
 
+// GetOrdersPageByUser mocks base method.
+func (m *MockApp) GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) (*models.OrdersPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersPageByUser", ctx, userID, query)
+	ret0, _ := ret[0].(*models.OrdersPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersPageByUser indicates an expected call of GetOrdersPageByUser.
+func (mr *MockAppMockRecorder) GetOrdersPageByUser(ctx, userID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersPageByUser", reflect.TypeOf((*MockApp)(nil).GetOrdersPageByUser), ctx, userID, query)
+}
+
 // GetUserBalance mocks base method.
 func (m *MockApp) GetUserBalance(ctx context.Context, userID int64) (*models.Balance, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +125,66 @@ func (mr *MockAppMockRecorder) GetUserBalance(ctx, userID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalance", reflect.TypeOf((*MockApp)(nil).GetUserBalance), ctx, userID)
 }
 
+// GetUserBalanceHistory mocks base method.
+func (m *MockApp) GetUserBalanceHistory(ctx context.Context, userID int64) ([]models.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserBalanceHistory", ctx, userID)
+	ret0, _ := ret[0].([]models.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserBalanceHistory indicates an expected call of GetUserBalanceHistory.
+func (mr *MockAppMockRecorder) GetUserBalanceHistory(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalanceHistory", reflect.TypeOf((*MockApp)(nil).GetUserBalanceHistory), ctx, userID)
+}
+
+// GetUserOrder mocks base method.
+func (m *MockApp) GetUserOrder(ctx context.Context, userID int64, orderNumber string) (*models.OrderDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrder", ctx, userID, orderNumber)
+	ret0, _ := ret[0].(*models.OrderDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrder indicates an expected call of GetUserOrder.
+func (mr *MockAppMockRecorder) GetUserOrder(ctx, userID, orderNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrder", reflect.TypeOf((*MockApp)(nil).GetUserOrder), ctx, userID, orderNumber)
+}
+
+// GetUserWebhookDeliveries mocks base method.
+func (m *MockApp) GetUserWebhookDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserWebhookDeliveries", ctx, userID, webhookID, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserWebhookDeliveries indicates an expected call of GetUserWebhookDeliveries.
+func (mr *MockAppMockRecorder) GetUserWebhookDeliveries(ctx, userID, webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWebhookDeliveries", reflect.TypeOf((*MockApp)(nil).GetUserWebhookDeliveries), ctx, userID, webhookID, limit)
+}
+
+// GetUserWebhooks mocks base method.
+func (m *MockApp) GetUserWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserWebhooks", ctx, userID)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserWebhooks indicates an expected call of GetUserWebhooks.
+func (mr *MockAppMockRecorder) GetUserWebhooks(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWebhooks", reflect.TypeOf((*MockApp)(nil).GetUserWebhooks), ctx, userID)
+}
+
 // GetUserWithdrawals mocks base method.
 func (m *MockApp) GetUserWithdrawals(ctx context.Context, userID int64) ([]models.Withdrawal, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +200,79 @@ func (mr *MockAppMockRecorder) GetUserWithdrawals(ctx, userID interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawals", reflect.TypeOf((*MockApp)(nil).GetUserWithdrawals), ctx, userID)
 }
 
+// GetUserWithdrawalsPage mocks base method.
+func (m *MockApp) GetUserWithdrawalsPage(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserWithdrawalsPage", ctx, userID, query)
+	ret0, _ := ret[0].(*models.WithdrawalsPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserWithdrawalsPage indicates an expected call of GetUserWithdrawalsPage.
+func (mr *MockAppMockRecorder) GetUserWithdrawalsPage(ctx, userID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawalsPage", reflect.TypeOf((*MockApp)(nil).GetUserWithdrawalsPage), ctx, userID, query)
+}
+
+// IssueTokens mocks base method.
+func (m *MockApp) IssueTokens(ctx context.Context, userID int64) (*models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", ctx, userID)
+	ret0, _ := ret[0].(*models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockAppMockRecorder) IssueTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockApp)(nil).IssueTokens), ctx, userID)
+}
+
+// JWKS mocks base method.
+func (m *MockApp) JWKS() security.JWKSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(security.JWKSet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAppMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockApp)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockApp) Logout(ctx context.Context, claims *security.Claims, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, claims, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAppMockRecorder) Logout(ctx, claims, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockApp)(nil).Logout), ctx, claims, refreshToken)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockApp) RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, refreshToken)
+	ret0, _ := ret[0].(*models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockAppMockRecorder) RefreshTokens(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockApp)(nil).RefreshTokens), ctx, refreshToken)
+}
+
 // RegisterOrder mocks base method.
 func (m *MockApp) RegisterOrder(ctx context.Context, userID int64, orderNumber string) error {
 	m.ctrl.T.Helper()
@@ -94,6 +287,21 @@ func (mr *MockAppMockRecorder) RegisterOrder(ctx, userID, orderNumber interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOrder", reflect.TypeOf((*MockApp)(nil).RegisterOrder), ctx, userID, orderNumber)
 }
 
+// RegisterOrders mocks base method.
+func (m *MockApp) RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterOrders", ctx, userID, orderNumbers)
+	ret0, _ := ret[0].([]models.OrderUploadResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterOrders indicates an expected call of RegisterOrders.
+func (mr *MockAppMockRecorder) RegisterOrders(ctx, userID, orderNumbers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOrders", reflect.TypeOf((*MockApp)(nil).RegisterOrders), ctx, userID, orderNumbers)
+}
+
 // RegisterUser mocks base method.
 func (m *MockApp) RegisterUser(ctx context.Context, user *models.User) (int64, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +317,60 @@ func (mr *MockAppMockRecorder) RegisterUser(ctx, user interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockApp)(nil).RegisterUser), ctx, user)
 }
 
+// RequestPasswordReset mocks base method.
+func (m *MockApp) RequestPasswordReset(ctx context.Context, resetReq *models.PasswordResetRequest, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, resetReq, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAppMockRecorder) RequestPasswordReset(ctx, resetReq, clientIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockApp)(nil).RequestPasswordReset), ctx, resetReq, clientIP)
+}
+
+// ResetPassword mocks base method.
+func (m *MockApp) ResetPassword(ctx context.Context, confirmReq *models.PasswordResetConfirmRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, confirmReq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAppMockRecorder) ResetPassword(ctx, confirmReq interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockApp)(nil).ResetPassword), ctx, confirmReq)
+}
+
+// SubscribeUserEvents mocks base method.
+func (m *MockApp) SubscribeUserEvents(userID, lastEventID int64) *events.Subscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeUserEvents", userID, lastEventID)
+	ret0, _ := ret[0].(*events.Subscription)
+	return ret0
+}
+
+// SubscribeUserEvents indicates an expected call of SubscribeUserEvents.
+func (mr *MockAppMockRecorder) SubscribeUserEvents(userID, lastEventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeUserEvents", reflect.TypeOf((*MockApp)(nil).SubscribeUserEvents), userID, lastEventID)
+}
+
+// UnsubscribeUserEvents mocks base method.
+func (m *MockApp) UnsubscribeUserEvents(sub *events.Subscription) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnsubscribeUserEvents", sub)
+}
+
+// UnsubscribeUserEvents indicates an expected call of UnsubscribeUserEvents.
+func (mr *MockAppMockRecorder) UnsubscribeUserEvents(sub interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsubscribeUserEvents", reflect.TypeOf((*MockApp)(nil).UnsubscribeUserEvents), sub)
+}
+
 // ValidateOrderNumber mocks base method.
 func (m *MockApp) ValidateOrderNumber(orderNumber string) bool {
 	m.ctrl.T.Helper()
@@ -124,18 +386,18 @@ func (mr *MockAppMockRecorder) ValidateOrderNumber(orderNumber interface{}) *gom
 }
 
 // ValidateUser mocks base method.
-func (m *MockApp) ValidateUser(ctx context.Context, user *models.User) (*models.User, error) {
+func (m *MockApp) ValidateUser(ctx context.Context, user *models.User, clientIP string) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateUser", ctx, user)
+	ret := m.ctrl.Call(m, "ValidateUser", ctx, user, clientIP)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ValidateUser indicates an expected call of ValidateUser.
-func (mr *MockAppMockRecorder) ValidateUser(ctx, user interface{}) *gomock.Call {
+func (mr *MockAppMockRecorder) ValidateUser(ctx, user, clientIP interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateUser", reflect.TypeOf((*MockApp)(nil).ValidateUser), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateUser", reflect.TypeOf((*MockApp)(nil).ValidateUser), ctx, user, clientIP)
 }
 
 // WithdrawFromUserBalance mocks base method.
@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/go-chi/chi"
 	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
@@ -75,15 +81,119 @@ func (h *HTTPHandler) RegisterUserOrder(rw http.ResponseWriter, req *http.Reques
 	rw.WriteHeader(http.StatusAccepted)
 }
 
+// Максимальный размер тела пакетной загрузки: с запасом вмещает
+// models.MaxOrderBatchSize номеров в любом из форматов
+const maxOrderBatchBodyBytes = 128 << 10
+
+// @Summary	Пакетная загрузка номеров заказов
+// @Description	Принимает JSON-массив номеров или, с Content-Type text/plain, номера по одному на строке.
+// @Description	Номера неверного формата пропускаются, остальные сохраняются в одной транзакции.
+// @ID			RegisterUserOrdersBatch
+// @Accept		json
+// @Accept		plain
+// @Produce	json
+// @Success	200	{array}	models.OrderUploadResult	"результаты загрузки в порядке номеров в запросе"
+// @Failure	400	"неверный формат запроса или пустой пакет"
+// @Failure	401	"пользователь не аутентифицирован"
+// @Failure	413	"в пакете больше 1000 номеров или тело запроса больше 128 КБ"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/orders/batch [post]
+// @Param		Authorization	header	string		false	"Bearer"
+// @Param		orders			body	[]string	true	"Номера заказов"
+func (h *HTTPHandler) RegisterUserOrdersBatch(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := decodeOrderNumbers(rw, req)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.handleError(rw, err, "request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.app.RegisterOrders(ctx, userID, numbers)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrEmptyOrderBatch):
+			h.handleError(rw, err, appErrors.ErrEmptyOrderBatch.Error(), http.StatusBadRequest)
+		case errors.Is(err, appErrors.ErrOrderBatchTooLarge):
+			h.handleError(rw, err, appErrors.ErrOrderBatchTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		default:
+			h.handleError(rw, err, "failed to register orders", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(results); err != nil {
+		h.handleError(rw, err, "failed to encode order upload results", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Номера заказов из тела запроса: JSON-массив или, для text/plain,
+// по одному номеру на строке без учета пустых строк. Тело ограничено
+// maxOrderBatchBodyBytes, чтобы пакет не разбирался целиком до проверки размера.
+func decodeOrderNumbers(rw http.ResponseWriter, req *http.Request) ([]string, error) {
+	req.Body = http.MaxBytesReader(rw, req.Body, maxOrderBatchBodyBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "text/plain" {
+		numbers := []string{}
+		scanner := bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			if number := strings.TrimSpace(scanner.Text()); number != "" {
+				numbers = append(numbers, number)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return numbers, nil
+	}
+
+	numbers := []string{}
+	if err := json.NewDecoder(req.Body).Decode(&numbers); err != nil {
+		return nil, err
+	}
+	return numbers, nil
+}
+
 // @Summary	Получение списка загруженных номеров заказов
+// @Description	Без параметров возвращает массив всех заказов пользователя (204, если заказов нет).
+// @Description	С любым из параметров выборки возвращает страницу заказов models.OrdersPage.
 // @ID			GetUserOrders
 // @Produce	json
-// @Success	200	"успешная обработка запроса"
+// @Success	200	{object}	models.OrdersPage	"успешная обработка запроса"
 // @Success	204	"нет данных для ответа"
+// @Failure	400	"неверные параметры выборки"
 // @Failure	401	"пользователь не авторизован"
 // @Failure	500	"внутренняя ошибка сервера"
 // @Router		/api/user/orders [get]
 // @Param		Authorization	header	string	false	"Bearer"
+// @Param		limit			query	int		false	"размер страницы, от 1 до 1000 (по умолчанию 50)"
+// @Param		cursor			query	string	false	"позиция следующей страницы из next_cursor"
+// @Param		status			query	string	false	"статусы заказов через запятую: NEW, PROCESSING, INVALID, PROCESSED"
+// @Param		uploaded_from	query	string	false	"начало периода загрузки включительно, RFC 3339"
+// @Param		uploaded_to		query	string	false	"конец периода загрузки не включительно, RFC 3339"
+// @Param		sort			query	string	false	"uploaded_at (по умолчанию) или -uploaded_at"
 func (h *HTTPHandler) GetUserOrders(rw http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 
@@ -96,6 +206,11 @@ func (h *HTTPHandler) GetUserOrders(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if hasQueryParams(req.URL.Query(), ordersQueryParams) {
+		h.getUserOrdersPage(rw, req, userID)
+		return
+	}
+
 	dbOrders, err := h.app.GetOrdersByUser(ctx, userID)
 	if err != nil {
 		h.handleError(rw, err, "failed to get user orders", http.StatusInternalServerError)
@@ -112,3 +227,116 @@ func (h *HTTPHandler) GetUserOrders(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// @Summary	Получение заказа с историей смены статусов
+// @ID			GetUserOrder
+// @Produce	json
+// @Success	200	{object}	models.OrderDetails	"успешная обработка запроса"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	403	"заказ загружен другим пользователем"
+// @Failure	404	"заказ не найден"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/orders/{number} [get]
+// @Param		Authorization	header	string	false	"Bearer"
+// @Param		number			path	string	true	"номер заказа"
+func (h *HTTPHandler) GetUserOrder(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	order, err := h.app.GetUserOrder(ctx, userID, chi.URLParam(req, "number"))
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrOrderNotFound):
+			h.handleError(rw, err, appErrors.ErrOrderNotFound.Error(), http.StatusNotFound)
+		case errors.Is(err, appErrors.ErrOrderWasUploadedByAnotherUser):
+			h.handleError(rw, err, appErrors.ErrOrderWasUploadedByAnotherUser.Error(), http.StatusForbidden)
+		default:
+			h.handleError(rw, err, "failed to get user order", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(order); err != nil {
+		h.handleError(rw, err, "failed to encode order", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Параметры постраничной выборки заказов
+var ordersQueryParams = []string{"limit", "cursor", "status", "uploaded_from", "uploaded_to", "sort"}
+
+func (h *HTTPHandler) getUserOrdersPage(rw http.ResponseWriter, req *http.Request, userID int64) {
+	query, err := parseOrdersQuery(req.URL.Query())
+	if err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.app.GetOrdersPageByUser(req.Context(), userID, query)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidOrdersQuery) || errors.Is(err, appErrors.ErrInvalidOrdersCursor) {
+			h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.handleError(rw, err, "failed to get user orders", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(page); err != nil {
+		h.handleError(rw, err, "failed to encode orders", http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseOrdersQuery(values url.Values) (*models.OrdersQuery, error) {
+	limit, err := parseLimitParam(values, models.DefaultOrdersPageLimit, appErrors.ErrInvalidOrdersQuery)
+	if err != nil {
+		return nil, err
+	}
+	query := &models.OrdersQuery{Limit: limit}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := models.ParseOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	for _, param := range values["status"] {
+		for _, status := range strings.Split(param, ",") {
+			orderStatus := models.OrderStatus(strings.ToUpper(strings.TrimSpace(status)))
+			if !orderStatus.Valid() {
+				return nil, fmt.Errorf("%w: unknown status %q", appErrors.ErrInvalidOrdersQuery, status)
+			}
+			query.Statuses = append(query.Statuses, orderStatus)
+		}
+	}
+
+	if query.UploadedFrom, err = parseTimeParam(values, "uploaded_from", appErrors.ErrInvalidOrdersQuery); err != nil {
+		return nil, err
+	}
+	if query.UploadedTo, err = parseTimeParam(values, "uploaded_to", appErrors.ErrInvalidOrdersQuery); err != nil {
+		return nil, err
+	}
+
+	switch values.Get("sort") {
+	case "", "uploaded_at":
+	case "-uploaded_at":
+		query.Descending = true
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", appErrors.ErrInvalidOrdersQuery, values.Get("sort"))
+	}
+
+	return query, nil
+}
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
@@ -138,7 +140,7 @@ func TestHandler_GetUserOrders(t *testing.T) {
 					{
 						Number:     "2377225624",
 						Status:     models.OrderStatusNew,
-						Accrual:    200,
+						Accrual:    models.NewMoney(200, 0),
 						UploadedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -191,3 +193,336 @@ func TestHandler_GetUserOrders(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RegisterUserOrdersBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userCtx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+	results := []models.OrderUploadResult{
+		{Number: "12345678903", Status: models.OrderUploadAccepted},
+		{Number: "2377225624", Status: models.OrderUploadConflict},
+	}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		contentType        string
+		reqBody            string
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "JSON Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterOrders(gomock.Any(), int64(1), []string{"12345678903", "2377225624"}).
+					Return(results, nil)
+				return mockService
+			},
+			contentType: "application/json",
+			reqBody:     "[\"12345678903\",\"2377225624\"]",
+			expectedBody: "[{\"number\":\"12345678903\",\"status\":\"accepted\"}," +
+				"{\"number\":\"2377225624\",\"status\":\"conflict\"}]\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Plain text Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterOrders(gomock.Any(), int64(1), []string{"12345678903", "2377225624"}).
+					Return(results, nil)
+				return mockService
+			},
+			contentType:        "text/plain; charset=utf-8",
+			reqBody:            "12345678903\r\n\n  2377225624  \n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid JSON Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			contentType:        "application/json",
+			reqBody:            "{\"number\":\"12345678903\"}",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Empty batch Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterOrders(gomock.Any(), int64(1), []string{}).
+					Return(nil, appErrors.ErrEmptyOrderBatch)
+				return mockService
+			},
+			contentType:        "text/plain",
+			reqBody:            "\n",
+			expectedBody:       "order batch is empty\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Too large batch Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterOrders(gomock.Any(), int64(1), gomock.Any()).
+					Return(nil, appErrors.ErrOrderBatchTooLarge)
+				return mockService
+			},
+			contentType:        "application/json",
+			reqBody:            "[\"12345678903\"]",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "Too large body Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			contentType:        "text/plain",
+			reqBody:            strings.Repeat("12345678903\n", maxOrderBatchBodyBytes/12+1),
+			expectedBody:       "request body is too large\n",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterOrders(gomock.Any(), int64(1), gomock.Any()).
+					Return(nil, errors.New("storage error"))
+				return mockService
+			},
+			contentType:        "application/json",
+			reqBody:            "[\"12345678903\"]",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			req := httptest.NewRequest("POST", "/api/user/orders/batch", bytes.NewBufferString(tt.reqBody))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(userCtx)
+			rw := httptest.NewRecorder()
+
+			handler.RegisterUserOrdersBatch(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetUserOrdersPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userCtx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &models.OrderCursor{UploadedAt: from, ID: 7, Descending: true}
+	page := &models.OrdersPage{
+		Orders: []models.Order{{
+			Number:     "2377225624",
+			Status:     models.OrderStatusProcessed,
+			Accrual:    models.NewMoney(200, 0),
+			UploadedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		}},
+		NextCursor: "next",
+	}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		target             string
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.OrdersQuery{
+					Statuses:     []models.OrderStatus{models.OrderStatusProcessed, models.OrderStatusInvalid},
+					UploadedFrom: &from,
+					Descending:   true,
+					Limit:        1,
+					After:        cursor,
+				}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetOrdersPageByUser(gomock.Any(), int64(1), query).Return(page, nil)
+				return mockService
+			},
+			target: "/api/user/orders?limit=1&status=processed,INVALID&uploaded_from=2024-01-01T00:00:00Z" +
+				"&sort=-uploaded_at&cursor=" + cursor.Encode(),
+			expectedBody: "{\"orders\":[{\"number\":\"2377225624\",\"status\":\"PROCESSED\",\"accrual\":200," +
+				"\"uploaded_at\":\"2024-01-02T00:00:00Z\"}],\"next_cursor\":\"next\"}\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Default limit Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.OrdersQuery{
+					Statuses: []models.OrderStatus{models.OrderStatusNew},
+					Limit:    models.DefaultOrdersPageLimit,
+				}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetOrdersPageByUser(gomock.Any(), int64(1), query).
+					Return(&models.OrdersPage{Orders: []models.Order{}}, nil)
+				return mockService
+			},
+			target:             "/api/user/orders?status=NEW",
+			expectedBody:       "{\"orders\":[]}\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Unknown status Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/orders?status=DONE",
+			expectedBody:       "invalid orders query: unknown status \"DONE\"\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid time Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/orders?uploaded_to=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid cursor Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/orders?cursor=broken",
+			expectedBody:       "models.parseOrderCursor: invalid orders cursor\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid limit Case",
+			mockService: func() *mocks.MockApp {
+				query := &models.OrdersQuery{Limit: 5000}
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetOrdersPageByUser(gomock.Any(), int64(1), query).
+					Return(nil, appErrors.ErrInvalidOrdersQuery)
+				return mockService
+			},
+			target:             "/api/user/orders?limit=5000",
+			expectedBody:       "invalid orders query\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req = req.WithContext(userCtx)
+			rw := httptest.NewRecorder()
+
+			handler.GetUserOrders(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetUserOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uploadedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	order := &models.OrderDetails{
+		Order: models.Order{
+			Number:     "2377225624",
+			Status:     models.OrderStatusProcessed,
+			Accrual:    models.NewMoney(500, 0),
+			UploadedAt: uploadedAt,
+		},
+		History: []models.OrderStatusChange{
+			{Status: models.OrderStatusNew, ChangedAt: uploadedAt},
+			{Status: models.OrderStatusProcessed, ChangedAt: uploadedAt.Add(time.Minute)},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserOrder(gomock.Any(), int64(1), "2377225624").Return(order, nil)
+				return mockService
+			},
+			expectedBody: "{\"number\":\"2377225624\",\"status\":\"PROCESSED\",\"accrual\":500," +
+				"\"uploaded_at\":\"2024-01-01T00:00:00Z\",\"history\":[" +
+				"{\"status\":\"NEW\",\"changed_at\":\"2024-01-01T00:00:00Z\"}," +
+				"{\"status\":\"PROCESSED\",\"changed_at\":\"2024-01-01T00:01:00Z\"}]}\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Not found Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserOrder(gomock.Any(), int64(1), "2377225624").
+					Return(nil, appErrors.ErrOrderNotFound)
+				return mockService
+			},
+			expectedBody:       "order not found\n",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Foreign order Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserOrder(gomock.Any(), int64(1), "2377225624").
+					Return(nil, appErrors.ErrOrderWasUploadedByAnotherUser)
+				return mockService
+			},
+			expectedBody:       "the order was uploaded by another user\n",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserOrder(gomock.Any(), int64(1), "2377225624").
+					Return(nil, errors.New("storage error"))
+				return mockService
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("number", "2377225624")
+			ctx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+			ctx = context.WithValue(ctx, chi.RouteCtxKey, routeCtx)
+
+			req := httptest.NewRequest("GET", "/api/user/orders/2377225624", nil).WithContext(ctx)
+			rw := httptest.NewRecorder()
+
+			handler.GetUserOrder(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
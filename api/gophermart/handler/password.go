@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	"github.com/ulixes-bloom/ya-gophermart/internal/credpolicy"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// @Summary	Смена пароля
+// @Description	Все выданные ранее токены пользователя отзываются, в ответе передается новая пара токенов
+// @ID			ChangePassword
+// @Produce	json
+// @Success	200	{object}	models.TokenPair	"пароль успешно изменен"
+// @Failure	400	{object}	CredentialsErrorResponse	"неверный формат запроса или новый пароль не соответствует политике"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	403	"неверный текущий пароль"
+// @Failure	429	"слишком много неудачных попыток ввода пароля, повторить после Retry-After секунд"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/password [post]
+// @Param		Authorization			header	string							false	"Bearer"
+// @Param		PasswordChangeRequest	body	models.PasswordChangeRequest	true	"Текущий и новый пароли"
+func (h *HTTPHandler) ChangePassword(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	changeReq := &models.PasswordChangeRequest{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(changeReq); err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.app.ChangePassword(ctx, userID, changeReq, clientIP(req))
+	if err != nil {
+		var lockedErr *loginlimit.LockedError
+		if errors.As(err, &lockedErr) {
+			setRetryAfter(rw, lockedErr.RetryAfter)
+			h.handleError(rw, err, appErrors.ErrTooManyLoginAttempts.Error(), http.StatusTooManyRequests)
+			return
+		}
+		var policyErr *credpolicy.PolicyError
+		if errors.As(err, &policyErr) {
+			h.handlePolicyError(rw, policyErr)
+			return
+		}
+		if errors.Is(err, appErrors.ErrPasswordRequired) {
+			h.handleError(rw, err, appErrors.ErrPasswordRequired.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, appErrors.ErrInvalidPassword) {
+			h.handleError(rw, err, appErrors.ErrInvalidPassword.Error(), http.StatusForbidden)
+			return
+		}
+		h.handleError(rw, err, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.app.IssueTokens(ctx, userID)
+	if err != nil {
+		h.handleError(rw, err, "failed to issue tokens", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(rw, tokens)
+}
+
+// @Summary	Запрос на сброс пароля
+// @Description	Отправляет пользователю одноразовый токен сброса пароля. Ответ не зависит от наличия пользователя с указанным логином.
+// @ID			RequestPasswordReset
+// @Success	202	"запрос принят"
+// @Failure	400	"неверный формат запроса"
+// @Failure	429	"слишком много запросов на сброс пароля, повторить после Retry-After секунд"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/password/reset [post]
+// @Param		PasswordResetRequest	body	models.PasswordResetRequest	true	"Логин пользователя"
+func (h *HTTPHandler) RequestPasswordReset(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	resetReq := &models.PasswordResetRequest{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(resetReq); err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.app.RequestPasswordReset(ctx, resetReq, clientIP(req))
+	if err != nil {
+		if errors.Is(err, appErrors.ErrUserLoginRequired) {
+			h.handleError(rw, err, appErrors.ErrUserLoginRequired.Error(), http.StatusBadRequest)
+			return
+		}
+		var lockedErr *loginlimit.LockedError
+		if errors.As(err, &lockedErr) {
+			setRetryAfter(rw, lockedErr.RetryAfter)
+			h.handleError(rw, err, appErrors.ErrTooManyPasswordResetRequests.Error(), http.StatusTooManyRequests)
+			return
+		}
+		h.handleError(rw, err, "failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary	Сброс пароля по токену
+// @Description	Токен используется однократно. Все выданные ранее токены пользователя отзываются.
+// @ID			ResetPassword
+// @Produce	json
+// @Success	200	"пароль успешно изменен"
+// @Failure	400	{object}	CredentialsErrorResponse	"неверный формат запроса, недействительный токен или новый пароль не соответствует политике"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/password/reset/confirm [post]
+// @Param		PasswordResetConfirmRequest	body	models.PasswordResetConfirmRequest	true	"Токен сброса и новый пароль"
+func (h *HTTPHandler) ResetPassword(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	confirmReq := &models.PasswordResetConfirmRequest{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(confirmReq); err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.app.ResetPassword(ctx, confirmReq)
+	if err != nil {
+		var policyErr *credpolicy.PolicyError
+		if errors.As(err, &policyErr) {
+			h.handlePolicyError(rw, policyErr)
+			return
+		}
+		if errors.Is(err, appErrors.ErrPasswordResetTokenRequired) {
+			h.handleError(rw, err, appErrors.ErrPasswordResetTokenRequired.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, appErrors.ErrPasswordResetTokenInvalid) {
+			h.handleError(rw, err, appErrors.ErrPasswordResetTokenInvalid.Error(), http.StatusBadRequest)
+			return
+		}
+		h.handleError(rw, err, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/credpolicy"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func TestHandler_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userCtx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+	changeReq := &models.PasswordChangeRequest{OldPassword: "old", NewPassword: "new"}
+	reqBody := "{\"old_password\":\"old\",\"new_password\":\"new\"}\n"
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		reqBody            *bytes.Buffer
+		ctx                context.Context
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ChangePassword(gomock.Any(), int64(1), changeReq, gomock.Any()).Return(nil)
+				mockService.EXPECT().IssueTokens(gomock.Any(), int64(1)).Return(mockTokens, nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "{\"access_token\":\"access\",\"refresh_token\":\"refresh\",\"expires_in\":900}\n",
+		},
+		{
+			name: "Wrong old password Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ChangePassword(gomock.Any(), int64(1), changeReq, gomock.Any()).Return(appErrors.ErrInvalidPassword)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       "invalid password\n",
+		},
+		{
+			name: "Too many attempts Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ChangePassword(gomock.Any(), int64(1), changeReq, "192.0.2.1").
+					Return(&loginlimit.LockedError{RetryAfter: time.Minute})
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedBody:       "too many login attempts\n",
+		},
+		{
+			name: "Policy violation Case",
+			mockService: func() *mocks.MockApp {
+				err := &credpolicy.PolicyError{Violations: []credpolicy.Violation{
+					{Field: "password", Rule: credpolicy.RulePasswordLength, Message: "too short"},
+				}}
+
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ChangePassword(gomock.Any(), int64(1), changeReq, gomock.Any()).Return(err)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: "{\"error\":\"credentials violate policy\",\"violations\":" +
+				"[{\"field\":\"password\",\"rule\":\"password_length\",\"message\":\"too short\"}]}\n",
+		},
+		{
+			name: "Invalid body Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{")),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "unexpected EOF\n",
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ChangePassword(gomock.Any(), int64(1), changeReq, gomock.Any()).Return(errors.New("storage error"))
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			ctx:                userCtx,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "failed to change password\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(tt.mockService(), config.GetDefault())
+
+			req := httptest.NewRequest("POST", "/api/user/password", tt.reqBody).WithContext(tt.ctx)
+			rw := httptest.NewRecorder()
+
+			handler.ChangePassword(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			assert.Equal(t, tt.expectedBody, rw.Body.String())
+		})
+	}
+}
+
+func TestHandler_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	resetReq := &models.PasswordResetRequest{Login: "login"}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		reqBody            *bytes.Buffer
+		expectedStatusCode int
+		expectedRetryAfter string
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RequestPasswordReset(gomock.Any(), resetReq, "192.0.2.1").Return(nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"login\":\"login\"}\n")),
+			expectedStatusCode: http.StatusAccepted,
+		},
+		{
+			name: "Empty login Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RequestPasswordReset(gomock.Any(), &models.PasswordResetRequest{}, "192.0.2.1").
+					Return(appErrors.ErrUserLoginRequired)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{}\n")),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Too many requests Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RequestPasswordReset(gomock.Any(), resetReq, "192.0.2.1").
+					Return(&loginlimit.LockedError{RetryAfter: 59500 * time.Millisecond})
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"login\":\"login\"}\n")),
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "60",
+		},
+		{
+			name: "Notifier error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RequestPasswordReset(gomock.Any(), resetReq, "192.0.2.1").Return(errors.New("notifier error"))
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"login\":\"login\"}\n")),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(tt.mockService(), config.GetDefault())
+
+			req := httptest.NewRequest("POST", "/api/user/password/reset", tt.reqBody)
+			rw := httptest.NewRecorder()
+
+			handler.RequestPasswordReset(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			assert.Equal(t, tt.expectedRetryAfter, rw.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	confirmReq := &models.PasswordResetConfirmRequest{Token: "token", NewPassword: "new"}
+	reqBody := "{\"token\":\"token\",\"new_password\":\"new\"}\n"
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		reqBody            *bytes.Buffer
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ResetPassword(gomock.Any(), confirmReq).Return(nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "",
+		},
+		{
+			name: "Invalid token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ResetPassword(gomock.Any(), confirmReq).Return(appErrors.ErrPasswordResetTokenInvalid)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "invalid, expired or used password reset token\n",
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ResetPassword(gomock.Any(), confirmReq).Return(errors.New("storage error"))
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte(reqBody)),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "failed to reset password\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(tt.mockService(), config.GetDefault())
+
+			req := httptest.NewRequest("POST", "/api/user/password/reset/confirm", tt.reqBody)
+			rw := httptest.NewRecorder()
+
+			handler.ResetPassword(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			assert.Equal(t, tt.expectedBody, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Разбор параметров постраничных выборок. Ошибки разбора оборачивают errInvalid,
+// чтобы обработчик мог ответить 400.
+
+func hasQueryParams(values url.Values, params []string) bool {
+	for _, param := range params {
+		if values.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLimitParam(values url.Values, defaultLimit int, errInvalid error) (int, error) {
+	value := values.Get("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: limit: %w", errInvalid, err)
+	}
+	return limit, nil
+}
+
+func parseTimeParam(values url.Values, param string, errInvalid error) (*time.Time, error) {
+	value := values.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalid, param, err)
+	}
+	return &t, nil
+}
+
+func parseMoneyParam(values url.Values, param string, errInvalid error) (*models.Money, error) {
+	value := values.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+
+	m, err := models.ParseMoney(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalid, param, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+// @Summary	Обновление пары токенов
+// @ID			RefreshToken
+// @Produce	json
+// @Success	200	{object}	models.TokenPair	"токены успешно обновлены"
+// @Failure	400	"неверный формат запроса"
+// @Failure	401	"refresh токен недействителен, истек или уже использован"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/token/refresh [post]
+// @Param		RefreshRequest	body	models.RefreshRequest	true	"Refresh токен"
+func (h *HTTPHandler) RefreshToken(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	refreshReq := &models.RefreshRequest{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(refreshReq); err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if refreshReq.RefreshToken == "" {
+		h.handleError(rw, nil, appErrors.ErrRefreshTokenRequired.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.app.RefreshTokens(ctx, refreshReq.RefreshToken)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrRefreshTokenReused) {
+			h.handleError(rw, err, appErrors.ErrRefreshTokenReused.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, appErrors.ErrRefreshTokenInvalid) {
+			h.handleError(rw, err, appErrors.ErrRefreshTokenInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		h.handleError(rw, err, "failed to refresh tokens", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(rw, tokens)
+}
+
+// @Summary	Выход пользователя
+// @Description	Отзывает текущий access токен и, если он передан, все семейство refresh токена
+// @ID			Logout
+// @Success	200	"пользователь успешно вышел"
+// @Failure	400	"неверный формат запроса"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/logout [post]
+// @Param		Authorization	header	string					false	"Bearer"
+// @Param		RefreshRequest	body	models.RefreshRequest	false	"Refresh токен"
+func (h *HTTPHandler) Logout(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	claims, ok := req.Context().Value(middleware.ClaimsContext).(*security.Claims)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserUnauthorized,
+			appErrors.ErrUserUnauthorized.Error(),
+			http.StatusUnauthorized)
+		return
+	}
+
+	// Тело запроса необязательно
+	logoutReq := &models.RefreshRequest{}
+	if req.Body != nil {
+		dec := json.NewDecoder(req.Body)
+		if err := dec.Decode(logoutReq); err != nil && !errors.Is(err, io.EOF) {
+			h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := h.app.Logout(ctx, claims, logoutReq.RefreshToken)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrRefreshTokenInvalid) {
+			h.handleError(rw, err, appErrors.ErrRefreshTokenInvalid.Error(), http.StatusBadRequest)
+			return
+		}
+		h.handleError(rw, err, "failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Передача пары токенов: access токен дублируется в заголовке Authorization
+func (h *HTTPHandler) writeTokens(rw http.ResponseWriter, tokens *models.TokenPair) {
+	rw.Header().Add("Authorization", fmt.Sprintf("Bearer %s", tokens.AccessToken))
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(rw).Encode(tokens); err != nil {
+		h.handleError(rw, err, "failed to encode tokens", http.StatusInternalServerError)
+		return
+	}
+}
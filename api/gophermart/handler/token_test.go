@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+func TestHandler_RefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		reqBody            *bytes.Buffer
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RefreshTokens(gomock.Any(), "refresh").Return(mockTokens, nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"refresh_token\":\"refresh\"}\n")),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "{\"access_token\":\"access\",\"refresh_token\":\"refresh\",\"expires_in\":900}\n",
+		},
+		{
+			name: "Empty refresh token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{}\n")),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "refresh token is required\n",
+		},
+		{
+			name: "Invalid refresh token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RefreshTokens(gomock.Any(), "refresh").Return(nil, appErrors.ErrRefreshTokenInvalid)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"refresh_token\":\"refresh\"}\n")),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "invalid or expired refresh token\n",
+		},
+		{
+			name: "Reused refresh token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RefreshTokens(gomock.Any(), "refresh").Return(nil, appErrors.ErrRefreshTokenReused)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"refresh_token\":\"refresh\"}\n")),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "refresh token reused\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(tt.mockService(), config.GetDefault())
+
+			req := httptest.NewRequest("POST", "/api/user/token/refresh", tt.reqBody)
+			rw := httptest.NewRecorder()
+
+			handler.RefreshToken(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			assert.Equal(t, tt.expectedBody, rw.Body.String())
+		})
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claims := &security.Claims{UserID: 1}
+	claimsCtx := context.WithValue(context.Background(), middleware.ClaimsContext, claims)
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		reqBody            *bytes.Buffer
+		ctx                context.Context
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().Logout(gomock.Any(), claims, "refresh").Return(nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"refresh_token\":\"refresh\"}\n")),
+			ctx:                claimsCtx,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Without refresh token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().Logout(gomock.Any(), claims, "").Return(nil)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer(nil),
+			ctx:                claimsCtx,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Foreign refresh token Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().Logout(gomock.Any(), claims, "refresh").Return(appErrors.ErrRefreshTokenInvalid)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer([]byte("{\"refresh_token\":\"refresh\"}\n")),
+			ctx:                claimsCtx,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().Logout(gomock.Any(), claims, "").Return(errors.New("storage error"))
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer(nil),
+			ctx:                claimsCtx,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Unauthorized Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				return mockService
+			},
+			reqBody:            bytes.NewBuffer(nil),
+			ctx:                context.Background(),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(tt.mockService(), config.GetDefault())
+
+			req := httptest.NewRequest("POST", "/api/user/logout", tt.reqBody).WithContext(tt.ctx)
+			rw := httptest.NewRecorder()
+
+			handler.Logout(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+		})
+	}
+}
+
+func TestHandler_GetJWKS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockApp(ctrl)
+	mockService.EXPECT().JWKS().Return(security.JWKSet{Keys: []security.JWK{
+		{KeyType: "OKP", KeyID: "2025-ed", Algorithm: "EdDSA", Use: "sig", Curve: "Ed25519", X: "x"},
+	}})
+	handler := New(mockService, config.GetDefault())
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rw := httptest.NewRecorder()
+
+	handler.GetJWKS(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t,
+		"{\"keys\":[{\"kty\":\"OKP\",\"kid\":\"2025-ed\",\"alg\":\"EdDSA\",\"use\":\"sig\",\"crv\":\"Ed25519\",\"x\":\"x\"}]}\n",
+		rw.Body.String())
+}
@@ -3,19 +3,24 @@ package handler
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/credpolicy"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
-	"github.com/ulixes-bloom/ya-gophermart/internal/security"
 )
 
 // @Summary	Регистрация пользователя
 // @ID			RegisterUser
 // @Produce	json
-// @Success	200	"пользователь успешно зарегистрирован и аутентифицирован"
-// @Failure	400	"неверный формат запроса"
+// @Success	200	{object}	models.TokenPair	"пользователь успешно зарегистрирован и аутентифицирован"
+// @Failure	400	{object}	CredentialsErrorResponse	"неверный формат запроса или логин и пароль не соответствуют политике"
 // @Failure	409	"логин уже занят"
 // @Failure	500	"внутренняя ошибка сервера"
 // @Router		/api/user/register [post]
@@ -42,6 +47,11 @@ func (h *HTTPHandler) RegisterUser(rw http.ResponseWriter, req *http.Request) {
 
 	createdUserID, err := h.app.RegisterUser(ctx, user)
 	if err != nil {
+		var policyErr *credpolicy.PolicyError
+		if errors.As(err, &policyErr) {
+			h.handlePolicyError(rw, policyErr)
+			return
+		}
 		if errors.Is(err, appErrors.ErrUserLoginAlreadyExists) {
 			h.handleError(rw, err, appErrors.ErrUserLoginAlreadyExists.Error(), http.StatusConflict)
 			return
@@ -50,22 +60,22 @@ func (h *HTTPHandler) RegisterUser(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	jwtToken, err := security.BuildJWTString(createdUserID, h.conf.TokenSecretKey, h.conf.TokenLifetime)
+	tokens, err := h.app.IssueTokens(ctx, createdUserID)
 	if err != nil {
-		h.handleError(rw, err, err.Error(), http.StatusInternalServerError)
+		h.handleError(rw, err, "failed to issue tokens", http.StatusInternalServerError)
 		return
 	}
 
-	rw.Header().Add("Authorization", fmt.Sprintf("Bearer %s", jwtToken))
-	rw.WriteHeader(http.StatusOK)
+	h.writeTokens(rw, tokens)
 }
 
 // @Summary	Аутентификация пользователя
 // @ID			AuthUser
 // @Produce	json
-// @Success	200	"пользователь успешно аутентифицирован"
+// @Success	200	{object}	models.TokenPair	"пользователь успешно аутентифицирован"
 // @Failure	400	"неверный формат запроса"
 // @Failure	401	"неверная пара логин/пароль"
+// @Failure	429	"слишком много неудачных попыток входа, повторить после Retry-After секунд"
 // @Failure	500	"внутренняя ошибка сервера"
 // @Router		/api/user/login [post]
 // @Param		user	body	models.User	true	"User Registration Information"
@@ -89,20 +99,46 @@ func (h *HTTPHandler) AuthUser(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	dbUser, err := h.app.ValidateUser(ctx, user)
+	dbUser, err := h.app.ValidateUser(ctx, user, clientIP(req))
 	if err != nil {
+		var lockedErr *loginlimit.LockedError
+		if errors.As(err, &lockedErr) {
+			setRetryAfter(rw, lockedErr.RetryAfter)
+			h.handleError(rw, err, appErrors.ErrTooManyLoginAttempts.Error(), http.StatusTooManyRequests)
+			return
+		}
 		h.handleError(rw, err, appErrors.ErrInvalidUserLoginOrPassword.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	jwtToken, err := security.BuildJWTString(dbUser.ID, h.conf.TokenSecretKey, h.conf.TokenLifetime)
+	tokens, err := h.app.IssueTokens(ctx, dbUser.ID)
 	if err != nil {
-		h.handleError(rw, err, err.Error(), http.StatusInternalServerError)
+		h.handleError(rw, err, "failed to issue tokens", http.StatusInternalServerError)
 		return
 	}
 
-	rw.Header().Add("Authorization", fmt.Sprintf("Bearer %s", jwtToken))
-	rw.WriteHeader(http.StatusOK)
+	h.writeTokens(rw, tokens)
+}
+
+// Ответ с перечнем нарушенных правил политики логинов и паролей
+type CredentialsErrorResponse struct {
+	Error      string                 `json:"error"`
+	Violations []credpolicy.Violation `json:"violations"`
+}
+
+func (h *HTTPHandler) handlePolicyError(rw http.ResponseWriter, policyErr *credpolicy.PolicyError) {
+	log.Error().Err(policyErr).Msg("credentials violate policy")
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusBadRequest)
+
+	err := json.NewEncoder(rw).Encode(CredentialsErrorResponse{
+		Error:      appErrors.ErrCredentialPolicyViolation.Error(),
+		Violations: policyErr.Violations,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode policy violations")
+	}
 }
 
 func checkUserCredentials(user *models.User) error {
@@ -111,3 +147,18 @@ func checkUserCredentials(user *models.User) error {
 	}
 	return nil
 }
+
+// IP адрес клиента из адреса соединения. Заголовки прокси не учитываются,
+// так как их может подделать клиент.
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// Время ожидания до следующей попытки в заголовке Retry-After, округленное вверх до секунд
+func setRetryAfter(rw http.ResponseWriter, retryAfter time.Duration) {
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
@@ -6,15 +6,24 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/credpolicy"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
 
+var mockTokens = &models.TokenPair{
+	AccessToken:  "access",
+	RefreshToken: "refresh",
+	ExpiresIn:    900,
+}
+
 func TestHandler_RegisterUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -37,6 +46,7 @@ func TestHandler_RegisterUser(t *testing.T) {
 				}
 				mockService := mocks.NewMockApp(ctrl)
 				mockService.EXPECT().RegisterUser(gomock.Any(), user).Return(int64(1), nil)
+				mockService.EXPECT().IssueTokens(gomock.Any(), int64(1)).Return(mockTokens, nil)
 				return mockService
 			},
 			conf:                      *config.GetDefault(),
@@ -88,6 +98,27 @@ func TestHandler_RegisterUser(t *testing.T) {
 			expectedHeader:            "",
 			expectedHeaderValContains: "",
 		},
+		{
+			name: "Policy violation Case",
+			mockService: func() *mocks.MockApp {
+				user := &models.User{
+					Login:    "login",
+					Password: "password",
+				}
+				err := &credpolicy.PolicyError{Violations: []credpolicy.Violation{
+					{Field: "password", Rule: credpolicy.RulePasswordBreached, Message: "breached"},
+				}}
+
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().RegisterUser(gomock.Any(), user).Return(int64(-1), err)
+				return mockService
+			},
+			conf:                      *config.GetDefault(),
+			reqBody:                   bytes.NewBuffer([]byte("{\"login\":\"login\",\"password\":\"password\"}\n")),
+			expectedStatusCode:        http.StatusBadRequest,
+			expectedHeader:            "Content-Type",
+			expectedHeaderValContains: "application/json",
+		},
 		{
 			name: "Data base error Case",
 			mockService: func() *mocks.MockApp {
@@ -150,7 +181,8 @@ func TestHandler_AuthUser(t *testing.T) {
 					Password: "password",
 				}
 				mockService := mocks.NewMockApp(ctrl)
-				mockService.EXPECT().ValidateUser(gomock.Any(), user).Return(dbUser, nil)
+				mockService.EXPECT().ValidateUser(gomock.Any(), user, "192.0.2.1").Return(dbUser, nil)
+				mockService.EXPECT().IssueTokens(gomock.Any(), dbUser.ID).Return(mockTokens, nil)
 				return mockService
 			},
 			conf:                      *config.GetDefault(),
@@ -167,7 +199,7 @@ func TestHandler_AuthUser(t *testing.T) {
 					Password: "password",
 				}
 				mockService := mocks.NewMockApp(ctrl)
-				mockService.EXPECT().ValidateUser(gomock.Any(), user).Return(nil, appErrors.ErrInvalidUserLoginOrPassword)
+				mockService.EXPECT().ValidateUser(gomock.Any(), user, "192.0.2.1").Return(nil, appErrors.ErrInvalidUserLoginOrPassword)
 				return mockService
 			},
 			conf:                      *config.GetDefault(),
@@ -176,6 +208,25 @@ func TestHandler_AuthUser(t *testing.T) {
 			expectedHeader:            "",
 			expectedHeaderValContains: "",
 		},
+		{
+			name: "Too many attempts Case",
+			mockService: func() *mocks.MockApp {
+				user := &models.User{
+					Login:    "login",
+					Password: "password",
+				}
+				err := &loginlimit.LockedError{RetryAfter: 59500 * time.Millisecond}
+
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().ValidateUser(gomock.Any(), user, "192.0.2.1").Return(nil, err)
+				return mockService
+			},
+			conf:                      *config.GetDefault(),
+			reqBody:                   bytes.NewBuffer([]byte("{\"login\":\"login\",\"password\":\"password\"}\n")),
+			expectedStatusCode:        http.StatusTooManyRequests,
+			expectedHeader:            "Retry-After",
+			expectedHeaderValContains: "60",
+		},
 		{
 			name: "Empty login Case",
 			mockService: func() *mocks.MockApp {
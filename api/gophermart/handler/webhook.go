@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// @Summary	Регистрация вебхука
+// @Description	На адрес вебхука отправляются подписанные POST запросы о смене статуса и начислениях по заказам.
+// @Description	Секрет подписи возвращается только в ответе на этот запрос.
+// @Description	Адрес должен разрешаться только в публичные IP: loopback, частные и link-local адреса не принимаются.
+// @ID			AddUserWebhook
+// @Accept		json
+// @Produce	json
+// @Success	201	{object}	models.Webhook	"вебхук зарегистрирован"
+// @Failure	400	"неверный формат запроса, адреса или адрес не публичный"
+// @Failure	401	"пользователь не аутентифицирован"
+// @Failure	409	"у пользователя уже 10 вебхуков"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/webhooks [post]
+// @Param		Authorization	header	string					false	"Bearer"
+// @Param		webhook			body	models.WebhookRequest	true	"Адрес вебхука"
+func (h *HTTPHandler) AddUserWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	if req.Body == nil {
+		h.handleError(rw, nil, "request body is missing", http.StatusBadRequest)
+		return
+	}
+
+	webhookReq := &models.WebhookRequest{}
+	if err := json.NewDecoder(req.Body).Decode(webhookReq); err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.app.AddUserWebhook(ctx, userID, webhookReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrInvalidWebhookURL):
+			h.handleError(rw, err, appErrors.ErrInvalidWebhookURL.Error(), http.StatusBadRequest)
+		case errors.Is(err, appErrors.ErrTooManyWebhooks):
+			h.handleError(rw, err, appErrors.ErrTooManyWebhooks.Error(), http.StatusConflict)
+		default:
+			h.handleError(rw, err, "failed to add user webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(webhook); err != nil {
+		h.handleError(rw, err, "failed to encode webhook", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Получение списка вебхуков
+// @ID			GetUserWebhooks
+// @Produce	json
+// @Success	200	{array}	models.Webhook	"успешная обработка запроса"
+// @Success	204	"нет данных для ответа"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/webhooks [get]
+// @Param		Authorization	header	string	false	"Bearer"
+func (h *HTTPHandler) GetUserWebhooks(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	webhooks, err := h.app.GetUserWebhooks(ctx, userID)
+	if err != nil {
+		h.handleError(rw, err, "failed to get user webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(webhooks); err != nil {
+		h.handleError(rw, err, "failed to encode webhooks", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Удаление вебхука
+// @Description	Вместе с вебхуком удаляется журнал его доставок.
+// @ID			DeleteUserWebhook
+// @Success	204	"вебхук удален"
+// @Failure	400	"неверный ID вебхука"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	404	"вебхук не найден"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/webhooks/{id} [delete]
+// @Param		Authorization	header	string	false	"Bearer"
+// @Param		id				path	int		true	"ID вебхука"
+func (h *HTTPHandler) DeleteUserWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	webhookID, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		h.handleError(rw, err, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.app.DeleteUserWebhook(ctx, userID, webhookID); err != nil {
+		if errors.Is(err, appErrors.ErrWebhookNotFound) {
+			h.handleError(rw, err, appErrors.ErrWebhookNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		h.handleError(rw, err, "failed to delete user webhook", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary	Журнал доставок на вебхук
+// @Description	Последние доставки, начиная с новых. Доставка в статусе dead исчерпала попытки и больше не отправляется.
+// @ID			GetUserWebhookDeliveries
+// @Produce	json
+// @Success	200	{array}	models.WebhookDelivery	"успешная обработка запроса"
+// @Failure	400	"неверный ID вебхука или параметры выборки"
+// @Failure	401	"пользователь не авторизован"
+// @Failure	404	"вебхук не найден"
+// @Failure	500	"внутренняя ошибка сервера"
+// @Router		/api/user/webhooks/{id}/deliveries [get]
+// @Param		Authorization	header	string	false	"Bearer"
+// @Param		id				path	int		true	"ID вебхука"
+// @Param		limit			query	int		false	"количество доставок, от 1 до 1000 (по умолчанию 50)"
+func (h *HTTPHandler) GetUserWebhookDeliveries(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	userID, ok := req.Context().Value(middleware.UserIDContext).(int64)
+	if !ok {
+		h.handleError(rw,
+			appErrors.ErrUserInalidID,
+			appErrors.ErrUserInalidID.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	webhookID, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		h.handleError(rw, err, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimitParam(req.URL.Query(), models.DefaultWebhookDeliveryLimit, appErrors.ErrInvalidWebhookDeliveryQuery)
+	if err != nil {
+		h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.app.GetUserWebhookDeliveries(ctx, userID, webhookID, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrInvalidWebhookDeliveryQuery):
+			h.handleError(rw, err, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, appErrors.ErrWebhookNotFound):
+			h.handleError(rw, err, appErrors.ErrWebhookNotFound.Error(), http.StatusNotFound)
+		default:
+			h.handleError(rw, err, "failed to get webhook deliveries", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(deliveries); err != nil {
+		h.handleError(rw, err, "failed to encode webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/handler/mocks"
+	"github.com/ulixes-bloom/ya-gophermart/api/gophermart/middleware"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func TestHandler_AddUserWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	webhookReq := &models.WebhookRequest{URL: "https://example.com/hook"}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		body               string
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().AddUserWebhook(gomock.Any(), int64(1), webhookReq).Return(&models.Webhook{
+					ID:        7,
+					UserID:    1,
+					URL:       webhookReq.URL,
+					Secret:    "secret",
+					CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				}, nil)
+				return mockService
+			},
+			body: `{"url":"https://example.com/hook"}`,
+			expectedBody: "{\"id\":7,\"url\":\"https://example.com/hook\",\"secret\":\"secret\"," +
+				"\"created_at\":\"2024-01-01T00:00:00Z\"}\n",
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "Invalid json Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			body:               `{"url":`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid url Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().AddUserWebhook(gomock.Any(), int64(1), webhookReq).
+					Return(nil, appErrors.ErrInvalidWebhookURL)
+				return mockService
+			},
+			body:               `{"url":"https://example.com/hook"}`,
+			expectedBody:       "invalid webhook url\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Too many webhooks Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().AddUserWebhook(gomock.Any(), int64(1), webhookReq).
+					Return(nil, appErrors.ErrTooManyWebhooks)
+				return mockService
+			},
+			body:               `{"url":"https://example.com/hook"}`,
+			expectedBody:       "too many webhooks\n",
+			expectedStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			ctx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+			req := httptest.NewRequest("POST", "/api/user/webhooks", bytes.NewBufferString(tt.body)).WithContext(ctx)
+			rw := httptest.NewRecorder()
+
+			handler.AddUserWebhook(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteUserWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		id                 string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().DeleteUserWebhook(gomock.Any(), int64(1), int64(7)).Return(nil)
+				return mockService
+			},
+			id:                 "7",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "Invalid id Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			id:                 "abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Not found Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().DeleteUserWebhook(gomock.Any(), int64(1), int64(7)).
+					Return(appErrors.ErrWebhookNotFound)
+				return mockService
+			},
+			id:                 "7",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+			ctx = context.WithValue(ctx, chi.RouteCtxKey, routeCtx)
+
+			req := httptest.NewRequest("DELETE", "/api/user/webhooks/"+tt.id, nil).WithContext(ctx)
+			rw := httptest.NewRecorder()
+
+			handler.DeleteUserWebhook(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+		})
+	}
+}
+
+func TestHandler_GetUserWebhookDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deliveries := []models.WebhookDelivery{
+		{
+			ID:            3,
+			WebhookID:     7,
+			EventID:       12,
+			EventType:     models.OutboxEventOrderCredited,
+			Payload:       []byte(`{"number":"2377225624","status":"PROCESSED","accrual":500}`),
+			Status:        models.WebhookDeliveryDead,
+			Attempts:      8,
+			ResponseCode:  http.StatusServiceUnavailable,
+			LastError:     "unexpected status code 503",
+			NextAttemptAt: createdAt.Add(time.Hour),
+			CreatedAt:     createdAt,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		mockService        func() *mocks.MockApp
+		target             string
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name: "Success Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().GetUserWebhookDeliveries(gomock.Any(), int64(1), int64(7), 10).Return(deliveries, nil)
+				return mockService
+			},
+			target: "/api/user/webhooks/7/deliveries?limit=10",
+			expectedBody: "[{\"id\":3,\"webhook_id\":7,\"event_id\":12,\"event_type\":\"order.credited\"," +
+				"\"payload\":{\"number\":\"2377225624\",\"status\":\"PROCESSED\",\"accrual\":500}," +
+				"\"status\":\"dead\",\"attempts\":8,\"response_code\":503,\"last_error\":\"unexpected status code 503\"," +
+				"\"next_attempt_at\":\"2024-01-01T01:00:00Z\",\"created_at\":\"2024-01-01T00:00:00Z\"}]\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Default limit Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().
+					GetUserWebhookDeliveries(gomock.Any(), int64(1), int64(7), models.DefaultWebhookDeliveryLimit).
+					Return([]models.WebhookDelivery{}, nil)
+				return mockService
+			},
+			target:             "/api/user/webhooks/7/deliveries",
+			expectedBody:       "[]\n",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid limit Case",
+			mockService: func() *mocks.MockApp {
+				return mocks.NewMockApp(ctrl)
+			},
+			target:             "/api/user/webhooks/7/deliveries?limit=abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Not found Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().
+					GetUserWebhookDeliveries(gomock.Any(), int64(1), int64(7), models.DefaultWebhookDeliveryLimit).
+					Return(nil, appErrors.ErrWebhookNotFound)
+				return mockService
+			},
+			target:             "/api/user/webhooks/7/deliveries",
+			expectedBody:       "webhook not found\n",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Storage error Case",
+			mockService: func() *mocks.MockApp {
+				mockService := mocks.NewMockApp(ctrl)
+				mockService.EXPECT().
+					GetUserWebhookDeliveries(gomock.Any(), int64(1), int64(7), models.DefaultWebhookDeliveryLimit).
+					Return(nil, errors.New("storage error"))
+				return mockService
+			},
+			target:             "/api/user/webhooks/7/deliveries",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPHandler{app: tt.mockService()}
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "7")
+			ctx := context.WithValue(context.Background(), middleware.UserIDContext, int64(1))
+			ctx = context.WithValue(ctx, chi.RouteCtxKey, routeCtx)
+
+			req := httptest.NewRequest("GET", tt.target, nil).WithContext(ctx)
+			rw := httptest.NewRecorder()
+
+			handler.GetUserWebhookDeliveries(rw, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rw.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/scheduler"
+)
+
+type healthResponse struct {
+	Status        string           `json:"status"`
+	AccrualPoller scheduler.Health `json:"accrual_poller"`
+}
+
+// @Summary	Проверка состояния сервиса
+// @ID			GetHealth
+// @Produce	json
+// @Success	200	"сервис исправен"
+// @Failure	503	"информация о заказах давно не обновлялась"
+// @Router		/health [get]
+func healthHandler(poller *scheduler.Scheduler) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		resp := healthResponse{
+			Status:        "ok",
+			AccrualPoller: poller.Health(),
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if !poller.Healthy(time.Now()) {
+			resp.Status = "degraded"
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(rw).Encode(resp); err != nil {
+			log.Error().Err(err).Msg("failed to encode health")
+		}
+	}
+}
@@ -12,9 +12,15 @@ import (
 
 const (
 	UserIDContext = "userID"
+	ClaimsContext = "claims"
 )
 
-func WithAuth(secretKey string) func(http.Handler) http.Handler {
+// Проверка access токена
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*security.Claims, error)
+}
+
+func WithAuth(auth Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 			authHeader := req.Header.Get("Authorization")
@@ -29,15 +35,16 @@ func WithAuth(secretKey string) func(http.Handler) http.Handler {
 			authHeaderSplit := strings.Split(authHeader, " ")
 			jwtToken := authHeaderSplit[1]
 
-			// Получаем userID из токена
-			userID, err := security.GetUserID(jwtToken, secretKey)
+			// Проверяем токен и получаем из него userID
+			claims, err := auth.Authenticate(req.Context(), jwtToken)
 			if err != nil {
 				handleUnauthorized(rw, "invalid JWT token")
 				return
 			}
 
-			// Добавляем userID в контекст
-			ctx := context.WithValue(req.Context(), UserIDContext, userID)
+			// Добавляем userID и данные токена в контекст
+			ctx := context.WithValue(req.Context(), UserIDContext, claims.UserID)
+			ctx = context.WithValue(ctx, ClaimsContext, claims)
 			next.ServeHTTP(rw, req.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/ulixes-bloom/ya-gophermart/internal/metrics"
+)
+
+// Запись кода ответа для метрик. Поддерживает http.Flusher,
+// необходимый потоку событий.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Учет количества и длительности запросов по шаблону маршрута, чтобы
+// параметры пути (номера заказов, ID вебхуков) не порождали новых рядов метрик.
+// Длительность потоковых ответов (text/event-stream) определяется временем
+// подключения клиента, поэтому в гистограмму не попадает.
+func WithMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: rw}
+
+		next.ServeHTTP(recorder, req)
+
+		route := "unmatched"
+		if routeCtx := chi.RouteContext(req.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		code := strconv.Itoa(recorder.status)
+
+		metrics.HTTPRequests.WithLabelValues(req.Method, route, code).Inc()
+		if !isStreaming(recorder) {
+			metrics.HTTPRequestDuration.WithLabelValues(req.Method, route, code).Observe(time.Since(start).Seconds())
+		}
+	})
+}
+
+func isStreaming(rw http.ResponseWriter) bool {
+	mediaType, _, _ := strings.Cut(rw.Header().Get("Content-Type"), ";")
+	return strings.TrimSpace(mediaType) == "text/event-stream"
+}
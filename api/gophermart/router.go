@@ -8,32 +8,59 @@ import (
 	_ "github.com/ulixes-bloom/ya-gophermart/docs"
 	"github.com/ulixes-bloom/ya-gophermart/internal/app"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/metrics"
+	"github.com/ulixes-bloom/ya-gophermart/internal/scheduler"
 )
 
-func NewRouter(app *app.App, conf *config.Config) *chi.Mux {
+func NewRouter(app *app.App, conf *config.Config, poller *scheduler.Scheduler) *chi.Mux {
 	r := chi.NewRouter()
 	h := handler.New(app, conf)
 
 	r.Use(middleware.WithLogging)
+	r.Use(middleware.WithTracing)
+	r.Use(middleware.WithMetrics)
 	r.Mount("/swagger", httpSwagger.WrapHandler)
+	r.Get("/health", healthHandler(poller))
+	r.Method("GET", "/metrics", metrics.Handler(
+		metrics.NewOrdersCollector(app.CountOrdersByStatus),
+		metrics.NewFailingOrdersCollector(app.CountChronicallyFailingOrders),
+		metrics.NewAccrualThrottleCollector(app.AccrualThrottleState),
+	))
+	r.Get("/.well-known/jwks.json", h.GetJWKS)
 
 	r.Route("/api/user", func(r chi.Router) {
 		r.Post("/register", h.RegisterUser)
 		r.Post("/login", h.AuthUser)
+		r.Post("/token/refresh", h.RefreshToken)
+		r.Post("/password/reset", h.RequestPasswordReset)
+		r.Post("/password/reset/confirm", h.ResetPassword)
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.WithAuth(conf.TokenSecretKey))
+			r.Use(middleware.WithAuth(app))
+			r.Post("/logout", h.Logout)
+			r.Post("/password", h.ChangePassword)
 			r.Route("/orders", func(r chi.Router) {
 				r.Post("/", h.RegisterUserOrder)
+				r.Post("/batch", h.RegisterUserOrdersBatch)
 				r.Get("/", h.GetUserOrders)
+				r.Get("/{number}", h.GetUserOrder)
 			})
 
 			r.Route("/balance", func(r chi.Router) {
 				r.Get("/", h.GetUserBalance)
 				r.Post("/withdraw", h.WithdrawFromUserBalance)
+				r.Get("/history", h.GetUserBalanceHistory)
 			})
 
 			r.Get("/withdrawals", h.GetUserWithdrawals)
+			r.Get("/events", h.StreamUserEvents)
+
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Post("/", h.AddUserWebhook)
+				r.Get("/", h.GetUserWebhooks)
+				r.Delete("/{id}", h.DeleteUserWebhook)
+				r.Get("/{id}/deliveries", h.GetUserWebhookDeliveries)
+			})
 		})
 	})
 
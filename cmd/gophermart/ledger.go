@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/app"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+const ledgerUsage = "usage: gophermart [flags] ledger check"
+
+// Выполнение подкоманды ledger
+func runLedger(ctx context.Context, conf *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errors.New(ledgerUsage)
+	}
+
+	// Хранилище в памяти при запуске подкоманды пустое, и сверка с ним ничего не проверяет
+	if conf.DatabaseURI == "" {
+		return errors.New("ledger: database URI is required to check balances")
+	}
+
+	storage, err := newStorage(ctx, conf)
+	if err != nil {
+		return fmt.Errorf("ledger: %w", err)
+	}
+	// Ключи подписи токенов для сверки не требуются
+	a := app.New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+	defer a.Shutdown()
+
+	discrepancies, err := a.CheckBalancesConsistency(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger: %w", err)
+	}
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("ledger: found %d balances inconsistent with ledger", len(discrepancies))
+	}
+
+	log.Info().Msg("all balances are consistent with ledger")
+	return nil
+}
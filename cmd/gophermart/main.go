@@ -2,23 +2,30 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	api "github.com/ulixes-bloom/ya-gophermart/api/gophermart"
 	"github.com/ulixes-bloom/ya-gophermart/internal/app"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
 	"github.com/ulixes-bloom/ya-gophermart/internal/storage/pg"
+	"github.com/ulixes-bloom/ya-gophermart/internal/tracing"
 )
 
 func main() {
-	ctx := context.Background()
+	// Контекст отменяется по сигналу остановки
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	conf, err := config.Parse()
 	if err != nil {
-		log.Error().Msg(err.Error())
+		log.Fatal().Err(err).Msg("failed to parse config")
 	}
 
 	logLvl, err := zerolog.ParseLevel(conf.LogLvl)
@@ -27,20 +34,88 @@ func main() {
 	}
 	zerolog.SetGlobalLevel(logLvl)
 
-	db, err := sql.Open("pgx", conf.DatabaseURI)
+	// Выполнение подкоманд
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(ctx, conf, args[1:]); err != nil {
+				log.Fatal().Err(err).Msg("failed to run migrations")
+			}
+		case "ledger":
+			if err := runLedger(ctx, conf, args[1:]); err != nil {
+				log.Fatal().Err(err).Msg("failed to check ledger")
+			}
+		default:
+			log.Fatal().Msgf("unknown command %q", args[0])
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, conf)
 	if err != nil {
-		log.Error().Msg(err.Error())
+		log.Fatal().Err(err).Msg("failed to initialize tracing")
 	}
 
-	storage, err := pg.NewStorage(ctx, db)
+	storage, err := newStorage(ctx, conf)
 	if err != nil {
-		log.Error().Msg(err.Error())
+		log.Fatal().Err(err).Msg("failed to initialize storage")
 	}
-	app := app.New(storage, conf)
+	keyring, err := newKeyring(conf)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load token signing keys")
+	}
+	app := app.New(storage, keyring, conf)
 
 	api := api.New(conf, app)
 	err = api.Run(ctx)
+
+	// Отправка накопленных span'ов
+	tracingCtx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.Error().Err(err).Msg("failed to flush traces")
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("service stopped with error")
+	}
+	log.Info().Msg("service stopped")
+}
+
+// Выбор хранилища: при пустой строке подключения к БД данные хранятся в памяти
+func newStorage(ctx context.Context, conf *config.Config) (app.Storage, error) {
+	if conf.DatabaseURI == "" {
+		log.Info().Msg("database URI is empty, using in-memory storage")
+		return memory.NewStorage(), nil
+	}
+
+	db, err := pg.OpenDB(conf.DatabaseURI)
 	if err != nil {
-		log.Panic().Err(err)
+		return nil, err
 	}
+
+	storage, err := pg.NewStorage(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Набор ключей подписи токенов: из каталога ключей, а если он не задан — из секрета TokenSecretKey
+func newKeyring(conf *config.Config) (*security.Keyring, error) {
+	if conf.TokenKeysDir == "" {
+		if conf.TokenSecretKey == config.GetDefault().TokenSecretKey {
+			log.Warn().Msg("tokens are signed with default secret key, set -k or -kd")
+		}
+		return security.NewHMACKeyring(conf.TokenSecretKey), nil
+	}
+
+	keyring, err := security.LoadKeyring(conf.TokenKeysDir, conf.TokenKeyID)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("kid", keyring.ActiveKeyID()).Msg("token signing keys loaded")
+
+	return keyring, nil
 }
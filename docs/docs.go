@@ -15,6 +15,26 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получение открытых ключей проверки токенов",
+                "operationId": "GetJWKS",
+                "responses": {
+                    "200": {
+                        "description": "набор открытых ключей",
+                        "schema": {
+                            "$ref": "#/definitions/security.JWKSet"
+                        }
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
         "/api/user/balance": {
             "get": {
                 "produces": [
@@ -43,6 +63,43 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/user/balance/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получение истории движения баллов",
+                "operationId": "GetUserBalanceHistory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LedgerEntry"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "нет ни одной проводки"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
         "/api/user/balance/withdraw": {
             "post": {
                 "produces": [
@@ -71,6 +128,9 @@ const docTemplate = `{
                     "200": {
                         "description": "успешная обработка запроса"
                     },
+                    "400": {
+                        "description": "неверный формат запроса или неположительная сумма списания"
+                    },
                     "401": {
                         "description": "пользователь не авторизован"
                     },
@@ -86,6 +146,44 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/user/events": {
+            "get": {
+                "description": "Server-Sent Events поток: событие order при смене статуса заказа, balance при начислении баллов.\nПри переподключении с заголовком Last-Event-ID передаются пропущенные события из буфера сервиса.\nЕсли пропущенные события недоступны, передается событие reset, после которого состояние нужно запросить заново.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Поток обновлений заказов и баланса",
+                "operationId": "StreamUserEvents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "поток событий"
+                    },
+                    "400": {
+                        "description": "неверный заголовок Last-Event-ID"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
         "/api/user/login": {
             "post": {
                 "produces": [
@@ -106,7 +204,10 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "пользователь успешно аутентифицирован"
+                        "description": "пользователь успешно аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenPair"
+                        }
                     },
                     "400": {
                         "description": "неверный формат запроса"
@@ -114,6 +215,46 @@ const docTemplate = `{
                     "401": {
                         "description": "неверная пара логин/пароль"
                     },
+                    "429": {
+                        "description": "слишком много неудачных попыток входа, повторить после Retry-After секунд"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/logout": {
+            "post": {
+                "description": "Отзывает текущий access токен и, если он передан, все семейство refresh токена",
+                "summary": "Выход пользователя",
+                "operationId": "Logout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Refresh токен",
+                        "name": "RefreshRequest",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "пользователь успешно вышел"
+                    },
+                    "400": {
+                        "description": "неверный формат запроса"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
                     "500": {
                         "description": "внутренняя ошибка сервера"
                     }
@@ -122,6 +263,7 @@ const docTemplate = `{
         },
         "/api/user/orders": {
             "get": {
+                "description": "Без параметров возвращает массив всех заказов пользователя (204, если заказов нет).\nС любым из параметров выборки возвращает страницу заказов models.OrdersPage.",
                 "produces": [
                     "application/json"
                 ],
@@ -133,15 +275,57 @@ const docTemplate = `{
                         "description": "Bearer",
                         "name": "Authorization",
                         "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "размер страницы, от 1 до 1000 (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "позиция следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "статусы заказов через запятую: NEW, PROCESSING, INVALID, PROCESSED",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "начало периода загрузки включительно, RFC 3339",
+                        "name": "uploaded_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода загрузки не включительно, RFC 3339",
+                        "name": "uploaded_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "uploaded_at (по умолчанию) или -uploaded_at",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "успешная обработка запроса"
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrdersPage"
+                        }
                     },
                     "204": {
                         "description": "нет данных для ответа"
                     },
+                    "400": {
+                        "description": "неверные параметры выборки"
+                    },
                     "401": {
                         "description": "пользователь не авторизован"
                     },
@@ -198,33 +382,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/register": {
+        "/api/user/orders/batch": {
             "post": {
+                "description": "Принимает JSON-массив номеров или, с Content-Type text/plain, номера по одному на строке.\nНомера неверного формата пропускаются, остальные сохраняются в одной транзакции.",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Регистрация пользователя",
-                "operationId": "RegisterUser",
+                "summary": "Пакетная загрузка номеров заказов",
+                "operationId": "RegisterUserOrdersBatch",
                 "parameters": [
                     {
-                        "description": "User Registration Information",
-                        "name": "user",
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Номера заказов",
+                        "name": "orders",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "пользователь успешно зарегистрирован и аутентифицирован"
+                        "description": "результаты загрузки в порядке номеров в запросе",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderUploadResult"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "неверный формат запроса"
+                        "description": "неверный формат запроса или пустой пакет"
                     },
-                    "409": {
-                        "description": "логин уже занят"
+                    "401": {
+                        "description": "пользователь не аутентифицирован"
+                    },
+                    "413": {
+                        "description": "в пакете больше 1000 номеров или тело запроса больше 128 КБ"
                     },
                     "500": {
                         "description": "внутренняя ошибка сервера"
@@ -232,55 +439,941 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/withdrawals": {
+        "/api/user/orders/{number}": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Получение информации о выводе средств",
-                "operationId": "GetUserWithdrawals",
+                "summary": "Получение заказа с историей смены статусов",
+                "operationId": "GetUserOrder",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Bearer",
                         "name": "Authorization",
                         "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "номер заказа",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "успешная обработка запроса"
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderDetails"
+                        }
                     },
-                    "204": {
-                        "description": "нет ни одного списания"
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "403": {
+                        "description": "заказ загружен другим пользователем"
+                    },
+                    "404": {
+                        "description": "заказ не найден"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/password": {
+            "post": {
+                "description": "Все выданные ранее токены пользователя отзываются, в ответе передается новая пара токенов",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Смена пароля",
+                "operationId": "ChangePassword",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Текущий и новый пароли",
+                        "name": "PasswordChangeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "пароль успешно изменен",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "неверный формат запроса или новый пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CredentialsErrorResponse"
+                        }
                     },
                     "401": {
                         "description": "пользователь не авторизован"
                     },
+                    "403": {
+                        "description": "неверный текущий пароль"
+                    },
+                    "429": {
+                        "description": "слишком много неудачных попыток ввода пароля, повторить после Retry-After секунд"
+                    },
                     "500": {
                         "description": "внутренняя ошибка сервера"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.OrderRequest": {
-            "type": "object",
-            "properties": {
-                "number": {
-                    "type": "string"
+        },
+        "/api/user/password/reset": {
+            "post": {
+                "description": "Отправляет пользователю одноразовый токен сброса пароля. Ответ не зависит от наличия пользователя с указанным логином.",
+                "summary": "Запрос на сброс пароля",
+                "operationId": "RequestPasswordReset",
+                "parameters": [
+                    {
+                        "description": "Логин пользователя",
+                        "name": "PasswordResetRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordResetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "запрос принят"
+                    },
+                    "400": {
+                        "description": "неверный формат запроса"
+                    },
+                    "429": {
+                        "description": "слишком много запросов на сброс пароля, повторить после Retry-After секунд"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
                 }
             }
         },
-        "models.User": {
-            "type": "object",
-            "properties": {
-                "login": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
+        "/api/user/password/reset/confirm": {
+            "post": {
+                "description": "Токен используется однократно. Все выданные ранее токены пользователя отзываются.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Сброс пароля по токену",
+                "operationId": "ResetPassword",
+                "parameters": [
+                    {
+                        "description": "Токен сброса и новый пароль",
+                        "name": "PasswordResetConfirmRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordResetConfirmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "пароль успешно изменен"
+                    },
+                    "400": {
+                        "description": "неверный формат запроса, недействительный токен или новый пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CredentialsErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/register": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Регистрация пользователя",
+                "operationId": "RegisterUser",
+                "parameters": [
+                    {
+                        "description": "User Registration Information",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "пользователь успешно зарегистрирован и аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "неверный формат запроса или логин и пароль не соответствуют политике",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CredentialsErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "логин уже занят"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/token/refresh": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Обновление пары токенов",
+                "operationId": "RefreshToken",
+                "parameters": [
+                    {
+                        "description": "Refresh токен",
+                        "name": "RefreshRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "токены успешно обновлены",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "неверный формат запроса"
+                    },
+                    "401": {
+                        "description": "refresh токен недействителен, истек или уже использован"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получение списка вебхуков",
+                "operationId": "GetUserWebhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "нет данных для ответа"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            },
+            "post": {
+                "description": "На адрес вебхука отправляются подписанные POST запросы о смене статуса и начислениях по заказам.\nСекрет подписи возвращается только в ответе на этот запрос.\nАдрес должен разрешаться только в публичные IP: loopback, частные и link-local адреса не принимаются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Регистрация вебхука",
+                "operationId": "AddUserWebhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Адрес вебхука",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "вебхук зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "неверный формат запроса, адреса или адрес не публичный"
+                    },
+                    "401": {
+                        "description": "пользователь не аутентифицирован"
+                    },
+                    "409": {
+                        "description": "у пользователя уже 10 вебхуков"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/webhooks/{id}": {
+            "delete": {
+                "description": "Вместе с вебхуком удаляется журнал его доставок.",
+                "summary": "Удаление вебхука",
+                "operationId": "DeleteUserWebhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "вебхук удален"
+                    },
+                    "400": {
+                        "description": "неверный ID вебхука"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "404": {
+                        "description": "вебхук не найден"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Последние доставки, начиная с новых. Доставка в статусе dead исчерпала попытки и больше не отправляется.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Журнал доставок на вебхук",
+                "operationId": "GetUserWebhookDeliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "количество доставок, от 1 до 1000 (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "неверный ID вебхука или параметры выборки"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "404": {
+                        "description": "вебхук не найден"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/api/user/withdrawals": {
+            "get": {
+                "description": "Без параметров возвращает массив всех списаний пользователя (204, если списаний нет).\nС любым из параметров выборки возвращает страницу списаний models.WithdrawalsPage\nс итогами по всем списаниям, подходящим под фильтры.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получение информации о выводе средств",
+                "operationId": "GetUserWithdrawals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "размер страницы, от 1 до 1000 (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "позиция следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "начало периода списания включительно, RFC 3339",
+                        "name": "processed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода списания не включительно, RFC 3339",
+                        "name": "processed_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "минимальная сумма списания включительно",
+                        "name": "min_sum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "максимальная сумма списания включительно",
+                        "name": "max_sum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "processed_at (по умолчанию) или -processed_at",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "успешная обработка запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.WithdrawalsPage"
+                        }
+                    },
+                    "204": {
+                        "description": "нет ни одного списания"
+                    },
+                    "400": {
+                        "description": "неверные параметры выборки"
+                    },
+                    "401": {
+                        "description": "пользователь не авторизован"
+                    },
+                    "500": {
+                        "description": "внутренняя ошибка сервера"
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Проверка состояния сервиса",
+                "operationId": "GetHealth",
+                "responses": {
+                    "200": {
+                        "description": "сервис исправен"
+                    },
+                    "503": {
+                        "description": "информация о заказах давно не обновлялась"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "credpolicy.Violation": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.CredentialsErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/credpolicy.Violation"
+                    }
+                }
+            }
+        },
+        "models.AccrualStatus": {
+            "type": "string",
+            "enum": [
+                "REGISTERED",
+                "PROCESSING",
+                "INVALID",
+                "PROCESSED"
+            ],
+            "x-enum-varnames": [
+                "AccrualStatusRegistered",
+                "AccrualStatusProcessing",
+                "AccrualStatusInvalid",
+                "AccrualStatusProcessed"
+            ]
+        },
+        "models.LedgerAccount": {
+            "type": "string",
+            "enum": [
+                "user",
+                "accruals",
+                "withdrawals",
+                "adjustments"
+            ],
+            "x-enum-comments": {
+                "LedgerAccountAccruals": "источник начислений за заказы",
+                "LedgerAccountAdjustments": "корректировки при переносе истории",
+                "LedgerAccountUser": "счет баллов пользователя",
+                "LedgerAccountWithdrawals": "списания в счет оплаты заказов"
+            },
+            "x-enum-varnames": [
+                "LedgerAccountUser",
+                "LedgerAccountAccruals",
+                "LedgerAccountWithdrawals",
+                "LedgerAccountAdjustments"
+            ]
+        },
+        "models.LedgerEntry": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "credit_account": {
+                    "$ref": "#/definitions/models.LedgerAccount"
+                },
+                "debit_account": {
+                    "$ref": "#/definitions/models.LedgerAccount"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.LedgerEntryKind"
+                },
+                "order": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LedgerEntryKind": {
+            "type": "string",
+            "enum": [
+                "ACCRUAL",
+                "WITHDRAWAL",
+                "ADJUSTMENT"
+            ],
+            "x-enum-comments": {
+                "LedgerEntryAdjustment": "корректировки расхождений при переносе истории в журнал"
+            },
+            "x-enum-varnames": [
+                "LedgerEntryAccrual",
+                "LedgerEntryWithdrawal",
+                "LedgerEntryAdjustment"
+            ]
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderDetails": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderStatusChange"
+                    }
+                },
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "NEW",
+                "PROCESSING",
+                "INVALID",
+                "PROCESSED"
+            ],
+            "x-enum-varnames": [
+                "OrderStatusNew",
+                "OrderStatusProcessing",
+                "OrderStatusInvalid",
+                "OrderStatusProcessed"
+            ]
+        },
+        "models.OrderStatusChange": {
+            "type": "object",
+            "properties": {
+                "accrual_status": {
+                    "description": "пусто для статуса NEW при загрузке",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccrualStatus"
+                        }
+                    ]
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
+        "models.OrderUploadResult": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderUploadStatus"
+                }
+            }
+        },
+        "models.OrderUploadStatus": {
+            "type": "string",
+            "enum": [
+                "accepted",
+                "already_uploaded",
+                "conflict",
+                "invalid"
+            ],
+            "x-enum-comments": {
+                "OrderUploadAccepted": "новый номер принят в обработку",
+                "OrderUploadAlreadyUploaded": "номер уже загружен этим пользователем",
+                "OrderUploadConflict": "номер уже загружен другим пользователем",
+                "OrderUploadInvalid": "неверный формат номера"
+            },
+            "x-enum-varnames": [
+                "OrderUploadAccepted",
+                "OrderUploadAlreadyUploaded",
+                "OrderUploadConflict",
+                "OrderUploadInvalid"
+            ]
+        },
+        "models.OrdersPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "пусто на последней странице",
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "models.OutboxEventType": {
+            "type": "string",
+            "enum": [
+                "order.registered",
+                "order.status_changed",
+                "order.credited",
+                "balance.withdrawn"
+            ],
+            "x-enum-comments": {
+                "OutboxEventOrderStatusChanged": "смена статуса, кроме перехода в PROCESSED"
+            },
+            "x-enum-varnames": [
+                "OutboxEventOrderRegistered",
+                "OutboxEventOrderStatusChanged",
+                "OutboxEventOrderCredited",
+                "OutboxEventBalanceWithdrawn"
+            ]
+        },
+        "models.PasswordChangeRequest": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "old_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PasswordResetConfirmRequest": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PasswordResetRequest": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TokenPair": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "время жизни access токена в секундах",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "description": "только в ответе на регистрацию",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/models.OutboxEventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_code": {
+                    "description": "код ответа последней попытки",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "pending, delivered или dead",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.WebhookDeliveryStatus"
+                        }
+                    ]
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "dead"
+            ],
+            "x-enum-comments": {
+                "WebhookDeliveryDead": "попытки исчерпаны, доставка прекращена",
+                "WebhookDeliveryDelivered": "получен ответ 2xx",
+                "WebhookDeliveryPending": "ожидает очередной попытки"
+            },
+            "x-enum-varnames": [
+                "WebhookDeliveryPending",
+                "WebhookDeliveryDelivered",
+                "WebhookDeliveryDead"
+            ]
+        },
+        "models.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "description": "абсолютный http(s) адрес, разрешающийся в публичные IP",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Withdrawal": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "type": "string"
+                },
+                "processed_at": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
                 }
             }
         },
@@ -294,6 +1387,79 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "models.WithdrawalsPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "пусто на последней странице",
+                    "type": "string"
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.WithdrawalsSummary"
+                },
+                "withdrawals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Withdrawal"
+                    }
+                }
+            }
+        },
+        "models.WithdrawalsSummary": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "security.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "кривая OKP",
+                    "type": "string"
+                },
+                "e": {
+                    "description": "экспонента RSA",
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "модуль RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "description": "открытый ключ OKP",
+                    "type": "string"
+                }
+            }
+        },
+        "security.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/security.JWK"
+                    }
+                }
+            }
         }
     }
 }`
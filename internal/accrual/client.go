@@ -6,16 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/metrics"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 	"github.com/ulixes-bloom/ya-gophermart/internal/workerpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Максимальное количество попыток запроса информации по заказу,
+// прерванных ограничением количества запросов
+const maxThrottledAttempts = 3
+
+const tracerName = "github.com/ulixes-bloom/ya-gophermart/internal/accrual"
+
 type Client struct {
-	http HTTPClient
-	conf *config.Config
+	http     HTTPClient
+	conf     *config.Config
+	throttle throttle // общий для всех worker'ов шлюз ограничения запросов
 }
 
 func NewClient(conf *config.Config) *Client {
@@ -25,32 +43,106 @@ func NewClient(conf *config.Config) *Client {
 	}
 }
 
-func (ac *Client) GetOrdersInfo(ctx context.Context, orders []models.Order) ([]models.Order, error) {
-	wp := workerpool.New(ctx, ac.conf.AccrualRateLimit, ac.conf.AccrualRateLimit*2, ac.GetOrderInfo)
+// Ошибка получения информации по конкретному заказу
+type OrderError struct {
+	Order models.Order
+	Err   error
+}
+
+func (e *OrderError) Error() string {
+	return fmt.Sprintf("order %s: %v", e.Order.Number, e.Err)
+}
+
+func (e *OrderError) Unwrap() error {
+	return e.Err
+}
+
+// Получение информации по заказам. Возвращает успешно обработанные заказы
+// и отчет об ошибках по каждому заказу, информацию по которому получить не удалось.
+func (ac *Client) GetOrdersInfo(ctx context.Context, orders []models.Order) ([]models.Order, []OrderError) {
+	wp := workerpool.New(ctx, "accrual", ac.conf.AccrualRateLimit, ac.conf.AccrualRateLimit*2, ac.getOrderInfoWithRetry)
 	resOrders := make([]models.Order, 0, len(orders))
+	orderErrs := []OrderError{}
+
+	// Результаты читаются параллельно с добавлением job'ов,
+	// чтобы worker'ы не блокировались на заполненных каналах результатов
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for order := range wp.Results() {
+			resOrders = append(resOrders, *order)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for err := range wp.Errors() {
+			var orderErr *OrderError
+			if !errors.As(err, &orderErr) {
+				log.Error().Err(err).Msg("unexpected error without order info")
+				continue
+			}
+			orderErrs = append(orderErrs, *orderErr)
+		}
+	}()
 
 	for i := range orders {
 		wp.Submit(&orders[i])
 	}
 	wp.StopAndWait()
+	wg.Wait()
 
-	for order := range wp.Results() {
-		resOrders = append(resOrders, *order)
-	}
+	return resOrders, orderErrs
+}
 
-	var resultErr error
-	for err := range wp.Errors() {
-		resultErr = errors.Join(resultErr, err)
-	}
+// Текущее состояние ограничения запросов к системе расчета начислений
+func (ac *Client) ThrottleState() ThrottleState {
+	return ac.throttle.State()
+}
 
-	if resultErr != nil {
-		return nil, resultErr
-	}
+// Получение информации по заказу с повторением запросов, прерванных
+// ограничением количества запросов. Повторный запрос выполняется после окончания паузы.
+func (ac *Client) getOrderInfoWithRetry(ctx context.Context, order *models.Order) (*models.Order, error) {
+	for attempt := 1; ; attempt++ {
+		resOrder, err := ac.GetOrderInfo(ctx, order)
+		if err == nil {
+			return resOrder, nil
+		}
+		if !errors.Is(err, appErrors.ErrAccrualTooManyRequests) || attempt >= maxThrottledAttempts {
+			return nil, &OrderError{Order: *order, Err: err}
+		}
 
-	return resOrders, nil
+		log.Debug().
+			Str("order", order.Number).
+			Int("attempt", attempt).
+			Msg("order info request rescheduled due to accrual service throttling")
+	}
 }
 
+// Запрос информации по заказу. Запрос оборачивается в span, контекст
+// трассировки передается системе расчета начислений в заголовке traceparent.
 func (ac *Client) GetOrderInfo(ctx context.Context, order *models.Order) (*models.Order, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "accrual.GetOrderInfo",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("order.number", order.Number)))
+	defer span.End()
+
+	resOrder, err := ac.getOrderInfo(ctx, order)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("accrual.status", string(resOrder.AccrualStatus)))
+	return resOrder, nil
+}
+
+func (ac *Client) getOrderInfo(ctx context.Context, order *models.Order) (*models.Order, error) {
+	// Ожидание окончания паузы, объявленной системой расчета начислений
+	if err := ac.throttle.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("accrual.getOrderInfo.waitThrottle: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		ac.conf.NormilizedAccrualSysAddr()+"/api/orders/"+order.Number,
 		nil)
@@ -58,12 +150,16 @@ func (ac *Client) GetOrderInfo(ctx context.Context, order *models.Order) (*model
 		return nil, fmt.Errorf("accrual.getOrderInfo: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	resp, err := ac.http.Do(req)
 	if err != nil {
+		metrics.AccrualRequests.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("accrual.getOrderInfo.doRequest: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.AccrualRequests.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	switch resp.StatusCode {
 	case http.StatusOK:
@@ -74,15 +170,23 @@ func (ac *Client) GetOrderInfo(ctx context.Context, order *models.Order) (*model
 		}
 
 		order := &models.Order{
-			UserID:  order.UserID,
-			Number:  accrualResp.OrderNumber,
-			Status:  mapAccrualResponseStatus(accrualResp.AccrualStatus),
-			Accrual: accrualResp.Accrual,
+			UserID:        order.UserID,
+			Number:        accrualResp.OrderNumber,
+			Status:        mapAccrualResponseStatus(accrualResp.AccrualStatus),
+			AccrualStatus: accrualResp.AccrualStatus,
+			Accrual:       accrualResp.Accrual,
 		}
 		return order, nil
 	case http.StatusNoContent:
 		return nil, errors.Join(appErrors.ErrAccrualOrderNotRegistered, fmt.Errorf("accrual.getOrderInfo: order %s", order.Number))
 	case http.StatusTooManyRequests:
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		until := ac.throttle.Pause(retryAfter)
+		log.Warn().
+			Str("order", order.Number).
+			Dur("retry_after", retryAfter).
+			Time("throttled_until", until).
+			Msg("accrual service throttled requests, pausing all workers")
 		return nil, errors.Join(appErrors.ErrAccrualTooManyRequests, fmt.Errorf("accrual.getOrderInfo: order %s", order.Number))
 	default:
 		return nil, fmt.Errorf("accrual.getOrderInfo: %d", resp.StatusCode)
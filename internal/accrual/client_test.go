@@ -3,16 +3,26 @@ package accrual
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/ulixes-bloom/ya-gophermart/internal/accrual/mocks"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestAccrualClient_GetOrderInfo(t *testing.T) {
@@ -41,9 +51,10 @@ func TestAccrualClient_GetOrderInfo(t *testing.T) {
 			},
 			conf: config.GetDefault(),
 			expectedOrder: &models.Order{
-				Number:  "2377225624",
-				Status:  models.OrderStatusNew,
-				Accrual: 200,
+				Number:        "2377225624",
+				Status:        models.OrderStatusNew,
+				AccrualStatus: models.AccrualStatusRegistered,
+				Accrual:       models.NewMoney(200, 0),
 			},
 			expectedErr: nil,
 		},
@@ -100,3 +111,134 @@ func TestAccrualClient_GetOrderInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestAccrualClient_GetOrdersInfo_RetryAfter(t *testing.T) {
+	const retryAfter = time.Second
+
+	var (
+		mu           sync.Mutex
+		throttled    bool
+		throttledAt  time.Time
+		requestTimes []time.Time
+	)
+
+	// Тестовая система расчета начислений, ограничивающая первый запрос
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !throttled {
+			throttled = true
+			throttledAt = time.Now()
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		requestTimes = append(requestTimes, time.Now())
+
+		number := strings.TrimPrefix(req.URL.Path, "/api/orders/")
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rw, `{"order":"%s","status":"PROCESSED","accrual":100}`, number)
+	}))
+	defer srv.Close()
+
+	conf := config.GetDefault()
+	conf.AccrualSysAddr = strings.TrimPrefix(srv.URL, "http://")
+	ac := NewClient(conf)
+
+	orders := []models.Order{
+		{Number: "12345678903"},
+		{Number: "2377225624"},
+		{Number: "79927398713"},
+		{Number: "4561261212345467"},
+	}
+	resOrders, orderErrs := ac.GetOrdersInfo(context.Background(), orders)
+	require.Empty(t, orderErrs)
+
+	// Ограниченный заказ повторно запрошен и все заказы обработаны
+	assert.Len(t, resOrders, len(orders))
+
+	// После ответа 429 ни один worker не обращался к системе до окончания паузы.
+	// Запросы, отправленные одновременно с ограниченным, не учитываются.
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, requestTimes)
+	for _, requestTime := range requestTimes {
+		sinceThrottled := requestTime.Sub(throttledAt)
+		if sinceThrottled < 100*time.Millisecond {
+			continue
+		}
+		assert.GreaterOrEqual(t, sinceThrottled, retryAfter-50*time.Millisecond)
+	}
+	assert.False(t, ac.ThrottleState().Throttled)
+}
+
+func TestAccrualClient_GetOrdersInfo_PartialSuccess(t *testing.T) {
+	// Тестовая система расчета начислений, не знающая заказы с номерами, оканчивающимися на 0
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		number := strings.TrimPrefix(req.URL.Path, "/api/orders/")
+		if strings.HasSuffix(number, "0") {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rw, `{"order":"%s","status":"PROCESSED","accrual":100}`, number)
+	}))
+	defer srv.Close()
+
+	conf := config.GetDefault()
+	conf.AccrualSysAddr = strings.TrimPrefix(srv.URL, "http://")
+	ac := NewClient(conf)
+
+	// Количество заказов превышает размер буферов пула worker'ов
+	const ordersCount = 100
+	orders := make([]models.Order, 0, ordersCount)
+	for i := range ordersCount {
+		orders = append(orders, models.Order{Number: fmt.Sprintf("%d", 1000+i)})
+	}
+
+	resOrders, orderErrs := ac.GetOrdersInfo(context.Background(), orders)
+
+	assert.Len(t, resOrders, ordersCount-ordersCount/10)
+	require.Len(t, orderErrs, ordersCount/10)
+	for _, orderErr := range orderErrs {
+		assert.True(t, strings.HasSuffix(orderErr.Order.Number, "0"))
+		assert.ErrorIs(t, &orderErr, appErrors.ErrAccrualOrderNotRegistered)
+	}
+}
+
+func TestAccrualClient_GetOrderInfo_Tracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	// Система расчета начислений получает контекст трассировки запроса
+	traceparent := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		traceparent <- req.Header.Get("traceparent")
+		fmt.Fprint(rw, `{"order":"2377225624","status":"PROCESSED","accrual":200}`)
+	}))
+	defer srv.Close()
+
+	conf := config.GetDefault()
+	conf.AccrualSysAddr = strings.TrimPrefix(srv.URL, "http://")
+	client := NewClient(conf)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, err := client.GetOrderInfo(ctx, &models.Order{Number: "2377225624"})
+	require.NoError(t, err)
+	parent.End()
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	span := ended[0]
+	assert.Equal(t, "accrual.GetOrderInfo", span.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, fmt.Sprintf("00-%s-%s-01", span.SpanContext().TraceID(), span.SpanContext().SpanID()), <-traceparent)
+}
@@ -0,0 +1,94 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Пауза по умолчанию, если система расчета начислений не передала заголовок Retry-After
+const defaultRetryAfter = 60 * time.Second
+
+type (
+	// Общий для всех worker'ов шлюз, приостанавливающий запросы к системе
+	// расчета начислений после получения ответа 429 Too Many Requests
+	throttle struct {
+		mu    sync.Mutex
+		until time.Time // время, до которого запросы приостановлены
+	}
+
+	// Текущее состояние ограничения запросов
+	ThrottleState struct {
+		Throttled bool
+		Until     time.Time
+	}
+)
+
+// Ожидание открытия шлюза
+func (t *throttle) Wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		delay := time.Until(t.until)
+		t.mu.Unlock()
+
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			// Пауза могла быть продлена, пока worker ожидал
+		}
+	}
+}
+
+// Приостановка запросов на указанную длительность. Уже действующая
+// более длительная пауза не сокращается. Возвращает время окончания паузы.
+func (t *throttle) Pause(d time.Duration) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+	return t.until
+}
+
+func (t *throttle) State() ThrottleState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return ThrottleState{
+		Throttled: time.Now().Before(t.until),
+		Until:     t.until,
+	}
+}
+
+// Разбор заголовка Retry-After, заданного в секундах или в виде HTTP-даты
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return defaultRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return defaultRetryAfter
+}
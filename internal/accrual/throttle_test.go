@@ -0,0 +1,59 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_ParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		header           string
+		expectedDuration time.Duration
+	}{
+		{name: "Seconds Case", header: "60", expectedDuration: 60 * time.Second},
+		{name: "HTTP date Case", header: now.Add(90 * time.Second).Format(http.TimeFormat), expectedDuration: 90 * time.Second},
+		{name: "Past HTTP date Case", header: now.Add(-time.Minute).Format(http.TimeFormat), expectedDuration: 0},
+		{name: "Missing header Case", header: "", expectedDuration: defaultRetryAfter},
+		{name: "Negative seconds Case", header: "-5", expectedDuration: defaultRetryAfter},
+		{name: "Invalid header Case", header: "soon", expectedDuration: defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedDuration, parseRetryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestThrottle_PauseAndWait(t *testing.T) {
+	var th throttle
+	assert.False(t, th.State().Throttled)
+
+	// Открытый шлюз не задерживает запросы
+	start := time.Now()
+	assert.NoError(t, th.Wait(context.Background()))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	// Более короткая пауза не сокращает уже действующую
+	until := th.Pause(200 * time.Millisecond)
+	assert.Equal(t, until, th.Pause(10*time.Millisecond))
+	assert.True(t, th.State().Throttled)
+
+	start = time.Now()
+	assert.NoError(t, th.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.False(t, th.State().Throttled)
+
+	// Ожидание прерывается отменой контекста
+	th.Pause(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, th.Wait(ctx), context.DeadlineExceeded)
+}
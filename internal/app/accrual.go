@@ -2,18 +2,32 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/accrual"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
 
+// Время на сохранение полученных результатов после отмены обновления
+const checkpointTimeout = 5 * time.Second
+
 var NotProcessedOrderStatuses = []models.OrderStatus{
 	models.OrderStatusNew,
 	models.OrderStatusProcessing,
 }
 
+// Обновление информации о начислениях по необработанным заказам. Успешно полученная
+// информация сохраняется, даже если по части заказов запрос завершился ошибкой;
+// такие заказы будут запрошены повторно при следующем обновлении.
+// При отмене контекста уже полученные результаты также сохраняются.
 func (a *App) UpdateNotProcessedOrders(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "UpdateNotProcessedOrders")
+	defer span.End()
+
 	notProcessedOrders, err := a.storage.GetOrdersByStatus(ctx, NotProcessedOrderStatuses)
 	if err != nil {
 		return fmt.Errorf("app.updateNotProcessedOrders.getOrdersFromStorage: %w", err)
@@ -24,15 +38,104 @@ func (a *App) UpdateNotProcessedOrders(ctx context.Context) error {
 		return nil
 	}
 
-	updatedOrders, err := a.ac.GetOrdersInfo(ctx, notProcessedOrders)
+	updatedOrders, orderErrs := a.ac.GetOrdersInfo(ctx, notProcessedOrders)
+
+	// Результаты сохраняются и после отмены контекста, чтобы не запрашивать их повторно
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkpointTimeout)
+	defer cancel()
+
+	changedOrders, err := a.storage.SetOrdersAccrualAndUpdateBalance(ctx, updatedOrders)
 	if err != nil {
-		return fmt.Errorf("app.updateNotProcessedOrders.getOrdersInfo: %w", err)
+		return fmt.Errorf("app.updateNotProcessedOrders.setOrdersAccrualAndUpdateBalance: %w", err)
+	}
+	log.Debug().Int("changed", len(changedOrders)).Msg("not processed orders updated")
+
+	if err := a.publishOrdersUserEvents(ctx, changedOrders); err != nil {
+		log.Error().Err(err).Msg("failed to publish user events")
+	}
+
+	if err := a.updateOrdersAccrualFailures(ctx, updatedOrders, orderErrs); err != nil {
+		return fmt.Errorf("app.updateNotProcessedOrders: %w", err)
 	}
 
-	err = a.storage.SetOrdersAccrualAndUpdateBalance(ctx, updatedOrders)
+	return nil
+}
+
+// Получение заказов, информацию о начислениях по которым не удается получить
+// не менее заданного в конфигурации количества раз подряд
+func (a *App) GetChronicallyFailingOrders(ctx context.Context) ([]models.Order, error) {
+	ctx, span := startSpan(ctx, "GetChronicallyFailingOrders")
+	defer span.End()
+
+	orders, err := a.storage.GetOrdersByAccrualFailures(ctx, a.conf.AccrualFailureThreshold)
 	if err != nil {
-		return fmt.Errorf("app.updateNotProcessedOrders.setOrdersAccrualAndUpdateBalance: %w", err)
+		return nil, fmt.Errorf("app.getChronicallyFailingOrders: %w", err)
+	}
+	return orders, nil
+}
+
+// Количество заказов, информацию о начислениях по которым не удается получить
+// не менее заданного в конфигурации количества раз подряд
+func (a *App) CountChronicallyFailingOrders(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "CountChronicallyFailingOrders")
+	defer span.End()
+
+	count, err := a.storage.CountOrdersByAccrualFailures(ctx, a.conf.AccrualFailureThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("app.countChronicallyFailingOrders: %w", err)
+	}
+	return count, nil
+}
+
+// Учет неудачных запросов информации о начислениях по заказам
+func (a *App) updateOrdersAccrualFailures(ctx context.Context, updatedOrders []models.Order, orderErrs []accrual.OrderError) error {
+	failures := make([]models.OrderAccrualFailure, 0, len(orderErrs))
+	for _, orderErr := range orderErrs {
+		// Ограничение запросов и отмена обновления не связаны с самим заказом
+		if errors.Is(orderErr.Err, appErrors.ErrAccrualTooManyRequests) ||
+			errors.Is(orderErr.Err, context.Canceled) ||
+			errors.Is(orderErr.Err, context.DeadlineExceeded) {
+			continue
+		}
+
+		log.Debug().Err(orderErr.Err).Str("order", orderErr.Order.Number).Msg("failed to get order accrual info")
+		failures = append(failures, models.OrderAccrualFailure{
+			Number: orderErr.Order.Number,
+			Error:  orderErr.Err.Error(),
+		})
+	}
+	if len(orderErrs) > 0 {
+		log.Warn().
+			Int("succeeded", len(updatedOrders)).
+			Int("failed", len(orderErrs)).
+			Msg("accrual info was not received for some orders, they will be retried")
+	}
+
+	succeeded := make([]string, 0, len(updatedOrders))
+	for _, order := range updatedOrders {
+		succeeded = append(succeeded, order.Number)
+	}
+
+	failedOrders, err := a.storage.UpdateOrdersAccrualFailures(ctx, failures, succeeded)
+	if err != nil {
+		return fmt.Errorf("app.updateOrdersAccrualFailures: %w", err)
+	}
+
+	for _, order := range failedOrders {
+		if order.AccrualFailures >= a.conf.AccrualFailureThreshold {
+			log.Warn().
+				Str("order", order.Number).
+				Int("failures", order.AccrualFailures).
+				Str("last_error", order.LastAccrualError).
+				Msg("order accrual info is chronically failing")
+		}
 	}
 
 	return nil
 }
+
+// Состояние ограничения запросов к системе расчета начислений
+func (a *App) AccrualThrottleState() (bool, time.Time) {
+	state := a.ac.ThrottleState()
+	return state.Throttled, state.Until
+}
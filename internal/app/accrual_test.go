@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+// Тестовая система расчета начислений, возвращающая для каждого заказа
+// статус PROCESSING на первые processingResponses запросов и PROCESSED после них
+func newFakeAccrualServer(t *testing.T, processingResponses int64, accrual string) *httptest.Server {
+	var requests sync.Map
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		number := strings.TrimPrefix(req.URL.Path, "/api/orders/")
+
+		counter, _ := requests.LoadOrStore(number, new(atomic.Int64))
+		status := models.AccrualStatusProcessed
+		if counter.(*atomic.Int64).Add(1) <= processingResponses {
+			status = models.AccrualStatusProcessing
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if status == models.AccrualStatusProcessed {
+			fmt.Fprintf(rw, `{"order":"%s","status":"%s","accrual":%s}`, number, status, accrual)
+		} else {
+			fmt.Fprintf(rw, `{"order":"%s","status":"%s"}`, number, status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestApp(t *testing.T, accrualSrv *httptest.Server) (*App, Storage) {
+	conf := config.GetDefault()
+	conf.AccrualSysAddr = strings.TrimPrefix(accrualSrv.URL, "http://")
+
+	storage := memory.NewStorage()
+	return New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf), storage
+}
+
+func TestApp_UpdateNotProcessedOrders_Overlapping(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestApp(t, newFakeAccrualServer(t, 0, "100.10"))
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	orderNumbers := []string{"12345678903", "2377225624", "79927398713"}
+	for _, number := range orderNumbers {
+		require.NoError(t, a.RegisterOrder(ctx, userID, number))
+	}
+
+	// Несколько одновременных обновлений видят одни и те же необработанные заказы
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, a.UpdateNotProcessedOrders(ctx))
+		}()
+	}
+	wg.Wait()
+
+	// Повторные запуски после завершения обработки
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+
+	balance, err := a.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(300, 30), balance.Current)
+
+	entries, err := a.GetUserBalanceHistory(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, entries, len(orderNumbers))
+}
+
+func TestApp_UpdateNotProcessedOrders_Retries(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestApp(t, newFakeAccrualServer(t, 2, "250"))
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, a.RegisterOrder(ctx, userID, "12345678903"))
+
+	// Пока заказ обрабатывается, баллы не начисляются
+	for range 2 {
+		require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+
+		orders, err := a.GetOrdersByUser(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, models.OrderStatusProcessing, orders[0].Status)
+
+		balance, err := a.GetUserBalance(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, models.Money(0), balance.Current)
+	}
+
+	// После завершения обработки баллы начисляются один раз
+	for range 3 {
+		require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+	}
+
+	orders, err := a.GetOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, orders[0].Status)
+
+	balance, err := a.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(250, 0), balance.Current)
+}
+
+func TestApp_UpdateNotProcessedOrders_PartialFailure(t *testing.T) {
+	const failingOrder = "2377225624"
+
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		number := strings.TrimPrefix(req.URL.Path, "/api/orders/")
+		if number == failingOrder {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rw, `{"order":"%s","status":"PROCESSED","accrual":100}`, number)
+	}))
+	t.Cleanup(srv.Close)
+
+	a, storage := newTestApp(t, srv)
+	a.conf.AccrualFailureThreshold = 2
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, a.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, a.RegisterOrder(ctx, userID, failingOrder))
+
+	// Ошибка по одному заказу не отменяет начисления по остальным
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+
+	balance, err := a.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(100, 0), balance.Current)
+
+	failingOrders, err := a.GetChronicallyFailingOrders(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, failingOrders)
+
+	// Повторно запрашивается только заказ с ошибкой
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+
+	failingOrders, err = a.GetChronicallyFailingOrders(ctx)
+	require.NoError(t, err)
+	require.Len(t, failingOrders, 1)
+	assert.Equal(t, failingOrder, failingOrders[0].Number)
+	assert.Equal(t, 2, failingOrders[0].AccrualFailures)
+	assert.Contains(t, failingOrders[0].LastAccrualError, "500")
+
+	count, err := a.CountChronicallyFailingOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestApp_UpdateNotProcessedOrders_UserEvents(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestApp(t, newFakeAccrualServer(t, 1, "100"))
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	otherID, err := storage.AddUser(ctx, "other", "password")
+	require.NoError(t, err)
+	require.NoError(t, a.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, a.RegisterOrder(ctx, userID, "2377225624"))
+	require.NoError(t, a.RegisterOrder(ctx, otherID, "79927398713"))
+
+	sub := a.SubscribeUserEvents(userID, 0)
+	defer a.UnsubscribeUserEvents(sub)
+
+	// Смена статуса на PROCESSING не меняет баланс
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+	for range 2 {
+		event := <-sub.Events()
+		assert.Equal(t, models.UserEventOrder, event.Type)
+		assert.Contains(t, string(event.Data), `"status":"PROCESSING"`)
+	}
+	assert.Len(t, sub.Events(), 0)
+
+	// Начисление публикуется событиями заказов и одним событием итогового баланса
+	require.NoError(t, a.UpdateNotProcessedOrders(ctx))
+	for range 2 {
+		event := <-sub.Events()
+		assert.Equal(t, models.UserEventOrder, event.Type)
+		assert.Contains(t, string(event.Data), `"status":"PROCESSED"`)
+	}
+	event := <-sub.Events()
+	assert.Equal(t, models.UserEventBalance, event.Type)
+	assert.JSONEq(t, `{"current":200,"withdrawn":0}`, string(event.Data))
+	assert.Len(t, sub.Events(), 0)
+}
@@ -1,24 +1,151 @@
 package app
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
 	"github.com/ulixes-bloom/ya-gophermart/internal/accrual"
 	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"github.com/ulixes-bloom/ya-gophermart/internal/credpolicy"
+	"github.com/ulixes-bloom/ya-gophermart/internal/events"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/notifier"
+	"github.com/ulixes-bloom/ya-gophermart/internal/outbox"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/webhook"
 )
 
 type App struct {
-	storage Storage
-	ac      *accrual.Client
-	conf    *config.Config
+	storage      Storage
+	keyring      *security.Keyring
+	loginLimiter *loginlimit.Limiter
+	resetLimiter *loginlimit.Limiter // ограничение запросов на сброс пароля
+	credPolicy   *credpolicy.Policy
+	hasher       *security.PasswordHasher
+	notifier     notifier.Notifier
+	relay        *outbox.Relay
+	webhooks     *webhook.Dispatcher
+	stream       *events.Broker
+	ac           *accrual.Client
+	conf         *config.Config
 }
 
-func New(storage Storage, conf *config.Config) *App {
+func New(storage Storage, keyring *security.Keyring, conf *config.Config) *App {
 	return &App{
-		storage: storage,
-		conf:    conf,
-		ac:      accrual.NewClient(conf),
+		storage:      storage,
+		keyring:      keyring,
+		loginLimiter: newLoginLimiter(storage, conf),
+		resetLimiter: newResetLimiter(storage, conf),
+		credPolicy: credpolicy.New(credpolicy.Config{
+			LoginMinLength:     conf.LoginMinLength,
+			LoginMaxLength:     conf.LoginMaxLength,
+			PasswordMinLength:  conf.PasswordMinLength,
+			PasswordMinEntropy: conf.PasswordMinEntropy,
+			CheckBreached:      conf.CheckBreachedPasswords,
+		}),
+		hasher: security.NewPasswordHasher(security.PasswordHashConfig{
+			Algorithm:     conf.PasswordHashAlgorithm,
+			BcryptCost:    conf.BcryptCost,
+			Argon2Time:    uint32(conf.Argon2Time),
+			Argon2Memory:  uint32(conf.Argon2Memory),
+			Argon2Threads: uint8(conf.Argon2Threads),
+		}),
+		notifier: newNotifier(conf),
+		relay: outbox.NewRelay(storage,
+			outbox.NewMultiPublisher(newOutboxPublisher(conf), webhook.NewFanout(storage)),
+			outbox.Config{
+				MaxAttempts: conf.OutboxMaxAttempts,
+				Backoff:     conf.OutboxBackoff,
+				MaxBackoff:  conf.OutboxMaxBackoff,
+				Lease:       conf.OutboxLease,
+				BatchSize:   conf.OutboxBatchSize,
+			}),
+		webhooks: newWebhookDispatcher(storage, conf),
+		stream:   events.NewBroker(conf.EventsBufferSize),
+		conf:     conf,
+		ac:       accrual.NewClient(conf),
+	}
+}
+
+// Ограничение попыток по логину и IP адресу клиента с отдельным хранилищем счетчиков
+func newLoginLimiter(storage Storage, conf *config.Config) *loginlimit.Limiter {
+	return loginlimit.New(loginlimit.NewMemoryStore(conf.LoginMaxLockout), storage, loginlimit.Config{
+		Reason:             models.LoginLockoutReasonLogin,
+		MaxLoginFailures:   conf.LoginMaxFailures,
+		MaxIPFailures:      conf.LoginIPMaxFailures,
+		Window:             conf.LoginFailureWindow,
+		LockoutDuration:    conf.LoginLockout,
+		MaxLockoutDuration: conf.LoginMaxLockout,
+	})
+}
+
+// Ограничение запросов на сброс пароля: отдельные от входа пороги,
+// блокировки записываются в журнал с причиной password_reset
+func newResetLimiter(storage Storage, conf *config.Config) *loginlimit.Limiter {
+	return loginlimit.New(loginlimit.NewMemoryStore(conf.ResetMaxLockout), storage, loginlimit.Config{
+		Reason:             models.LoginLockoutReasonPasswordReset,
+		MaxLoginFailures:   conf.ResetMaxRequests,
+		MaxIPFailures:      conf.ResetIPMaxRequests,
+		Window:             conf.ResetWindow,
+		LockoutDuration:    conf.ResetLockout,
+		MaxLockoutDuration: conf.ResetMaxLockout,
+	})
+}
+
+// Доставка на вебхуки пользователей. Разрешение частных адресов позволяет пользователям
+// отправлять запросы во внутреннюю сеть сервиса, поэтому о нем предупреждается в журнале.
+func newWebhookDispatcher(storage Storage, conf *config.Config) *webhook.Dispatcher {
+	if conf.WebhookAllowPrivate {
+		log.Warn().Msg("user webhooks on private network addresses are allowed, do not use it in production")
+	}
+	return webhook.NewDispatcher(storage, webhook.Config{
+		MaxAttempts:  conf.WebhookMaxAttempts,
+		Backoff:      conf.WebhookBackoff,
+		MaxBackoff:   conf.WebhookMaxBackoff,
+		Timeout:      conf.WebhookTimeout,
+		BatchSize:    conf.WebhookBatchSize,
+		Concurrency:  conf.WebhookConcurrency,
+		Lease:        conf.WebhookLease,
+		AllowPrivate: conf.WebhookAllowPrivate,
+	})
+}
+
+// Уведомления записываются в файл, если он задан, иначе в журнал
+func newNotifier(conf *config.Config) notifier.Notifier {
+	if conf.NotificationsFile != "" {
+		return notifier.NewFileNotifier(conf.NotificationsFile)
+	}
+	if conf.NotificationsLogSecrets {
+		log.Warn().Msg("notification secrets are written to the log, do not use it in production")
+	}
+	return notifier.NewLogNotifier(conf.NotificationsLogSecrets)
+}
+
+// События публикуются способом, заданным параметром OutboxPublisher
+func newOutboxPublisher(conf *config.Config) outbox.Publisher {
+	switch conf.OutboxPublisher {
+	case "file":
+		return outbox.NewFilePublisher(conf.OutboxFile)
+	case "webhook":
+		return outbox.NewWebhookPublisher(conf.OutboxWebhookURL, conf.OutboxWebhookTimeout)
+	default:
+		return outbox.NewStdoutPublisher()
 	}
 }
 
+// Доставка очередной пачки событий из outbox
+func (a *App) DeliverOutboxEvents(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "DeliverOutboxEvents")
+	defer span.End()
+
+	if err := a.relay.DeliverPending(ctx); err != nil {
+		return fmt.Errorf("app.deliverOutboxEvents: %w", err)
+	}
+	return nil
+}
+
 func (a *App) Shutdown() error {
 	err := a.storage.Close()
 	if err != nil {
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/rs/zerolog/log"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
 
 func (a *App) GetUserBalance(ctx context.Context, userID int64) (*models.Balance, error) {
+	ctx, span := startSpan(ctx, "GetUserBalance")
+	defer span.End()
+
 	dbBalance, err := a.storage.GetBalanceByUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("app.getUserBalance: %w", err)
@@ -16,6 +21,9 @@ func (a *App) GetUserBalance(ctx context.Context, userID int64) (*models.Balance
 }
 
 func (a *App) GetUserWithdrawals(ctx context.Context, userID int64) ([]models.Withdrawal, error) {
+	ctx, span := startSpan(ctx, "GetUserWithdrawals")
+	defer span.End()
+
 	dbWithdrawals, err := a.storage.GetWithdrawalsByUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("app.getUserBalance: %w", err)
@@ -23,10 +31,93 @@ func (a *App) GetUserWithdrawals(ctx context.Context, userID int64) ([]models.Wi
 	return dbWithdrawals, nil
 }
 
+// Получение страницы истории списаний пользователя вместе с итогами
+// по всем списаниям, подходящим под фильтры выборки. Позиция следующей
+// страницы возвращается, только если после текущей страницы есть списания.
+func (a *App) GetUserWithdrawalsPage(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsPage, error) {
+	ctx, span := startSpan(ctx, "GetUserWithdrawalsPage")
+	defer span.End()
+
+	if query.Limit <= 0 || query.Limit > models.MaxWithdrawalsPageLimit {
+		return nil, fmt.Errorf("app.getUserWithdrawalsPage: %w: limit must be from 1 to %d",
+			appErrors.ErrInvalidWithdrawalsQuery, models.MaxWithdrawalsPageLimit)
+	}
+	if query.MinSum != nil && query.MaxSum != nil && *query.MinSum > *query.MaxSum {
+		return nil, fmt.Errorf("app.getUserWithdrawalsPage: %w: min_sum is greater than max_sum",
+			appErrors.ErrInvalidWithdrawalsQuery)
+	}
+	// Позиция действительна только для порядка, в котором она получена
+	if query.After != nil && query.After.Descending != query.Descending {
+		return nil, fmt.Errorf("app.getUserWithdrawalsPage: %w: sort order differs from cursor",
+			appErrors.ErrInvalidWithdrawalsCursor)
+	}
+
+	// Дополнительное списание показывает, что страница не последняя
+	pageQuery := *query
+	pageQuery.Limit++
+	withdrawals, err := a.storage.GetWithdrawalsPageByUser(ctx, userID, &pageQuery)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserWithdrawalsPage.getWithdrawals: %w", err)
+	}
+	summary, err := a.storage.GetWithdrawalsSummaryByUser(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserWithdrawalsPage.getSummary: %w", err)
+	}
+
+	page := &models.WithdrawalsPage{Withdrawals: withdrawals, Summary: *summary}
+	if len(withdrawals) > query.Limit {
+		page.Withdrawals = withdrawals[:query.Limit]
+		page.NextCursor = models.NewWithdrawalCursor(page.Withdrawals[query.Limit-1], query.Descending).Encode()
+	}
+	return page, nil
+}
+
 func (a *App) WithdrawFromUserBalance(ctx context.Context, userID int64, withdrawalReq *models.WithdrawalRequest) error {
+	ctx, span := startSpan(ctx, "WithdrawFromUserBalance")
+	defer span.End()
+
+	if withdrawalReq.Sum <= 0 {
+		return fmt.Errorf("app.withdrawFromUserBalance: %w", appErrors.ErrInvalidWithdrawalSum)
+	}
+
 	err := a.storage.WithdrawFromUserBalance(ctx, userID, withdrawalReq.Order, withdrawalReq.Sum)
 	if err != nil {
 		return fmt.Errorf("app.withdrawFromUserBalance: %w", err)
 	}
 	return nil
 }
+
+func (a *App) GetUserBalanceHistory(ctx context.Context, userID int64) ([]models.LedgerEntry, error) {
+	ctx, span := startSpan(ctx, "GetUserBalanceHistory")
+	defer span.End()
+
+	entries, err := a.storage.GetLedgerByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserBalanceHistory: %w", err)
+	}
+	return entries, nil
+}
+
+// Сверка сохраненных балансов пользователей с журналом проводок.
+// Возвращает и логирует всех пользователей, баланс которых расходится с журналом.
+func (a *App) CheckBalancesConsistency(ctx context.Context) ([]models.BalanceDiscrepancy, error) {
+	ctx, span := startSpan(ctx, "CheckBalancesConsistency")
+	defer span.End()
+
+	discrepancies, err := a.storage.GetBalanceDiscrepancies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("app.checkBalancesConsistency: %w", err)
+	}
+
+	for _, d := range discrepancies {
+		log.Warn().
+			Int64("user_id", d.UserID).
+			Stringer("balance_current", d.Balance.Current).
+			Stringer("balance_withdrawn", d.Balance.Withdrawn).
+			Stringer("ledger_current", d.Ledger.Current).
+			Stringer("ledger_withdrawn", d.Ledger.Withdrawn).
+			Msg("user balance disagrees with ledger")
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_GetUserWithdrawalsPage(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(100, 0)},
+	})
+	require.NoError(t, err)
+
+	const withdrawalsCount = 5
+	for i := range withdrawalsCount {
+		require.NoError(t, storage.WithdrawFromUserBalance(ctx, userID, fmt.Sprintf("%d", i), models.NewMoney(10, 0)))
+	}
+
+	// Позиция следующей страницы передается, пока списания не закончатся,
+	// итоги на каждой странице одинаковые
+	query := &models.WithdrawalsQuery{Descending: true, Limit: 2}
+	numbers := []string{}
+	for {
+		page, err := a.GetUserWithdrawalsPage(ctx, userID, query)
+		require.NoError(t, err)
+		assert.Equal(t, models.WithdrawalsSummary{Total: models.NewMoney(50, 0), Count: withdrawalsCount}, page.Summary)
+		for _, withdrawal := range page.Withdrawals {
+			numbers = append(numbers, withdrawal.Order)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.After, err = models.ParseWithdrawalCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"4", "3", "2", "1", "0"}, numbers)
+
+	// Страница, на которой списания заканчиваются ровно, последняя
+	page, err := a.GetUserWithdrawalsPage(ctx, userID, &models.WithdrawalsQuery{Limit: withdrawalsCount})
+	require.NoError(t, err)
+	assert.Len(t, page.Withdrawals, withdrawalsCount)
+	assert.Empty(t, page.NextCursor)
+
+	// Позиция не применяется к выборке в другом порядке
+	_, err = a.GetUserWithdrawalsPage(ctx, userID, &models.WithdrawalsQuery{
+		Limit: 2,
+		After: models.NewWithdrawalCursor(page.Withdrawals[0], true),
+	})
+	assert.ErrorIs(t, err, appErrors.ErrInvalidWithdrawalsCursor)
+
+	_, err = a.GetUserWithdrawalsPage(ctx, userID, &models.WithdrawalsQuery{Limit: models.MaxWithdrawalsPageLimit + 1})
+	assert.ErrorIs(t, err, appErrors.ErrInvalidWithdrawalsQuery)
+
+	minSum, maxSum := models.NewMoney(20, 0), models.NewMoney(10, 0)
+	_, err = a.GetUserWithdrawalsPage(ctx, userID, &models.WithdrawalsQuery{MinSum: &minSum, MaxSum: &maxSum, Limit: 2})
+	assert.ErrorIs(t, err, appErrors.ErrInvalidWithdrawalsQuery)
+}
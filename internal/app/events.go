@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/events"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Подписка на поток обновлений заказов и баланса пользователя
+func (a *App) SubscribeUserEvents(userID, lastEventID int64) *events.Subscription {
+	return a.stream.Subscribe(userID, lastEventID)
+}
+
+func (a *App) UnsubscribeUserEvents(sub *events.Subscription) {
+	a.stream.Unsubscribe(sub)
+}
+
+// Завершение потоков обновлений при остановке сервиса
+func (a *App) CloseUserEvents() {
+	a.stream.Close()
+}
+
+// Публикация смены статусов заказов и балансов пользователей, которым
+// начислены баллы. Баланс запрашивается после сохранения всех начислений,
+// поэтому событие содержит итоговое значение.
+func (a *App) publishOrdersUserEvents(ctx context.Context, changedOrders []models.Order) error {
+	if len(changedOrders) == 0 {
+		return nil
+	}
+
+	userEvents := make([]models.UserEvent, 0, len(changedOrders))
+	credited := make(map[int64]struct{})
+	creditedUsers := make([]int64, 0)
+	for _, order := range changedOrders {
+		userEvents = append(userEvents, models.NewOrderUserEvent(order))
+		if order.Status != models.OrderStatusProcessed || order.Accrual <= 0 {
+			continue
+		}
+		if _, ok := credited[order.UserID]; !ok {
+			credited[order.UserID] = struct{}{}
+			creditedUsers = append(creditedUsers, order.UserID)
+		}
+	}
+
+	// События заказов публикуются, даже если баланс получить не удалось
+	var err error
+	for _, userID := range creditedUsers {
+		balance, getErr := a.storage.GetBalanceByUser(ctx, userID)
+		if getErr != nil {
+			err = fmt.Errorf("app.publishOrdersUserEvents.getBalance: %w", getErr)
+			continue
+		}
+		userEvents = append(userEvents, models.NewBalanceUserEvent(userID, *balance))
+	}
+
+	a.stream.Publish(userEvents...)
+	return err
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"time"
 
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
@@ -10,17 +11,59 @@ type (
 	// Интерфейс хранилища
 	Storage interface {
 		GetUserByLogin(ctx context.Context, login string) (*models.User, error)
-		AddUser(ctx context.Context, login, password string) (int64, error)
+		GetUserByID(ctx context.Context, userID int64) (*models.User, error)
+		AddUser(ctx context.Context, login, passwordHash string) (int64, error)
+		UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error
+		RehashUserPassword(ctx context.Context, userID int64, oldHash, newHash string) error
+
+		AddPasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+		GetUserByPasswordResetToken(ctx context.Context, tokenHash string) (*models.User, error)
+		ResetUserPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error)
+
+		AddRefreshToken(ctx context.Context, token *models.RefreshToken) error
+		RotateRefreshToken(ctx context.Context, tokenHash string, newToken *models.RefreshToken) error
+		RevokeRefreshTokenFamily(ctx context.Context, userID int64, tokenHash string) error
+		RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+		IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+
+		AddLoginLockout(ctx context.Context, lockout models.LoginLockout) error
+		GetLoginLockouts(ctx context.Context, subjectType models.LoginLockoutSubject, subject string) ([]models.LoginLockout, error)
 
 		RegisterOrder(ctx context.Context, userID int64, orderNumber string) error
+		RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error)
+		GetOrderByNumber(ctx context.Context, orderNumber string) (*models.Order, error)
+		GetOrderStatusHistory(ctx context.Context, orderID int64) ([]models.OrderStatusChange, error)
+		GetOrderStatusTransitions(ctx context.Context, from, to time.Time) ([]models.OrderStatusTransition, error)
 		GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error)
+		GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) ([]models.Order, error)
 		GetOrdersByStatus(ctx context.Context, statuses []models.OrderStatus) ([]models.Order, error)
-		SetOrdersAccrualAndUpdateBalance(ctx context.Context, orders []models.Order) error
+		GetOrdersCountByStatus(ctx context.Context) (map[models.OrderStatus]int64, error)
+		SetOrdersAccrualAndUpdateBalance(ctx context.Context, orders []models.Order) ([]models.Order, error)
+		UpdateOrdersAccrualFailures(ctx context.Context, failures []models.OrderAccrualFailure, succeeded []string) ([]models.Order, error)
+		GetOrdersByAccrualFailures(ctx context.Context, minFailures int) ([]models.Order, error)
+		CountOrdersByAccrualFailures(ctx context.Context, minFailures int) (int64, error)
 
 		GetBalanceByUser(ctx context.Context, userID int64) (*models.Balance, error)
 		GetWithdrawalsByUser(ctx context.Context, userID int64) ([]models.Withdrawal, error)
+		GetWithdrawalsPageByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) ([]models.Withdrawal, error)
+		GetWithdrawalsSummaryByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsSummary, error)
 		WithdrawFromUserBalance(ctx context.Context, userID int64, orderNumber string, sum models.Money) error
 
+		GetLedgerByUser(ctx context.Context, userID int64) ([]models.LedgerEntry, error)
+		GetBalanceDiscrepancies(ctx context.Context) ([]models.BalanceDiscrepancy, error)
+
+		ClaimOutboxEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error)
+		CompleteOutboxEvents(ctx context.Context, deliveries []models.OutboxDelivery) error
+
+		AddWebhook(ctx context.Context, webhook *models.Webhook, maxWebhooks int) error
+		GetWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error)
+		GetWebhook(ctx context.Context, webhookID int64) (*models.Webhook, error)
+		DeleteWebhook(ctx context.Context, webhookID int64) error
+		AddWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error
+		ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+		CompleteWebhookDeliveries(ctx context.Context, results []models.WebhookDeliveryResult) error
+		GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDelivery, error)
+
 		Close() error
 	}
 )
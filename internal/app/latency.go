@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Задержки обработки заказа по истории смены его статусов
+func (a *App) GetOrderLatency(ctx context.Context, orderNumber string) (*models.OrderLatency, error) {
+	ctx, span := startSpan(ctx, "GetOrderLatency")
+	defer span.End()
+
+	order, err := a.storage.GetOrderByNumber(ctx, orderNumber)
+	if err != nil {
+		return nil, fmt.Errorf("app.getOrderLatency.getOrder: %w", err)
+	}
+
+	history, err := a.storage.GetOrderStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("app.getOrderLatency.getStatusHistory: %w", err)
+	}
+
+	latency := &models.OrderLatency{
+		Number:      order.Number,
+		Transitions: models.NewOrderStatusTransitions(*order, history),
+		Completed:   order.Status.Final(),
+	}
+	if len(history) > 0 {
+		latency.Total = history[len(history)-1].ChangedAt.Sub(order.UploadedAt)
+	}
+	return latency, nil
+}
+
+// Распределение задержек обработки заказов по переходам, завершившимся в периоде [from, to)
+func (a *App) GetOrderLatencyStats(ctx context.Context, from, to time.Time) (*models.OrderLatencyStats, error) {
+	ctx, span := startSpan(ctx, "GetOrderLatencyStats")
+	defer span.End()
+
+	transitions, err := a.storage.GetOrderStatusTransitions(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("app.getOrderLatencyStats: %w", err)
+	}
+
+	type statusPair struct{ from, to models.OrderStatus }
+	byPair := map[statusPair][]time.Duration{}
+	completion := []time.Duration{}
+	for _, t := range transitions {
+		pair := statusPair{t.From, t.To}
+		byPair[pair] = append(byPair[pair], t.Duration())
+		if t.To.Final() {
+			completion = append(completion, t.ChangedAt.Sub(t.UploadedAt))
+		}
+	}
+
+	stats := &models.OrderLatencyStats{
+		Transitions: make([]models.TransitionLatencyStats, 0, len(byPair)),
+		Completion:  newLatencyStats(completion),
+	}
+	for pair, durations := range byPair {
+		stats.Transitions = append(stats.Transitions, models.TransitionLatencyStats{
+			From:         pair.from,
+			To:           pair.to,
+			LatencyStats: newLatencyStats(durations),
+		})
+	}
+	slices.SortFunc(stats.Transitions, func(a, b models.TransitionLatencyStats) int {
+		if a.From != b.From {
+			return orderStatusRank(a.From) - orderStatusRank(b.From)
+		}
+		return orderStatusRank(a.To) - orderStatusRank(b.To)
+	})
+
+	return stats, nil
+}
+
+func newLatencyStats(durations []time.Duration) models.LatencyStats {
+	if len(durations) == 0 {
+		return models.LatencyStats{}
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	return models.LatencyStats{
+		Count: len(sorted),
+		P50:   percentile(sorted, 0.5),
+		P90:   percentile(sorted, 0.9),
+		P99:   percentile(sorted, 0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// Процентиль по методу ближайшего ранга для непустого упорядоченного набора
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Порядок статусов в жизненном цикле заказа
+func orderStatusRank(status models.OrderStatus) int {
+	switch status {
+	case models.OrderStatusNew:
+		return 0
+	case models.OrderStatusProcessing:
+		return 1
+	case models.OrderStatusProcessed:
+		return 2
+	case models.OrderStatusInvalid:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_GetOrderLatency(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	start := time.Now()
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+	for _, status := range []models.OrderStatus{models.OrderStatusProcessing, models.OrderStatusProcessed} {
+		_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+			{Number: "12345678903", UserID: userID, Status: status},
+		})
+		require.NoError(t, err)
+	}
+
+	latency, err := a.GetOrderLatency(ctx, "12345678903")
+	require.NoError(t, err)
+	assert.True(t, latency.Completed)
+	require.Len(t, latency.Transitions, 2)
+	assert.Equal(t, models.OrderStatusNew, latency.Transitions[0].From)
+	assert.Equal(t, models.OrderStatusProcessed, latency.Transitions[1].To)
+	assert.Equal(t, latency.Transitions[0].Duration()+latency.Transitions[1].Duration(), latency.Total)
+
+	// Заказ без смены статуса
+	latency, err = a.GetOrderLatency(ctx, "2377225624")
+	require.NoError(t, err)
+	assert.False(t, latency.Completed)
+	assert.Empty(t, latency.Transitions)
+	assert.Zero(t, latency.Total)
+
+	_, err = a.GetOrderLatency(ctx, "79927398713")
+	assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+
+	stats, err := a.GetOrderLatencyStats(ctx, start, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, stats.Transitions, 2)
+	assert.Equal(t, models.OrderStatusNew, stats.Transitions[0].From)
+	assert.Equal(t, models.OrderStatusProcessing, stats.Transitions[0].To)
+	assert.Equal(t, 1, stats.Transitions[0].Count)
+	assert.Equal(t, models.OrderStatusProcessing, stats.Transitions[1].From)
+	assert.Equal(t, models.OrderStatusProcessed, stats.Transitions[1].To)
+	assert.Equal(t, 1, stats.Completion.Count)
+}
+
+func TestNewLatencyStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		expected  models.LatencyStats
+	}{
+		{
+			name:     "Empty Case",
+			expected: models.LatencyStats{},
+		},
+		{
+			name:      "Single Case",
+			durations: []time.Duration{time.Second},
+			expected: models.LatencyStats{
+				Count: 1, P50: time.Second, P90: time.Second, P99: time.Second, Max: time.Second,
+			},
+		},
+		{
+			name: "Unsorted Case",
+			durations: []time.Duration{
+				10 * time.Second, 1 * time.Second, 9 * time.Second, 2 * time.Second, 8 * time.Second,
+				3 * time.Second, 7 * time.Second, 4 * time.Second, 6 * time.Second, 5 * time.Second,
+			},
+			expected: models.LatencyStats{
+				Count: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second, Max: 10 * time.Second,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newLatencyStats(tt.durations))
+		})
+	}
+}
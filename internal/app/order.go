@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/luhn"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
 
 func (a *App) RegisterOrder(ctx context.Context, userID int64, orderNumber string) error {
+	ctx, span := startSpan(ctx, "RegisterOrder")
+	defer span.End()
+
 	err := a.storage.RegisterOrder(ctx, userID, orderNumber)
 	if err != nil {
 		return fmt.Errorf("app.registerOrder: %w", err)
@@ -17,7 +21,53 @@ func (a *App) RegisterOrder(ctx context.Context, userID int64, orderNumber strin
 	return nil
 }
 
+// Загрузка пакета номеров заказов. Номера неверного формата не сохраняются,
+// остальные сохраняются в одной транзакции. Результаты возвращаются
+// в порядке номеров в пакете.
+func (a *App) RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error) {
+	ctx, span := startSpan(ctx, "RegisterOrders")
+	defer span.End()
+
+	if len(orderNumbers) == 0 {
+		return nil, fmt.Errorf("app.registerOrders: %w", appErrors.ErrEmptyOrderBatch)
+	}
+	if len(orderNumbers) > models.MaxOrderBatchSize {
+		return nil, fmt.Errorf("app.registerOrders: %w: at most %d numbers are allowed",
+			appErrors.ErrOrderBatchTooLarge, models.MaxOrderBatchSize)
+	}
+
+	valid := make([]string, 0, len(orderNumbers))
+	for _, number := range orderNumbers {
+		if a.ValidateOrderNumber(number) {
+			valid = append(valid, number)
+		}
+	}
+
+	stored := []models.OrderUploadResult{}
+	if len(valid) > 0 {
+		var err error
+		stored, err = a.storage.RegisterOrders(ctx, userID, valid)
+		if err != nil {
+			return nil, fmt.Errorf("app.registerOrders: %w", err)
+		}
+	}
+
+	results := make([]models.OrderUploadResult, 0, len(orderNumbers))
+	for _, number := range orderNumbers {
+		if !a.ValidateOrderNumber(number) {
+			results = append(results, models.OrderUploadResult{Number: number, Status: models.OrderUploadInvalid})
+			continue
+		}
+		results = append(results, stored[0])
+		stored = stored[1:]
+	}
+	return results, nil
+}
+
 func (a *App) GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error) {
+	ctx, span := startSpan(ctx, "GetOrdersByUser")
+	defer span.End()
+
 	orders, err := a.storage.GetOrdersByUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("app.getOrdersByUser: %w", err)
@@ -25,6 +75,74 @@ func (a *App) GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order
 	return orders, nil
 }
 
+// Количество заказов всех пользователей по статусам
+func (a *App) CountOrdersByStatus(ctx context.Context) (map[models.OrderStatus]int64, error) {
+	ctx, span := startSpan(ctx, "CountOrdersByStatus")
+	defer span.End()
+
+	counts, err := a.storage.GetOrdersCountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("app.countOrdersByStatus: %w", err)
+	}
+	return counts, nil
+}
+
+// Получение заказа пользователя с историей смены статусов. Для заказа,
+// загруженного другим пользователем, возвращается ErrOrderWasUploadedByAnotherUser.
+func (a *App) GetUserOrder(ctx context.Context, userID int64, orderNumber string) (*models.OrderDetails, error) {
+	ctx, span := startSpan(ctx, "GetUserOrder")
+	defer span.End()
+
+	order, err := a.storage.GetOrderByNumber(ctx, orderNumber)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserOrder.getOrder: %w", err)
+	}
+	if order.UserID != userID {
+		return nil, fmt.Errorf("app.getUserOrder: %w", appErrors.ErrOrderWasUploadedByAnotherUser)
+	}
+
+	history, err := a.storage.GetOrderStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserOrder.getStatusHistory: %w", err)
+	}
+
+	return &models.OrderDetails{Order: *order, History: history}, nil
+}
+
+// Получение страницы заказов пользователя. Позиция следующей страницы
+// возвращается, только если после текущей страницы есть заказы.
+func (a *App) GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) (*models.OrdersPage, error) {
+	ctx, span := startSpan(ctx, "GetOrdersPageByUser")
+	defer span.End()
+
+	if query.Limit <= 0 || query.Limit > models.MaxOrdersPageLimit {
+		return nil, fmt.Errorf("app.getOrdersPageByUser: %w: limit must be from 1 to %d",
+			appErrors.ErrInvalidOrdersQuery, models.MaxOrdersPageLimit)
+	}
+	// Позиция действительна только для порядка, в котором она получена
+	if query.After != nil && query.After.Descending != query.Descending {
+		return nil, fmt.Errorf("app.getOrdersPageByUser: %w: sort order differs from cursor",
+			appErrors.ErrInvalidOrdersCursor)
+	}
+
+	// Дополнительный заказ показывает, что страница не последняя
+	pageQuery := *query
+	pageQuery.Limit++
+	orders, err := a.storage.GetOrdersPageByUser(ctx, userID, &pageQuery)
+	if err != nil {
+		return nil, fmt.Errorf("app.getOrdersPageByUser: %w", err)
+	}
+
+	page := &models.OrdersPage{Orders: orders}
+	if len(orders) > query.Limit {
+		page.Orders = orders[:query.Limit]
+		page.NextCursor = models.NewOrderCursor(page.Orders[query.Limit-1], query.Descending).Encode()
+	}
+	return page, nil
+}
+
+// Номер заказа должен состоять из цифр и проходить проверку по алгоритму Луна.
+// Одна проверка используется для загрузки одного заказа, пакета и списания.
 func (a *App) ValidateOrderNumber(orderNumber string) bool {
 	return luhn.ValidateNumber(orderNumber)
 }
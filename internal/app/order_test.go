@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_GetOrdersPageByUser(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	const ordersCount = 5
+	for i := range ordersCount {
+		require.NoError(t, storage.RegisterOrder(ctx, userID, fmt.Sprintf("%d", i)))
+	}
+
+	// Позиция следующей страницы передается, пока заказы не закончатся
+	query := &models.OrdersQuery{Descending: true, Limit: 2}
+	numbers := []string{}
+	for {
+		page, err := a.GetOrdersPageByUser(ctx, userID, query)
+		require.NoError(t, err)
+		for _, order := range page.Orders {
+			numbers = append(numbers, order.Number)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.After, err = models.ParseOrderCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"4", "3", "2", "1", "0"}, numbers)
+
+	// Страница, на которой заказы заканчиваются ровно, последняя
+	page, err := a.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{Limit: ordersCount})
+	require.NoError(t, err)
+	assert.Len(t, page.Orders, ordersCount)
+	assert.Empty(t, page.NextCursor)
+
+	// Позиция не применяется к выборке в другом порядке
+	_, err = a.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{
+		Limit: 2,
+		After: models.NewOrderCursor(page.Orders[0], true),
+	})
+	assert.ErrorIs(t, err, appErrors.ErrInvalidOrdersCursor)
+
+	_, err = a.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{Limit: models.MaxOrdersPageLimit + 1})
+	assert.ErrorIs(t, err, appErrors.ErrInvalidOrdersQuery)
+}
+
+func TestApp_GetUserOrder(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another", "hash")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(500, 0)},
+	})
+	require.NoError(t, err)
+
+	order, err := a.GetUserOrder(ctx, userID, "12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, order.Status)
+	assert.Equal(t, models.NewMoney(500, 0), order.Accrual)
+	require.Len(t, order.History, 2)
+	assert.Equal(t, models.OrderStatusNew, order.History[0].Status)
+	assert.Equal(t, models.OrderStatusProcessed, order.History[1].Status)
+
+	// Чужой заказ отличается от несуществующего
+	_, err = a.GetUserOrder(ctx, anotherUserID, "12345678903")
+	assert.ErrorIs(t, err, appErrors.ErrOrderWasUploadedByAnotherUser)
+
+	_, err = a.GetUserOrder(ctx, userID, "2377225624")
+	assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+}
+
+func TestApp_ValidateOrderNumber(t *testing.T) {
+	conf := config.GetDefault()
+	a := New(memory.NewStorage(), security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	tests := []struct {
+		name     string
+		number   string
+		expected bool
+	}{
+		{name: "Valid Case", number: "12345678903", expected: true},
+		{name: "Invalid Checksum Case", number: "12345678904", expected: false},
+		{name: "Empty Case", number: "", expected: false},
+		{name: "Letters Case", number: "1234567890a", expected: false},
+		// Символы, которые алгоритм Луна без проверки цифр принял бы за цифры
+		{name: "Punctuation Case", number: "0:", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, a.ValidateOrderNumber(tt.number))
+		})
+	}
+}
+
+func TestApp_RegisterOrders(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+
+	// Номера неверного формата не сохраняются, порядок результатов совпадает с порядком номеров
+	results, err := a.RegisterOrders(ctx, userID, []string{"12345678903", "12345678904", "", "1234567890a", "2377225624"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.OrderUploadResult{
+		{Number: "12345678903", Status: models.OrderUploadAccepted},
+		{Number: "12345678904", Status: models.OrderUploadInvalid},
+		{Number: "", Status: models.OrderUploadInvalid},
+		{Number: "1234567890a", Status: models.OrderUploadInvalid},
+		{Number: "2377225624", Status: models.OrderUploadAccepted},
+	}, results)
+
+	orders, err := storage.GetOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, orders, 2)
+
+	results, err = a.RegisterOrders(ctx, userID, []string{"12345678904"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.OrderUploadResult{{Number: "12345678904", Status: models.OrderUploadInvalid}}, results)
+
+	_, err = a.RegisterOrders(ctx, userID, []string{})
+	assert.ErrorIs(t, err, appErrors.ErrEmptyOrderBatch)
+
+	_, err = a.RegisterOrders(ctx, userID, make([]string, models.MaxOrderBatchSize+1))
+	assert.ErrorIs(t, err, appErrors.ErrOrderBatchTooLarge)
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/notifier"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+// Смена пароля пользователем. Новый пароль должен соответствовать политике.
+// Проверка текущего пароля учитывается ограничением попыток входа, чтобы украденный
+// access токен не позволял подбирать пароль; при блокировке возвращается *loginlimit.LockedError.
+// После смены все выданные пользователю токены перестают приниматься.
+func (a *App) ChangePassword(ctx context.Context, userID int64, changeReq *models.PasswordChangeRequest, clientIP string) error {
+	ctx, span := startSpan(ctx, "ChangePassword")
+	defer span.End()
+
+	if changeReq.OldPassword == "" || changeReq.NewPassword == "" {
+		return fmt.Errorf("app.changePassword: %w", appErrors.ErrPasswordRequired)
+	}
+
+	user, err := a.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+
+	if err := a.loginLimiter.Check(ctx, user.Login, clientIP); err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+	if err := a.hasher.Verify(changeReq.OldPassword, user.Password); err != nil {
+		if limitErr := a.loginLimiter.Fail(ctx, user.Login, clientIP); limitErr != nil {
+			return fmt.Errorf("app.changePassword: %w", errors.Join(err, limitErr))
+		}
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+	if err := a.loginLimiter.Succeed(ctx, user.Login); err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+
+	if err := a.credPolicy.CheckPassword(user.Login, changeReq.NewPassword); err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+
+	passwordHash, err := a.hasher.Hash(changeReq.NewPassword)
+	if err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+
+	if err := a.storage.UpdateUserPassword(ctx, userID, passwordHash); err != nil {
+		return fmt.Errorf("app.changePassword: %w", err)
+	}
+
+	return nil
+}
+
+// Запрос на сброс пароля: пользователю отправляется одноразовый токен сброса.
+// Для неизвестного логина ошибка не возвращается, чтобы не раскрывать наличие пользователя.
+// Запросы учитываются по логину и IP адресу клиента с собственными порогами;
+// при их превышении возвращается *loginlimit.LockedError.
+func (a *App) RequestPasswordReset(ctx context.Context, resetReq *models.PasswordResetRequest, clientIP string) error {
+	ctx, span := startSpan(ctx, "RequestPasswordReset")
+	defer span.End()
+
+	if resetReq.Login == "" {
+		return fmt.Errorf("app.requestPasswordReset: %w", appErrors.ErrUserLoginRequired)
+	}
+
+	if err := a.resetLimiter.Check(ctx, resetReq.Login, clientIP); err != nil {
+		return fmt.Errorf("app.requestPasswordReset: %w", err)
+	}
+	if err := a.resetLimiter.Fail(ctx, resetReq.Login, clientIP); err != nil {
+		return fmt.Errorf("app.requestPasswordReset: %w", err)
+	}
+
+	user, err := a.storage.GetUserByLogin(ctx, resetReq.Login)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrUserNotFound) {
+			log.Debug().Str("login", resetReq.Login).Msg("password reset requested for unknown login")
+			return nil
+		}
+		return fmt.Errorf("app.requestPasswordReset: %w", err)
+	}
+
+	resetToken, err := security.NewPasswordResetToken()
+	if err != nil {
+		return fmt.Errorf("app.requestPasswordReset: %w", err)
+	}
+
+	storedToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: security.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(a.conf.PasswordResetLifetime),
+	}
+	if err := a.storage.AddPasswordResetToken(ctx, storedToken); err != nil {
+		return fmt.Errorf("app.requestPasswordReset: %w", err)
+	}
+
+	err = a.notifier.Notify(ctx, notifier.Notification{
+		Recipient: user.Login,
+		Subject:   "Password reset",
+		Body: fmt.Sprintf("Use token %s to reset your password. The token expires at %s.",
+			resetToken, storedToken.ExpiresAt.Format(time.RFC3339)),
+		Secrets: []string{resetToken},
+	})
+	if err != nil {
+		return fmt.Errorf("app.requestPasswordReset.notify: %w", err)
+	}
+
+	return nil
+}
+
+// Сброс пароля по токену. Токен используется однократно; после сброса
+// все выданные пользователю токены перестают приниматься.
+func (a *App) ResetPassword(ctx context.Context, confirmReq *models.PasswordResetConfirmRequest) error {
+	ctx, span := startSpan(ctx, "ResetPassword")
+	defer span.End()
+
+	if confirmReq.Token == "" || confirmReq.NewPassword == "" {
+		return fmt.Errorf("app.resetPassword: %w", appErrors.ErrPasswordResetTokenRequired)
+	}
+
+	tokenHash := security.HashToken(confirmReq.Token)
+	user, err := a.storage.GetUserByPasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		return fmt.Errorf("app.resetPassword: %w", err)
+	}
+
+	if err := a.credPolicy.CheckPassword(user.Login, confirmReq.NewPassword); err != nil {
+		return fmt.Errorf("app.resetPassword: %w", err)
+	}
+
+	passwordHash, err := a.hasher.Hash(confirmReq.NewPassword)
+	if err != nil {
+		return fmt.Errorf("app.resetPassword: %w", err)
+	}
+
+	if _, err := a.storage.ResetUserPassword(ctx, tokenHash, passwordHash); err != nil {
+		return fmt.Errorf("app.resetPassword: %w", err)
+	}
+
+	return nil
+}
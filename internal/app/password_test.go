@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/loginlimit"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/notifier"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+const (
+	testPassword    = "correct-horse-battery"
+	testNewPassword = "staple-gopher-lantern"
+)
+
+// Уведомления, сохраняемые для проверки в тестах
+type testNotifier struct {
+	mu            sync.Mutex
+	notifications []notifier.Notification
+}
+
+func (n *testNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func (n *testNotifier) lastResetToken(t *testing.T) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	require.NotEmpty(t, n.notifications)
+	var token string
+	_, err := fmt.Sscanf(n.notifications[len(n.notifications)-1].Body, "Use token %s", &token)
+	require.NoError(t, err)
+	return token
+}
+
+func newPasswordTestApp(t *testing.T) (*App, *testNotifier, int64) {
+	conf := config.GetDefault()
+	a := New(memory.NewStorage(), security.NewHMACKeyring(conf.TokenSecretKey), conf)
+	n := &testNotifier{}
+	a.notifier = n
+
+	userID, err := a.RegisterUser(context.Background(), &models.User{Login: "user", Password: testPassword})
+	require.NoError(t, err)
+
+	return a, n, userID
+}
+
+func TestApp_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	a, _, userID := newPasswordTestApp(t)
+
+	tokens, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	err = a.ChangePassword(ctx, userID, &models.PasswordChangeRequest{OldPassword: "wrong", NewPassword: testNewPassword}, "192.0.2.1")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidPassword)
+
+	err = a.ChangePassword(ctx, userID, &models.PasswordChangeRequest{OldPassword: testPassword, NewPassword: "user"}, "192.0.2.1")
+	assert.ErrorIs(t, err, appErrors.ErrCredentialPolicyViolation)
+
+	// Неудачные попытки не отзывают токены
+	_, err = a.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	err = a.ChangePassword(ctx, userID, &models.PasswordChangeRequest{OldPassword: testPassword, NewPassword: testNewPassword}, "192.0.2.1")
+	require.NoError(t, err)
+
+	// Выданные до смены пароля токены больше не принимаются
+	_, err = a.Authenticate(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, appErrors.ErrTokenRevoked)
+	_, err = a.RefreshTokens(ctx, tokens.RefreshToken)
+	assert.Error(t, err)
+
+	// Вход возможен только с новым паролем
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: testPassword}, "192.0.2.1")
+	assert.Error(t, err)
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: testNewPassword}, "192.0.2.1")
+	require.NoError(t, err)
+
+	newTokens, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+	_, err = a.Authenticate(ctx, newTokens.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestApp_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	a, n, userID := newPasswordTestApp(t)
+
+	tokens, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	// Для неизвестного логина уведомление не отправляется, но и ошибка не возвращается
+	require.NoError(t, a.RequestPasswordReset(ctx, &models.PasswordResetRequest{Login: "unknown"}, "192.0.2.1"))
+	assert.Empty(t, n.notifications)
+
+	require.NoError(t, a.RequestPasswordReset(ctx, &models.PasswordResetRequest{Login: "user"}, "192.0.2.1"))
+	require.Len(t, n.notifications, 1)
+	assert.Equal(t, "user", n.notifications[0].Recipient)
+	resetToken := n.lastResetToken(t)
+
+	err = a.ResetPassword(ctx, &models.PasswordResetConfirmRequest{Token: "unknown", NewPassword: testNewPassword})
+	assert.ErrorIs(t, err, appErrors.ErrPasswordResetTokenInvalid)
+
+	err = a.ResetPassword(ctx, &models.PasswordResetConfirmRequest{Token: resetToken, NewPassword: "password"})
+	assert.ErrorIs(t, err, appErrors.ErrCredentialPolicyViolation)
+
+	err = a.ResetPassword(ctx, &models.PasswordResetConfirmRequest{Token: resetToken, NewPassword: testNewPassword})
+	require.NoError(t, err)
+
+	// Токен сброса одноразовый
+	err = a.ResetPassword(ctx, &models.PasswordResetConfirmRequest{Token: resetToken, NewPassword: testPassword})
+	assert.ErrorIs(t, err, appErrors.ErrPasswordResetTokenInvalid)
+
+	_, err = a.Authenticate(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, appErrors.ErrTokenRevoked)
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: testNewPassword}, "192.0.2.1")
+	assert.NoError(t, err)
+}
+
+func TestApp_ChangePasswordLegacyLogin(t *testing.T) {
+	ctx := context.Background()
+	a, n, _ := newPasswordTestApp(t)
+
+	// Пользователь зарегистрирован до введения политики с логином, который она отклоняет
+	passwordHash, err := a.hasher.Hash(testPassword)
+	require.NoError(t, err)
+	userID, err := a.storage.AddUser(ctx, "a@b", passwordHash)
+	require.NoError(t, err)
+
+	err = a.ChangePassword(ctx, userID, &models.PasswordChangeRequest{OldPassword: testPassword, NewPassword: testNewPassword}, "192.0.2.1")
+	require.NoError(t, err)
+
+	require.NoError(t, a.RequestPasswordReset(ctx, &models.PasswordResetRequest{Login: "a@b"}, "192.0.2.1"))
+	err = a.ResetPassword(ctx, &models.PasswordResetConfirmRequest{Token: n.lastResetToken(t), NewPassword: testPassword})
+	require.NoError(t, err)
+}
+
+func TestApp_RequestPasswordResetLimit(t *testing.T) {
+	ctx := context.Background()
+	a, n, _ := newPasswordTestApp(t)
+	resetReq := &models.PasswordResetRequest{Login: "user"}
+
+	// Запросы на сброс пароля по логину ограничены собственным порогом
+	for range a.conf.ResetMaxRequests {
+		require.NoError(t, a.RequestPasswordReset(ctx, resetReq, "192.0.2.1"))
+	}
+	err := a.RequestPasswordReset(ctx, resetReq, "192.0.2.2")
+	var lockedErr *loginlimit.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, a.conf.ResetLockout, lockedErr.RetryAfter, float64(time.Second))
+	assert.Len(t, n.notifications, a.conf.ResetMaxRequests)
+
+	// Блокировка записывается в журнал с причиной сброса пароля
+	lockouts, err := a.storage.GetLoginLockouts(ctx, models.LoginLockoutSubjectLogin, "user")
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, models.LoginLockoutReasonPasswordReset, lockouts[0].Reason)
+
+	// Запросы на сброс не блокируют вход
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: testPassword}, "192.0.2.1")
+	assert.NoError(t, err)
+}
+
+func TestApp_ChangePasswordLimit(t *testing.T) {
+	ctx := context.Background()
+	a, _, userID := newPasswordTestApp(t)
+	changeReq := &models.PasswordChangeRequest{OldPassword: "wrong", NewPassword: testNewPassword}
+
+	// Подбор текущего пароля по access токену ограничен так же, как вход
+	for range a.conf.LoginMaxFailures {
+		err := a.ChangePassword(ctx, userID, changeReq, "192.0.2.1")
+		require.ErrorIs(t, err, appErrors.ErrInvalidPassword)
+	}
+
+	changeReq.OldPassword = testPassword
+	err := a.ChangePassword(ctx, userID, changeReq, "192.0.2.2")
+	var lockedErr *loginlimit.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+
+	// Логин заблокирован и для входа
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: testPassword}, "192.0.2.2")
+	assert.ErrorAs(t, err, &lockedErr)
+
+	lockouts, err := a.storage.GetLoginLockouts(ctx, models.LoginLockoutSubjectLogin, "user")
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, models.LoginLockoutReasonLogin, lockouts[0].Reason)
+}
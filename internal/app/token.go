@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+// Выдача пары токенов при входе пользователя. Refresh токен начинает новое семейство.
+func (a *App) IssueTokens(ctx context.Context, userID int64) (*models.TokenPair, error) {
+	ctx, span := startSpan(ctx, "IssueTokens")
+	defer span.End()
+
+	user, err := a.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("app.issueTokens: %w", err)
+	}
+
+	familyID, err := security.NewTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("app.issueTokens: %w", err)
+	}
+
+	refreshToken, storedToken, err := a.newRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("app.issueTokens: %w", err)
+	}
+	storedToken.UserID = userID
+	storedToken.FamilyID = familyID
+
+	if err := a.storage.AddRefreshToken(ctx, storedToken); err != nil {
+		return nil, fmt.Errorf("app.issueTokens: %w", err)
+	}
+
+	return a.newTokenPair(user, refreshToken)
+}
+
+// Обмен refresh токена на новую пару токенов. Каждый refresh токен используется
+// однократно; повторное использование отзывает все семейство токенов.
+func (a *App) RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	ctx, span := startSpan(ctx, "RefreshTokens")
+	defer span.End()
+
+	if refreshToken == "" {
+		return nil, fmt.Errorf("app.refreshTokens: %w", appErrors.ErrRefreshTokenRequired)
+	}
+
+	newRefreshToken, storedToken, err := a.newRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("app.refreshTokens: %w", err)
+	}
+
+	err = a.storage.RotateRefreshToken(ctx, security.HashToken(refreshToken), storedToken)
+	if err != nil {
+		return nil, fmt.Errorf("app.refreshTokens: %w", err)
+	}
+
+	user, err := a.storage.GetUserByID(ctx, storedToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("app.refreshTokens: %w", err)
+	}
+
+	return a.newTokenPair(user, newRefreshToken)
+}
+
+// Выход пользователя: отзыв access токена и, если передан, семейства refresh токена
+func (a *App) Logout(ctx context.Context, claims *security.Claims, refreshToken string) error {
+	ctx, span := startSpan(ctx, "Logout")
+	defer span.End()
+
+	if claims.ID != "" {
+		expiresAt := time.Now().Add(a.conf.TokenLifetime)
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		if err := a.storage.RevokeAccessToken(ctx, claims.ID, expiresAt); err != nil {
+			return fmt.Errorf("app.logout: %w", err)
+		}
+	}
+
+	if refreshToken != "" {
+		err := a.storage.RevokeRefreshTokenFamily(ctx, claims.UserID, security.HashToken(refreshToken))
+		if err != nil {
+			return fmt.Errorf("app.logout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Проверка access токена: подписи, срока действия, отсутствия в списке отозванных
+// и совпадения версии токенов пользователя, изменяемой при смене пароля
+func (a *App) Authenticate(ctx context.Context, accessToken string) (*security.Claims, error) {
+	ctx, span := startSpan(ctx, "Authenticate")
+	defer span.End()
+
+	claims, err := security.ParseJWT(accessToken, a.keyring)
+	if err != nil {
+		return nil, fmt.Errorf("app.authenticate: %w", err)
+	}
+
+	if claims.ID != "" {
+		revoked, err := a.storage.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("app.authenticate: %w", err)
+		}
+		if revoked {
+			return nil, fmt.Errorf("app.authenticate: %w", appErrors.ErrTokenRevoked)
+		}
+	}
+
+	user, err := a.storage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrUserNotFound) {
+			return nil, fmt.Errorf("app.authenticate: %w", appErrors.ErrTokenRevoked)
+		}
+		return nil, fmt.Errorf("app.authenticate: %w", err)
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, fmt.Errorf("app.authenticate: %w", appErrors.ErrTokenRevoked)
+	}
+
+	return claims, nil
+}
+
+// Открытые ключи проверки access токенов
+func (a *App) JWKS() security.JWKSet {
+	return a.keyring.JWKS()
+}
+
+func (a *App) newRefreshToken() (string, *models.RefreshToken, error) {
+	refreshToken, err := security.NewRefreshToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return refreshToken, &models.RefreshToken{
+		TokenHash: security.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(a.conf.RefreshTokenLifetime),
+	}, nil
+}
+
+func (a *App) newTokenPair(user *models.User, refreshToken string) (*models.TokenPair, error) {
+	accessToken, err := security.BuildJWTString(user.ID, user.TokenVersion, a.keyring, a.conf.TokenLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("app.newTokenPair: %w", err)
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(a.conf.TokenLifetime.Seconds()),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	tokens, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	claims, err := a.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+
+	// Ротация выдает новую пару токенов
+	refreshed, err := a.RefreshTokens(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	claims, err = a.Authenticate(ctx, refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+
+	// Повторное использование старого токена отзывает и выданный при ротации
+	_, err = a.RefreshTokens(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+	_, err = a.RefreshTokens(ctx, refreshed.RefreshToken)
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+
+	_, err = a.RefreshTokens(ctx, "unknown")
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenInvalid)
+}
+
+func TestApp_Logout(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	tokens, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+	otherSession, err := a.IssueTokens(ctx, userID)
+	require.NoError(t, err)
+
+	claims, err := a.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, a.Logout(ctx, claims, tokens.RefreshToken))
+
+	// Токены завершенной сессии больше не принимаются
+	_, err = a.Authenticate(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, appErrors.ErrTokenRevoked)
+	_, err = a.RefreshTokens(ctx, tokens.RefreshToken)
+	assert.Error(t, err)
+
+	// Другие сессии пользователя продолжают работать
+	_, err = a.Authenticate(ctx, otherSession.AccessToken)
+	assert.NoError(t, err)
+	_, err = a.RefreshTokens(ctx, otherSession.RefreshToken)
+	assert.NoError(t, err)
+}
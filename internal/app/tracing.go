@@ -0,0 +1,15 @@
+package app
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ulixes-bloom/ya-gophermart/internal/app"
+
+// Span операции приложения, вложенный в span HTTP запроса или фоновой задачи
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "app."+operation)
+}
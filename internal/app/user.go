@@ -2,27 +2,91 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/rs/zerolog/log"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
-	"github.com/ulixes-bloom/ya-gophermart/internal/security"
 )
 
-func (a *App) ValidateUser(ctx context.Context, user *models.User) (*models.User, error) {
-	dbUser, err := a.storage.GetUserByLogin(ctx, user.Login)
+// Проверка логина и пароля пользователя. Неудачные попытки учитываются по логину
+// и IP адресу клиента; при их превышении вход временно блокируется
+// и возвращается *loginlimit.LockedError. Если хеш пароля создан устаревшим
+// алгоритмом или с устаревшими параметрами, после успешного входа пароль перехешируется.
+func (a *App) ValidateUser(ctx context.Context, user *models.User, clientIP string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "ValidateUser")
+	defer span.End()
+
+	if err := a.loginLimiter.Check(ctx, user.Login, clientIP); err != nil {
+		return nil, fmt.Errorf("app.validateUser: %w", err)
+	}
+
+	dbUser, err := a.checkUserPassword(ctx, user)
 	if err != nil {
+		if limitErr := a.loginLimiter.Fail(ctx, user.Login, clientIP); limitErr != nil {
+			return nil, fmt.Errorf("app.validateUser: %w", errors.Join(err, limitErr))
+		}
 		return nil, fmt.Errorf("app.validateUser: %w", err)
 	}
 
-	if err := security.CheckPassword(user.Password, dbUser.Password); err != nil {
+	if err := a.loginLimiter.Succeed(ctx, user.Login); err != nil {
 		return nil, fmt.Errorf("app.validateUser: %w", err)
 	}
 
+	a.rehashPassword(ctx, dbUser, user.Password)
+
 	return dbUser, nil
 }
 
+// Перехеширование пароля текущим алгоритмом. Ошибка не прерывает вход
+// и только записывается в журнал: пароль будет перехеширован при следующем входе.
+func (a *App) rehashPassword(ctx context.Context, dbUser *models.User, password string) {
+	if !a.hasher.NeedsRehash(dbUser.Password) {
+		return
+	}
+
+	newHash, err := a.hasher.Hash(password)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", dbUser.ID).Msg("failed to rehash password")
+		return
+	}
+
+	if err := a.storage.RehashUserPassword(ctx, dbUser.ID, dbUser.Password, newHash); err != nil {
+		log.Error().Err(err).Int64("user_id", dbUser.ID).Msg("failed to save rehashed password")
+		return
+	}
+	log.Info().Int64("user_id", dbUser.ID).Msg("password rehashed with current parameters")
+}
+
+func (a *App) checkUserPassword(ctx context.Context, user *models.User) (*models.User, error) {
+	dbUser, err := a.storage.GetUserByLogin(ctx, user.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.hasher.Verify(user.Password, dbUser.Password); err != nil {
+		return nil, err
+	}
+
+	return dbUser, nil
+}
+
+// Регистрация пользователя. Логин и пароль должны соответствовать политике,
+// иначе возвращается *credpolicy.PolicyError с перечнем нарушенных правил.
 func (a *App) RegisterUser(ctx context.Context, user *models.User) (int64, error) {
-	createdUserID, err := a.storage.AddUser(ctx, user.Login, user.Password)
+	ctx, span := startSpan(ctx, "RegisterUser")
+	defer span.End()
+
+	if err := a.credPolicy.Check(user.Login, user.Password); err != nil {
+		return -1, fmt.Errorf("app.registerUser: %w", err)
+	}
+
+	passwordHash, err := a.hasher.Hash(user.Password)
+	if err != nil {
+		return -1, fmt.Errorf("app.registerUser: %w", err)
+	}
+
+	createdUserID, err := a.storage.AddUser(ctx, user.Login, passwordHash)
 	if err != nil {
 		return -1, fmt.Errorf("app.registerUser: %w", err)
 	}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_ValidateUser_Lockout(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	conf.LoginMaxFailures = 3
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	_, err := a.RegisterUser(ctx, &models.User{Login: "user", Password: "correct-horse-battery"})
+	require.NoError(t, err)
+
+	wrongPassword := &models.User{Login: "user", Password: "wrong"}
+	for range conf.LoginMaxFailures {
+		_, err := a.ValidateUser(ctx, wrongPassword, "192.0.2.1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, appErrors.ErrTooManyLoginAttempts)
+	}
+
+	// После блокировки не принимается даже верный пароль
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: "correct-horse-battery"}, "192.0.2.2")
+	assert.ErrorIs(t, err, appErrors.ErrTooManyLoginAttempts)
+
+	lockouts, err := storage.GetLoginLockouts(ctx, models.LoginLockoutSubjectLogin, "user")
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, conf.LoginMaxFailures, lockouts[0].Failures)
+}
+
+func TestApp_RegisterUser_CredentialPolicy(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	_, err := a.RegisterUser(ctx, &models.User{Login: "user", Password: "password"})
+	assert.ErrorIs(t, err, appErrors.ErrCredentialPolicyViolation)
+
+	// Пользователь с нарушающими политику данными не создается
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: "password"}, "192.0.2.1")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+}
+
+func TestApp_ValidateUser_Rehash(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewStorage()
+
+	conf := config.GetDefault()
+	conf.BcryptCost = 4
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := a.RegisterUser(ctx, &models.User{Login: "user", Password: "correct-horse-battery"})
+	require.NoError(t, err)
+	oldUser, err := storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+
+	// Смена алгоритма хеширования
+	argonConf := config.GetDefault()
+	argonConf.PasswordHashAlgorithm = security.PasswordHashArgon2id
+	argonConf.Argon2Time = 1
+	argonConf.Argon2Memory = 64
+	argonConf.Argon2Threads = 1
+	a = New(storage, security.NewHMACKeyring(argonConf.TokenSecretKey), argonConf)
+
+	// Неудачный вход не меняет хеш
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: "wrong"}, "192.0.2.1")
+	require.Error(t, err)
+	user, err := storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, oldUser.Password, user.Password)
+
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: "correct-horse-battery"}, "192.0.2.1")
+	require.NoError(t, err)
+
+	// После входа пароль перехеширован без отзыва токенов
+	user, err = storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Contains(t, user.Password, "$argon2id$v=19$m=64,t=1,p=1$")
+	assert.Equal(t, oldUser.TokenVersion, user.TokenVersion)
+
+	_, err = a.ValidateUser(ctx, &models.User{Login: "user", Password: "correct-horse-battery"}, "192.0.2.1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+)
+
+// Регистрация вебхука пользователя. Секрет подписи запросов возвращается только здесь.
+func (a *App) AddUserWebhook(ctx context.Context, userID int64, webhookReq *models.WebhookRequest) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "AddUserWebhook")
+	defer span.End()
+
+	if err := a.webhooks.ValidateURL(ctx, webhookReq.URL); err != nil {
+		return nil, fmt.Errorf("app.addUserWebhook: %w", err)
+	}
+
+	secret, err := security.NewWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("app.addUserWebhook: %w", err)
+	}
+
+	webhook := &models.Webhook{
+		UserID: userID,
+		URL:    webhookReq.URL,
+		Secret: secret,
+	}
+	if err := a.storage.AddWebhook(ctx, webhook, models.MaxUserWebhooks); err != nil {
+		return nil, fmt.Errorf("app.addUserWebhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// Вебхуки пользователя без секретов подписи
+func (a *App) GetUserWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	ctx, span := startSpan(ctx, "GetUserWebhooks")
+	defer span.End()
+
+	webhooks, err := a.storage.GetWebhooksByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserWebhooks: %w", err)
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (a *App) DeleteUserWebhook(ctx context.Context, userID, webhookID int64) error {
+	ctx, span := startSpan(ctx, "DeleteUserWebhook")
+	defer span.End()
+
+	if _, err := a.getUserWebhook(ctx, userID, webhookID); err != nil {
+		return fmt.Errorf("app.deleteUserWebhook: %w", err)
+	}
+	if err := a.storage.DeleteWebhook(ctx, webhookID); err != nil {
+		return fmt.Errorf("app.deleteUserWebhook: %w", err)
+	}
+	return nil
+}
+
+// Журнал последних limit доставок на вебхук пользователя
+func (a *App) GetUserWebhookDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "GetUserWebhookDeliveries")
+	defer span.End()
+
+	if limit < 1 || limit > models.MaxWebhookDeliveryLimit {
+		return nil, fmt.Errorf("app.getUserWebhookDeliveries: %w: limit must be from 1 to %d",
+			appErrors.ErrInvalidWebhookDeliveryQuery, models.MaxWebhookDeliveryLimit)
+	}
+	if _, err := a.getUserWebhook(ctx, userID, webhookID); err != nil {
+		return nil, fmt.Errorf("app.getUserWebhookDeliveries: %w", err)
+	}
+
+	deliveries, err := a.storage.GetWebhookDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("app.getUserWebhookDeliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Отправка доставок на вебхуки, время попытки которых наступило
+func (a *App) DeliverWebhooks(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "DeliverWebhooks")
+	defer span.End()
+
+	if err := a.webhooks.DeliverDue(ctx); err != nil {
+		return fmt.Errorf("app.deliverWebhooks: %w", err)
+	}
+	return nil
+}
+
+// Вебхук другого пользователя считается ненайденным, чтобы не раскрывать чужие ID
+func (a *App) getUserWebhook(ctx context.Context, userID, webhookID int64) (*models.Webhook, error) {
+	webhook, err := a.storage.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, appErrors.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
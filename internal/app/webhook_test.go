@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+func TestApp_UserWebhooks(t *testing.T) {
+	ctx := context.Background()
+	conf := config.GetDefault()
+	conf.OutboxPublisher = "file"
+	conf.OutboxFile = filepath.Join(t.TempDir(), "events.jsonl")
+	storage := memory.NewStorage()
+	a := New(storage, security.NewHMACKeyring(conf.TokenSecretKey), conf)
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "hash")
+	require.NoError(t, err)
+
+	// Неабсолютные адреса и адреса во внутренней сети сервиса не принимаются
+	for _, url := range []string{
+		"", "example.com/hook", "ftp://example.com/hook", "https:///hook",
+		"http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://169.254.169.254/latest", "https://10.0.0.1/hook",
+	} {
+		_, err = a.AddUserWebhook(ctx, userID, &models.WebhookRequest{URL: url})
+		assert.ErrorIs(t, err, appErrors.ErrInvalidWebhookURL, url)
+	}
+
+	// Секрет возвращается только при регистрации
+	webhook, err := a.AddUserWebhook(ctx, userID, &models.WebhookRequest{URL: "https://93.184.215.14/hook"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, webhook.Secret)
+
+	webhooks, err := a.GetUserWebhooks(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, webhook.ID, webhooks[0].ID)
+	assert.Empty(t, webhooks[0].Secret)
+
+	// Вебхук другого пользователя не находится
+	_, err = a.GetUserWebhookDeliveries(ctx, anotherUserID, webhook.ID, models.DefaultWebhookDeliveryLimit)
+	assert.ErrorIs(t, err, appErrors.ErrWebhookNotFound)
+	err = a.DeleteUserWebhook(ctx, anotherUserID, webhook.ID)
+	assert.ErrorIs(t, err, appErrors.ErrWebhookNotFound)
+
+	_, err = a.GetUserWebhookDeliveries(ctx, userID, webhook.ID, models.MaxWebhookDeliveryLimit+1)
+	assert.ErrorIs(t, err, appErrors.ErrInvalidWebhookDeliveryQuery)
+
+	// События заказов пользователя попадают в журнал доставок вебхука
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(100, 0)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, a.DeliverOutboxEvents(ctx))
+
+	deliveries, err := a.GetUserWebhookDeliveries(ctx, userID, webhook.ID, models.DefaultWebhookDeliveryLimit)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.OutboxEventOrderCredited, deliveries[0].EventType)
+	assert.Equal(t, models.WebhookDeliveryPending, deliveries[0].Status)
+
+	for range models.MaxUserWebhooks - 1 {
+		_, err = a.AddUserWebhook(ctx, userID, &models.WebhookRequest{URL: "https://93.184.215.14/hook"})
+		require.NoError(t, err)
+	}
+	_, err = a.AddUserWebhook(ctx, userID, &models.WebhookRequest{URL: "https://93.184.215.14/hook"})
+	assert.ErrorIs(t, err, appErrors.ErrTooManyWebhooks)
+
+	require.NoError(t, a.DeleteUserWebhook(ctx, userID, webhook.ID))
+	err = a.DeleteUserWebhook(ctx, userID, webhook.ID)
+	assert.ErrorIs(t, err, appErrors.ErrWebhookNotFound)
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/caarlos0/env"
@@ -14,9 +16,63 @@ type Config struct {
 	DatabaseURI             string        `env:"DATABASE_URI"`
 	LogLvl                  string        `env:"LOGLVL"`
 	TokenSecretKey          string        `env:"TOKEN_KEY"`
+	TokenKeysDir            string        `env:"TOKEN_KEYS_DIR"`
+	TokenKeyID              string        `env:"TOKEN_KEY_ID"`
 	TokenLifetime           time.Duration `env:"TOKEN_LIFETIME"`
+	RefreshTokenLifetime    time.Duration `env:"REFRESH_TOKEN_LIFETIME"`
 	AccrualRateLimit        int           `env:"RATE_LIMIT"`
 	OrderInfoUpdateInterval time.Duration `env:"ORDER_UPDATE_INTERVAL"`
+	OrderInfoUpdateTimeout  time.Duration `env:"ORDER_UPDATE_TIMEOUT"`
+	OrderInfoUpdateJitter   time.Duration `env:"ORDER_UPDATE_JITTER"`
+	AccrualFailureThreshold int           `env:"ACCRUAL_FAILURE_THRESHOLD"`
+	ShutdownTimeout         time.Duration `env:"SHUTDOWN_TIMEOUT"`
+	LoginMaxFailures        int           `env:"LOGIN_MAX_FAILURES"`
+	LoginIPMaxFailures      int           `env:"LOGIN_IP_MAX_FAILURES"`
+	LoginFailureWindow      time.Duration `env:"LOGIN_FAILURE_WINDOW"`
+	LoginLockout            time.Duration `env:"LOGIN_LOCKOUT"`
+	LoginMaxLockout         time.Duration `env:"LOGIN_MAX_LOCKOUT"`
+	LoginMinLength          int           `env:"LOGIN_MIN_LENGTH"`
+	LoginMaxLength          int           `env:"LOGIN_MAX_LENGTH"`
+	PasswordMinLength       int           `env:"PASSWORD_MIN_LENGTH"`
+	PasswordMinEntropy      float64       `env:"PASSWORD_MIN_ENTROPY"`
+	CheckBreachedPasswords  bool          `env:"CHECK_BREACHED_PASSWORDS"`
+	PasswordResetLifetime   time.Duration `env:"PASSWORD_RESET_LIFETIME"`
+	ResetMaxRequests        int           `env:"PASSWORD_RESET_MAX_REQUESTS"`
+	ResetIPMaxRequests      int           `env:"PASSWORD_RESET_IP_MAX_REQUESTS"`
+	ResetWindow             time.Duration `env:"PASSWORD_RESET_WINDOW"`
+	ResetLockout            time.Duration `env:"PASSWORD_RESET_LOCKOUT"`
+	ResetMaxLockout         time.Duration `env:"PASSWORD_RESET_MAX_LOCKOUT"`
+	NotificationsFile       string        `env:"NOTIFICATIONS_FILE"`
+	NotificationsLogSecrets bool          `env:"NOTIFICATIONS_LOG_SECRETS"`
+	PasswordHashAlgorithm   string        `env:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost              int           `env:"BCRYPT_COST"`
+	Argon2Time              uint          `env:"ARGON2_TIME"`
+	Argon2Memory            uint          `env:"ARGON2_MEMORY"`
+	Argon2Threads           uint          `env:"ARGON2_THREADS"`
+	OutboxPublisher         string        `env:"OUTBOX_PUBLISHER"`
+	OutboxFile              string        `env:"OUTBOX_FILE"`
+	OutboxWebhookURL        string        `env:"OUTBOX_WEBHOOK_URL"`
+	OutboxWebhookTimeout    time.Duration `env:"OUTBOX_WEBHOOK_TIMEOUT"`
+	OutboxRelayInterval     time.Duration `env:"OUTBOX_RELAY_INTERVAL"`
+	OutboxBatchSize         int           `env:"OUTBOX_BATCH_SIZE"`
+	OutboxMaxAttempts       int           `env:"OUTBOX_MAX_ATTEMPTS"`
+	OutboxBackoff           time.Duration `env:"OUTBOX_BACKOFF"`
+	OutboxMaxBackoff        time.Duration `env:"OUTBOX_MAX_BACKOFF"`
+	OutboxLease             time.Duration `env:"OUTBOX_LEASE"`
+	WebhookMaxAttempts      int           `env:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoff          time.Duration `env:"WEBHOOK_BACKOFF"`
+	WebhookMaxBackoff       time.Duration `env:"WEBHOOK_MAX_BACKOFF"`
+	WebhookTimeout          time.Duration `env:"WEBHOOK_TIMEOUT"`
+	WebhookDeliveryInterval time.Duration `env:"WEBHOOK_DELIVERY_INTERVAL"`
+	WebhookBatchSize        int           `env:"WEBHOOK_BATCH_SIZE"`
+	WebhookConcurrency      int           `env:"WEBHOOK_CONCURRENCY"`
+	WebhookLease            time.Duration `env:"WEBHOOK_LEASE"`
+	WebhookAllowPrivate     bool          `env:"WEBHOOK_ALLOW_PRIVATE"`
+	EventsBufferSize        int           `env:"EVENTS_BUFFER_SIZE"`
+	EventsHeartbeatInterval time.Duration `env:"EVENTS_HEARTBEAT_INTERVAL"`
+	TracingExporter         string        `env:"TRACING_EXPORTER"`
+	TracingEndpoint         string        `env:"TRACING_ENDPOINT"`
+	TracingSampleRatio      float64       `env:"TRACING_SAMPLE_RATIO"`
 }
 
 func Parse() (*Config, error) {
@@ -28,10 +84,101 @@ func Parse() (*Config, error) {
 	flag.StringVar(&conf.DatabaseURI, "d", defaultValues.DatabaseURI, "database connection string")
 	flag.StringVar(&conf.LogLvl, "l", defaultValues.LogLvl, "application logging level")
 	flag.StringVar(&conf.TokenSecretKey, "k", defaultValues.TokenSecretKey, "secret key to handle authentication")
+	flag.StringVar(&conf.TokenKeysDir, "kd", defaultValues.TokenKeysDir,
+		"directory with token signing keys (<kid>.pem for RS256/EdDSA, <kid>.secret for HS256)")
+	flag.StringVar(&conf.TokenKeyID, "kid", defaultValues.TokenKeyID, "id of the key to sign new tokens with")
 	flag.DurationVar(&conf.TokenLifetime, "t", defaultValues.TokenLifetime, "authentication token lifetime")
+	flag.DurationVar(&conf.RefreshTokenLifetime, "rt", defaultValues.RefreshTokenLifetime, "refresh token lifetime")
 	flag.IntVar(&conf.AccrualRateLimit, "rl", defaultValues.AccrualRateLimit, "accrual requests rate limit")
 	flag.DurationVar(&conf.OrderInfoUpdateInterval, "o", defaultValues.OrderInfoUpdateInterval,
 		"order info update interval")
+	flag.DurationVar(&conf.OrderInfoUpdateTimeout, "ot", defaultValues.OrderInfoUpdateTimeout,
+		"order info update timeout")
+	flag.DurationVar(&conf.OrderInfoUpdateJitter, "oj", defaultValues.OrderInfoUpdateJitter,
+		"max random delay added to order info update interval")
+	flag.IntVar(&conf.AccrualFailureThreshold, "ft", defaultValues.AccrualFailureThreshold,
+		"number of consecutive accrual request failures after which order is reported as failing")
+	flag.DurationVar(&conf.ShutdownTimeout, "st", defaultValues.ShutdownTimeout,
+		"grace period to finish in-flight requests and order info update on shutdown")
+	flag.IntVar(&conf.LoginMaxFailures, "lf", defaultValues.LoginMaxFailures,
+		"failed login attempts per login before lockout (0 disables)")
+	flag.IntVar(&conf.LoginIPMaxFailures, "lif", defaultValues.LoginIPMaxFailures,
+		"failed login attempts per client IP before lockout (0 disables)")
+	flag.DurationVar(&conf.LoginFailureWindow, "lw", defaultValues.LoginFailureWindow,
+		"sliding window to count failed login attempts in")
+	flag.DurationVar(&conf.LoginLockout, "ll", defaultValues.LoginLockout,
+		"first login lockout duration, doubled on each subsequent lockout")
+	flag.DurationVar(&conf.LoginMaxLockout, "llm", defaultValues.LoginMaxLockout, "max login lockout duration")
+	flag.IntVar(&conf.LoginMinLength, "lmin", defaultValues.LoginMinLength, "min login length")
+	flag.IntVar(&conf.LoginMaxLength, "lmax", defaultValues.LoginMaxLength, "max login length")
+	flag.IntVar(&conf.PasswordMinLength, "pmin", defaultValues.PasswordMinLength, "min password length")
+	flag.Float64Var(&conf.PasswordMinEntropy, "pent", defaultValues.PasswordMinEntropy,
+		"min password strength estimate in bits")
+	flag.BoolVar(&conf.CheckBreachedPasswords, "pbreached", defaultValues.CheckBreachedPasswords,
+		"reject passwords found in the bundled list of breached passwords")
+	flag.DurationVar(&conf.PasswordResetLifetime, "prl", defaultValues.PasswordResetLifetime,
+		"password reset token lifetime")
+	flag.IntVar(&conf.ResetMaxRequests, "prf", defaultValues.ResetMaxRequests,
+		"password reset requests per login before lockout (0 disables)")
+	flag.IntVar(&conf.ResetIPMaxRequests, "prif", defaultValues.ResetIPMaxRequests,
+		"password reset requests per client IP before lockout (0 disables)")
+	flag.DurationVar(&conf.ResetWindow, "prw", defaultValues.ResetWindow,
+		"sliding window to count password reset requests in")
+	flag.DurationVar(&conf.ResetLockout, "prll", defaultValues.ResetLockout,
+		"first password reset lockout duration, doubled on each subsequent lockout")
+	flag.DurationVar(&conf.ResetMaxLockout, "prllm", defaultValues.ResetMaxLockout,
+		"max password reset lockout duration")
+	flag.StringVar(&conf.NotificationsFile, "nf", defaultValues.NotificationsFile,
+		"file to append user notifications to (if empty, notifications are written to the log)")
+	flag.BoolVar(&conf.NotificationsLogSecrets, "nls", defaultValues.NotificationsLogSecrets,
+		"write secrets such as password reset tokens to the log with notifications (local development only)")
+	flag.StringVar(&conf.PasswordHashAlgorithm, "ph", defaultValues.PasswordHashAlgorithm,
+		"algorithm to hash new passwords with (bcrypt or argon2id)")
+	flag.IntVar(&conf.BcryptCost, "bcost", defaultValues.BcryptCost, "bcrypt cost")
+	flag.UintVar(&conf.Argon2Time, "a2t", defaultValues.Argon2Time, "argon2id number of passes")
+	flag.UintVar(&conf.Argon2Memory, "a2m", defaultValues.Argon2Memory, "argon2id memory in KiB")
+	flag.UintVar(&conf.Argon2Threads, "a2p", defaultValues.Argon2Threads, "argon2id parallelism")
+	flag.StringVar(&conf.OutboxPublisher, "op", defaultValues.OutboxPublisher,
+		"publisher to deliver order and balance events with (stdout, file or webhook)")
+	flag.StringVar(&conf.OutboxFile, "of", defaultValues.OutboxFile, "file to append events to for file publisher")
+	flag.StringVar(&conf.OutboxWebhookURL, "ou", defaultValues.OutboxWebhookURL, "url to post events to for webhook publisher")
+	flag.DurationVar(&conf.OutboxWebhookTimeout, "owt", defaultValues.OutboxWebhookTimeout, "webhook request timeout")
+	flag.DurationVar(&conf.OutboxRelayInterval, "oi", defaultValues.OutboxRelayInterval, "events delivery interval")
+	flag.IntVar(&conf.OutboxBatchSize, "ob", defaultValues.OutboxBatchSize, "max number of events delivered at once")
+	flag.IntVar(&conf.OutboxMaxAttempts, "oa", defaultValues.OutboxMaxAttempts,
+		"event delivery attempts before it is moved to dead letters")
+	flag.DurationVar(&conf.OutboxBackoff, "obo", defaultValues.OutboxBackoff,
+		"delay before second event delivery attempt, doubled on each subsequent attempt")
+	flag.DurationVar(&conf.OutboxMaxBackoff, "obom", defaultValues.OutboxMaxBackoff,
+		"max delay between event delivery attempts")
+	flag.DurationVar(&conf.OutboxLease, "ol", defaultValues.OutboxLease,
+		"time events selected for delivery are reserved for the service instance")
+	flag.IntVar(&conf.WebhookMaxAttempts, "wa", defaultValues.WebhookMaxAttempts,
+		"user webhook delivery attempts before it is moved to dead letters")
+	flag.DurationVar(&conf.WebhookBackoff, "wb", defaultValues.WebhookBackoff,
+		"delay before second user webhook delivery attempt, doubled on each subsequent attempt")
+	flag.DurationVar(&conf.WebhookMaxBackoff, "wbm", defaultValues.WebhookMaxBackoff,
+		"max delay between user webhook delivery attempts")
+	flag.DurationVar(&conf.WebhookTimeout, "wt", defaultValues.WebhookTimeout, "user webhook request timeout")
+	flag.DurationVar(&conf.WebhookDeliveryInterval, "wi", defaultValues.WebhookDeliveryInterval,
+		"user webhook delivery interval")
+	flag.IntVar(&conf.WebhookBatchSize, "wbs", defaultValues.WebhookBatchSize,
+		"max number of user webhook deliveries sent at once")
+	flag.IntVar(&conf.WebhookConcurrency, "wc", defaultValues.WebhookConcurrency,
+		"max number of concurrent user webhook requests")
+	flag.DurationVar(&conf.WebhookLease, "wl", defaultValues.WebhookLease,
+		"time user webhook deliveries selected for sending are reserved for the service instance")
+	flag.BoolVar(&conf.WebhookAllowPrivate, "wap", defaultValues.WebhookAllowPrivate,
+		"allow user webhooks on loopback and private network addresses (local development only)")
+	flag.IntVar(&conf.EventsBufferSize, "eb", defaultValues.EventsBufferSize,
+		"number of recent user events kept in memory to resume event streams")
+	flag.DurationVar(&conf.EventsHeartbeatInterval, "eh", defaultValues.EventsHeartbeatInterval,
+		"interval of heartbeat comments in user event streams")
+	flag.StringVar(&conf.TracingExporter, "te", defaultValues.TracingExporter,
+		"exporter to send traces with (none, stdout or otlp)")
+	flag.StringVar(&conf.TracingEndpoint, "tep", defaultValues.TracingEndpoint, "OTLP/HTTP traces endpoint url for otlp exporter")
+	flag.Float64Var(&conf.TracingSampleRatio, "tsr", defaultValues.TracingSampleRatio,
+		"fraction of traces started by the service to sample (from 0 to 1)")
 	flag.Parse()
 
 	env.Parse(&conf)
@@ -39,6 +186,25 @@ func Parse() (*Config, error) {
 	if conf.AccrualSysAddr == "" {
 		return nil, errors.New("empty value for accrual system address")
 	}
+	if err := conf.validateScheduler(); err != nil {
+		return nil, err
+	}
+	if err := conf.validatePasswordHash(); err != nil {
+		return nil, err
+	}
+	if err := conf.validateOutbox(); err != nil {
+		return nil, err
+	}
+	if err := conf.validateWebhooks(); err != nil {
+		return nil, err
+	}
+	if conf.EventsBufferSize <= 0 || conf.EventsHeartbeatInterval <= 0 {
+		return nil, fmt.Errorf("invalid user events parameters: buffer size=%d, heartbeat interval=%s",
+			conf.EventsBufferSize, conf.EventsHeartbeatInterval)
+	}
+	if err := conf.validateTracing(); err != nil {
+		return nil, err
+	}
 
 	return &conf, nil
 }
@@ -50,10 +216,150 @@ func GetDefault() (conf *Config) {
 		DatabaseURI:             "",
 		LogLvl:                  "Info",
 		TokenSecretKey:          "SECRET_KEY",
-		TokenLifetime:           8 * time.Hour,
+		TokenLifetime:           15 * time.Minute,
+		RefreshTokenLifetime:    30 * 24 * time.Hour,
 		AccrualRateLimit:        2,
 		OrderInfoUpdateInterval: 30 * time.Second,
+		OrderInfoUpdateTimeout:  20 * time.Second,
+		OrderInfoUpdateJitter:   5 * time.Second,
+		AccrualFailureThreshold: 10,
+		ShutdownTimeout:         10 * time.Second,
+		LoginMaxFailures:        5,
+		LoginIPMaxFailures:      20,
+		LoginFailureWindow:      15 * time.Minute,
+		LoginLockout:            time.Minute,
+		LoginMaxLockout:         time.Hour,
+		LoginMinLength:          3,
+		LoginMaxLength:          64,
+		PasswordMinLength:       8,
+		PasswordMinEntropy:      40,
+		CheckBreachedPasswords:  true,
+		PasswordResetLifetime:   30 * time.Minute,
+		ResetMaxRequests:        3,
+		ResetIPMaxRequests:      20,
+		ResetWindow:             time.Hour,
+		ResetLockout:            15 * time.Minute,
+		ResetMaxLockout:         24 * time.Hour,
+		NotificationsFile:       "",
+		NotificationsLogSecrets: false,
+		PasswordHashAlgorithm:   "bcrypt",
+		BcryptCost:              10,
+		Argon2Time:              3,
+		Argon2Memory:            64 * 1024,
+		Argon2Threads:           2,
+		OutboxPublisher:         "stdout",
+		OutboxFile:              "",
+		OutboxWebhookURL:        "",
+		OutboxWebhookTimeout:    5 * time.Second,
+		OutboxRelayInterval:     time.Second,
+		OutboxBatchSize:         100,
+		OutboxMaxAttempts:       10,
+		OutboxBackoff:           time.Second,
+		OutboxMaxBackoff:        5 * time.Minute,
+		OutboxLease:             time.Minute,
+		WebhookMaxAttempts:      8,
+		WebhookBackoff:          10 * time.Second,
+		WebhookMaxBackoff:       time.Hour,
+		WebhookTimeout:          5 * time.Second,
+		WebhookDeliveryInterval: time.Second,
+		WebhookBatchSize:        20,
+		WebhookConcurrency:      4,
+		WebhookLease:            time.Minute,
+		WebhookAllowPrivate:     false,
+		EventsBufferSize:        1000,
+		EventsHeartbeatInterval: 15 * time.Second,
+		TracingExporter:         "none",
+		TracingEndpoint:         "http://localhost:4318",
+		TracingSampleRatio:      1,
+	}
+}
+
+// Проверка параметров обновления информации о заказах и остановки сервиса
+func (c *Config) validateScheduler() error {
+	if c.OrderInfoUpdateInterval <= 0 || c.OrderInfoUpdateTimeout < 0 || c.OrderInfoUpdateJitter < 0 {
+		return fmt.Errorf("invalid order info update parameters: interval=%s, timeout=%s, jitter=%s",
+			c.OrderInfoUpdateInterval, c.OrderInfoUpdateTimeout, c.OrderInfoUpdateJitter)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive, got %s", c.ShutdownTimeout)
+	}
+	return nil
+}
+
+// Проверка параметров хеширования паролей
+func (c *Config) validatePasswordHash() error {
+	switch c.PasswordHashAlgorithm {
+	case "bcrypt":
+		if c.BcryptCost < 4 || c.BcryptCost > 31 {
+			return fmt.Errorf("bcrypt cost must be from 4 to 31, got %d", c.BcryptCost)
+		}
+	case "argon2id":
+		if c.Argon2Time < 1 || c.Argon2Memory < 8*c.Argon2Threads ||
+			c.Argon2Threads < 1 || c.Argon2Threads > math.MaxUint8 {
+			return fmt.Errorf("invalid argon2id parameters: t=%d, m=%d, p=%d",
+				c.Argon2Time, c.Argon2Memory, c.Argon2Threads)
+		}
+	default:
+		return fmt.Errorf("unsupported password hash algorithm %q", c.PasswordHashAlgorithm)
+	}
+	return nil
+}
+
+// Проверка параметров доставки событий
+func (c *Config) validateOutbox() error {
+	switch c.OutboxPublisher {
+	case "stdout":
+	case "file":
+		if c.OutboxFile == "" {
+			return errors.New("empty value for outbox file")
+		}
+	case "webhook":
+		if c.OutboxWebhookURL == "" {
+			return errors.New("empty value for outbox webhook url")
+		}
+	default:
+		return fmt.Errorf("unsupported outbox publisher %q", c.OutboxPublisher)
+	}
+	if c.OutboxRelayInterval <= 0 || c.OutboxBatchSize <= 0 || c.OutboxMaxAttempts < 1 ||
+		c.OutboxBackoff <= 0 || c.OutboxMaxBackoff < c.OutboxBackoff || c.OutboxLease <= 0 {
+		return fmt.Errorf("invalid outbox relay parameters: interval=%s, batch size=%d, attempts=%d, "+
+			"backoff=%s, max backoff=%s, lease=%s", c.OutboxRelayInterval, c.OutboxBatchSize,
+			c.OutboxMaxAttempts, c.OutboxBackoff, c.OutboxMaxBackoff, c.OutboxLease)
+	}
+	return nil
+}
+
+// Проверка параметров доставки на вебхуки пользователей
+func (c *Config) validateWebhooks() error {
+	if c.WebhookMaxAttempts < 1 || c.WebhookBackoff <= 0 || c.WebhookMaxBackoff < c.WebhookBackoff ||
+		c.WebhookDeliveryInterval <= 0 || c.WebhookBatchSize <= 0 || c.WebhookConcurrency <= 0 {
+		return fmt.Errorf("invalid webhook delivery parameters: attempts=%d, backoff=%s, max backoff=%s, "+
+			"interval=%s, batch size=%d, concurrency=%d", c.WebhookMaxAttempts, c.WebhookBackoff,
+			c.WebhookMaxBackoff, c.WebhookDeliveryInterval, c.WebhookBatchSize, c.WebhookConcurrency)
+	}
+	// Запросы отправляются в течение половины аренды и должны успевать завершиться
+	if c.WebhookLease < 2*c.WebhookTimeout {
+		return fmt.Errorf("webhook lease %s must be at least twice the webhook timeout %s",
+			c.WebhookLease, c.WebhookTimeout)
+	}
+	return nil
+}
+
+// Проверка параметров трассировки
+func (c *Config) validateTracing() error {
+	switch c.TracingExporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.TracingEndpoint == "" {
+			return errors.New("empty value for tracing endpoint")
+		}
+	default:
+		return fmt.Errorf("unsupported tracing exporter %q", c.TracingExporter)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be from 0 to 1, got %v", c.TracingSampleRatio)
 	}
+	return nil
 }
 
 func (c *Config) NormilizedAccrualSysAddr() string {
@@ -0,0 +1,232 @@
+// Package credpolicy проверяет логин и пароль нового пользователя на соответствие
+// политике: длина и набор символов логина, длина и стойкость пароля,
+// несовпадение пароля с логином и отсутствие пароля в списке утекших.
+package credpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Список распространенных паролей из публичных утечек
+//
+//go:embed breached_passwords.txt
+var breachedPasswords string
+
+// Допустимые символы логина: латинские буквы, цифры, точка, дефис и подчеркивание;
+// логин начинается с буквы или цифры
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Правила политики
+const (
+	RuleLoginLength      = "login_length"
+	RuleLoginCharset     = "login_charset"
+	RulePasswordLength   = "password_length"
+	RulePasswordEntropy  = "password_entropy"
+	RulePasswordIsLogin  = "password_equals_login"
+	RulePasswordBreached = "password_breached"
+)
+
+const (
+	fieldLogin    = "login"
+	fieldPassword = "password"
+
+	symbolsPoolSize    = 33  // печатные ASCII символы, кроме букв и цифр
+	otherRunesPoolSize = 100 // оценка для прочих символов Unicode
+)
+
+type (
+	Config struct {
+		LoginMinLength     int
+		LoginMaxLength     int
+		PasswordMinLength  int
+		PasswordMinEntropy float64 // минимальная оценка стойкости пароля в битах
+		CheckBreached      bool    // проверять пароль по списку утекших
+	}
+
+	// Нарушенное правило политики
+	Violation struct {
+		Field   string `json:"field"`
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+
+	// Ошибка с перечнем всех нарушенных правил
+	PolicyError struct {
+		Violations []Violation `json:"violations"`
+	}
+
+	Policy struct {
+		conf     Config
+		breached map[string]struct{}
+	}
+)
+
+func New(conf Config) *Policy {
+	p := &Policy{
+		conf:     conf,
+		breached: make(map[string]struct{}),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(breachedPasswords))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	return p
+}
+
+func (e *PolicyError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return fmt.Sprintf("%s: %s", appErrors.ErrCredentialPolicyViolation, strings.Join(rules, ", "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return appErrors.ErrCredentialPolicyViolation
+}
+
+// Проверка логина и пароля. Возвращает *PolicyError со всеми нарушенными правилами.
+func (p *Policy) Check(login, password string) error {
+	violations := []Violation{}
+	violations = append(violations, p.checkLogin(login)...)
+	violations = append(violations, p.checkPassword(login, password)...)
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Проверка только пароля существующего пользователя при его смене или сбросе.
+// Логин, зарегистрированный до введения политики, может ей не соответствовать
+// и не должен мешать смене пароля. Возвращает *PolicyError с нарушенными правилами.
+func (p *Policy) CheckPassword(login, password string) error {
+	if violations := p.checkPassword(login, password); len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (p *Policy) checkLogin(login string) []Violation {
+	violations := []Violation{}
+
+	length := utf8.RuneCountInString(login)
+	if length < p.conf.LoginMinLength || (p.conf.LoginMaxLength > 0 && length > p.conf.LoginMaxLength) {
+		violations = append(violations, Violation{
+			Field:   fieldLogin,
+			Rule:    RuleLoginLength,
+			Message: fmt.Sprintf("login must be from %d to %d characters long", p.conf.LoginMinLength, p.conf.LoginMaxLength),
+		})
+	}
+	if !loginPattern.MatchString(login) {
+		violations = append(violations, Violation{
+			Field:   fieldLogin,
+			Rule:    RuleLoginCharset,
+			Message: "login may contain only latin letters, digits, '.', '_' and '-' and must start with a letter or digit",
+		})
+	}
+
+	return violations
+}
+
+func (p *Policy) checkPassword(login, password string) []Violation {
+	violations := []Violation{}
+
+	if utf8.RuneCountInString(password) < p.conf.PasswordMinLength {
+		violations = append(violations, Violation{
+			Field:   fieldPassword,
+			Rule:    RulePasswordLength,
+			Message: fmt.Sprintf("password must be at least %d characters long", p.conf.PasswordMinLength),
+		})
+	}
+	if entropy := Entropy(password); entropy < p.conf.PasswordMinEntropy {
+		violations = append(violations, Violation{
+			Field: fieldPassword,
+			Rule:  RulePasswordEntropy,
+			Message: fmt.Sprintf("password is too weak (%.0f of %.0f bits), use a longer password with mixed character classes",
+				entropy, p.conf.PasswordMinEntropy),
+		})
+	}
+	if strings.EqualFold(password, login) {
+		violations = append(violations, Violation{
+			Field:   fieldPassword,
+			Rule:    RulePasswordIsLogin,
+			Message: "password must not be equal to login",
+		})
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; p.conf.CheckBreached && ok {
+		violations = append(violations, Violation{
+			Field:   fieldPassword,
+			Rule:    RulePasswordBreached,
+			Message: "password is found in a list of breached passwords",
+		})
+	}
+
+	return violations
+}
+
+// Оценка стойкости пароля в битах: длина пароля, умноженная на двоичный логарифм
+// размера алфавита из классов символов, встречающихся в пароле.
+// Повторы символа увеличивают учитываемую длину лишь логарифмически.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	seen := make(map[rune]int)
+	for _, r := range password {
+		seen[r]++
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += symbolsPoolSize
+	}
+	if other {
+		pool += otherRunesPoolSize
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	// Повторы одного символа почти не добавляют стойкости
+	length := 0.0
+	for _, n := range seen {
+		length += 1 + math.Log2(float64(n))
+	}
+
+	return length * math.Log2(float64(pool))
+}
@@ -0,0 +1,133 @@
+package credpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+var testConfig = Config{
+	LoginMinLength:     3,
+	LoginMaxLength:     16,
+	PasswordMinLength:  8,
+	PasswordMinEntropy: 40,
+	CheckBreached:      true,
+}
+
+func TestPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name          string
+		login         string
+		password      string
+		expectedRules []string
+	}{
+		{
+			name:          "Success Case",
+			login:         "gopher.user",
+			password:      "correct-horse-battery",
+			expectedRules: nil,
+		},
+		{
+			name:          "Short login Case",
+			login:         "go",
+			password:      "correct-horse-battery",
+			expectedRules: []string{RuleLoginLength},
+		},
+		{
+			name:          "Long login Case",
+			login:         "a-very-long-login-name",
+			password:      "correct-horse-battery",
+			expectedRules: []string{RuleLoginLength},
+		},
+		{
+			name:          "Login charset Case",
+			login:         "_гофер",
+			password:      "correct-horse-battery",
+			expectedRules: []string{RuleLoginCharset},
+		},
+		{
+			name:          "Short password Case",
+			login:         "gopher",
+			password:      "Xy7#q",
+			expectedRules: []string{RulePasswordLength, RulePasswordEntropy},
+		},
+		{
+			name:          "Weak password Case",
+			login:         "gopher",
+			password:      "aaaaaaaaaaaa",
+			expectedRules: []string{RulePasswordEntropy},
+		},
+		{
+			name:          "Password equals login Case",
+			login:         "Correct-Horse-Battery",
+			password:      "correct-horse-battery",
+			expectedRules: []string{RuleLoginLength, RulePasswordIsLogin},
+		},
+		{
+			name:          "Breached password Case",
+			login:         "gopher",
+			password:      "P@ssw0rd",
+			expectedRules: []string{RulePasswordBreached},
+		},
+		{
+			name:          "Several violations Case",
+			login:         "go",
+			password:      "qwerty",
+			expectedRules: []string{RuleLoginLength, RulePasswordLength, RulePasswordEntropy, RulePasswordBreached},
+		},
+	}
+
+	policy := New(testConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.login, tt.password)
+
+			if tt.expectedRules == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, appErrors.ErrCredentialPolicyViolation)
+			var policyErr *PolicyError
+			require.ErrorAs(t, err, &policyErr)
+
+			rules := []string{}
+			for _, v := range policyErr.Violations {
+				rules = append(rules, v.Rule)
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tt.expectedRules, rules)
+		})
+	}
+}
+
+func TestPolicy_CheckPassword(t *testing.T) {
+	p := New(testConfig)
+
+	// Логин, не соответствующий политике, не проверяется
+	assert.NoError(t, p.CheckPassword("a@b", "correct-horse-battery"))
+
+	var policyErr *PolicyError
+	require.ErrorAs(t, p.CheckPassword("a@b", "a@b"), &policyErr)
+	for _, v := range policyErr.Violations {
+		assert.Equal(t, fieldPassword, v.Field)
+	}
+}
+
+func TestPolicy_CheckBreachedDisabled(t *testing.T) {
+	conf := testConfig
+	conf.CheckBreached = false
+
+	assert.NoError(t, New(conf).Check("gopher", "P@ssw0rd"))
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Zero(t, Entropy(""))
+	assert.InDelta(t, 8*4.70, Entropy("abcdefgh"), 0.01)
+	// Смешанные классы символов увеличивают оценку
+	assert.Greater(t, Entropy("aB3$eF7&"), Entropy("abcdefgh"))
+	// Повторы символа почти не увеличивают оценку
+	assert.Less(t, Entropy("aaaaaaaaaaaa"), Entropy("abcdefgh"))
+}
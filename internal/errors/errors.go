@@ -8,15 +8,52 @@ var (
 	ErrInvalidOrderNumber            = errors.New("invalid order number")
 	ErrOrderWasUploadedByCurrentUser = errors.New("the order was uploaded by current user")
 	ErrOrderWasUploadedByAnotherUser = errors.New("the order was uploaded by another user")
+	ErrOrderNotFound                 = errors.New("order not found")
+	ErrEmptyOrderBatch               = errors.New("order batch is empty")
+	ErrOrderBatchTooLarge            = errors.New("order batch is too large")
+	ErrInvalidOrdersQuery            = errors.New("invalid orders query")
+	ErrInvalidOrdersCursor           = errors.New("invalid orders cursor")
+	ErrInvalidWithdrawalsQuery       = errors.New("invalid withdrawals query")
+	ErrInvalidWithdrawalsCursor      = errors.New("invalid withdrawals cursor")
 
 	ErrUserLoginAlreadyExists       = errors.New("user login already exists")
 	ErrInvalidUserLoginOrPassword   = errors.New("invalid login or password")
 	ErrUserLoginAndPasswordRequired = errors.New("login and password are required")
 	ErrUserUnauthorized             = errors.New("user unauthorized")
 	ErrUserInalidID                 = errors.New("invalid user ID")
+	ErrUserNotFound                 = errors.New("user not found")
+	ErrTooManyLoginAttempts         = errors.New("too many login attempts")
+	ErrCredentialPolicyViolation    = errors.New("credentials violate policy")
+	ErrInvalidPassword              = errors.New("invalid password")
+	ErrPasswordRequired             = errors.New("old and new passwords are required")
+	ErrUserLoginRequired            = errors.New("login is required")
 
-	ErrNegativeBalance = errors.New("negative balance")
+	ErrTokenRevoked         = errors.New("token revoked")
+	ErrRefreshTokenInvalid  = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+	ErrRefreshTokenRequired = errors.New("refresh token is required")
+
+	ErrPasswordResetTokenInvalid    = errors.New("invalid, expired or used password reset token")
+	ErrPasswordResetTokenRequired   = errors.New("password reset token and new password are required")
+	ErrTooManyPasswordResetRequests = errors.New("too many password reset requests")
+
+	ErrUnknownSigningKey       = errors.New("unknown signing key id")
+	ErrSigningKeyCannotSign    = errors.New("signing key has no private part")
+	ErrUnsupportedSigningKey   = errors.New("unsupported signing key")
+	ErrTokenAlgorithmMismatch  = errors.New("token algorithm does not match signing key")
+	ErrUnsupportedPasswordHash = errors.New("unsupported password hash")
+
+	ErrNegativeBalance      = errors.New("negative balance")
+	ErrInvalidWithdrawalSum = errors.New("withdrawal sum must be positive")
+
+	ErrInvalidWebhookURL           = errors.New("invalid webhook url")
+	ErrPrivateWebhookAddress       = errors.New("webhook address is not public")
+	ErrTooManyWebhooks             = errors.New("too many webhooks")
+	ErrWebhookNotFound             = errors.New("webhook not found")
+	ErrInvalidWebhookDeliveryQuery = errors.New("invalid webhook delivery query")
 
 	ErrAccrualOrderNotRegistered = errors.New("order not registered in accrual service")
 	ErrAccrualTooManyRequests    = errors.New("too many requests to accrual service")
+
+	ErrTaskAlreadyRunning = errors.New("task is already running")
 )
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Размер очереди событий подписчика. Подписчик, не успевающий читать
+// события, отключается и может возобновить поток из буфера.
+const subscriptionQueueSize = 64
+
+type (
+	// Рассылка событий пользователей подписчикам потока обновлений.
+	// Последние события хранятся в памяти экземпляра сервиса, чтобы
+	// переподключившийся клиент мог получить пропущенные события.
+	Broker struct {
+		mu          sync.Mutex
+		bufferSize  int
+		buffer      []models.UserEvent // последние события всех пользователей по возрастанию ID
+		lastID      int64
+		evictedID   int64 // ID последнего вытесненного из буфера события
+		subscribers map[int64]map[*Subscription]struct{}
+		closed      bool
+	}
+
+	// Подписка на события пользователя
+	Subscription struct {
+		userID int64
+		events chan models.UserEvent
+
+		// События после Last-Event-ID, оставшиеся в буфере
+		Missed []models.UserEvent
+		// Часть событий после Last-Event-ID недоступна: они вытеснены из буфера
+		// или идентификатор получен от другого экземпляра сервиса
+		Gap bool
+		// ID последнего события на момент подписки
+		LastID int64
+	}
+)
+
+// Идентификаторы событий начинаются с времени запуска, чтобы после перезапуска
+// сервиса идентификаторы, полученные клиентами ранее, не совпадали с новыми
+func NewBroker(bufferSize int) *Broker {
+	startID := time.Now().UnixMicro()
+	return &Broker{
+		bufferSize:  bufferSize,
+		buffer:      make([]models.UserEvent, 0, bufferSize),
+		lastID:      startID,
+		evictedID:   startID,
+		subscribers: make(map[int64]map[*Subscription]struct{}),
+	}
+}
+
+// Публикация событий. Событиям присваиваются очередные идентификаторы.
+func (b *Broker) Publish(events ...models.UserEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, event := range events {
+		b.lastID++
+		event.ID = b.lastID
+		event.CreatedAt = time.Now()
+
+		if len(b.buffer) == b.bufferSize {
+			b.evictedID = b.buffer[0].ID
+			b.buffer = append(b.buffer[:0], b.buffer[1:]...)
+		}
+		b.buffer = append(b.buffer, event)
+
+		for sub := range b.subscribers[event.UserID] {
+			select {
+			case sub.events <- event:
+			default:
+				b.remove(sub)
+			}
+		}
+	}
+}
+
+// Подписка на события пользователя, начиная с события, следующего за lastEventID.
+// Нулевой lastEventID означает подписку только на новые события.
+func (b *Broker) Subscribe(userID, lastEventID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		userID: userID,
+		events: make(chan models.UserEvent, subscriptionQueueSize),
+		LastID: b.lastID,
+	}
+	if b.closed {
+		close(sub.events)
+		return sub
+	}
+
+	if lastEventID != 0 {
+		sub.Gap = lastEventID < b.evictedID || lastEventID > b.lastID
+		for _, event := range b.buffer {
+			if event.UserID == userID && event.ID > lastEventID {
+				sub.Missed = append(sub.Missed, event)
+			}
+		}
+	}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Отмена подписки. Повторная отмена ничего не делает.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// Отключение всех подписчиков при остановке сервиса
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	subs, ok := b.subscribers[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+	close(sub.events)
+}
+
+// Новые события пользователя. Канал закрывается при отмене подписки,
+// отключении медленного подписчика или остановке сервиса.
+func (s *Subscription) Events() <-chan models.UserEvent {
+	return s.events
+}
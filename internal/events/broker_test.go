@@ -0,0 +1,99 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func TestBroker_Subscribe(t *testing.T) {
+	b := NewBroker(3)
+	order := func(userID int64) models.UserEvent {
+		return models.NewOrderUserEvent(models.Order{UserID: userID, Number: "12345678903", Status: models.OrderStatusProcessing})
+	}
+
+	first := b.Subscribe(1, 0)
+	b.Publish(order(1), order(2), order(1))
+
+	// Подписчик получает только события своего пользователя
+	received := []models.UserEvent{<-first.Events(), <-first.Events()}
+	assert.Len(t, first.Events(), 0)
+	assert.Less(t, received[0].ID, received[1].ID)
+	assert.Equal(t, int64(1), received[1].UserID)
+
+	tests := []struct {
+		name           string
+		lastEventID    int64
+		expectedMissed []int64
+		expectedGap    bool
+	}{
+		{name: "New Events Only Case", lastEventID: 0},
+		{name: "Resume Case", lastEventID: received[0].ID, expectedMissed: []int64{received[1].ID}},
+		{name: "Up To Date Case", lastEventID: received[1].ID},
+		{name: "Unknown Id Case", lastEventID: received[1].ID + 100, expectedGap: true},
+		{name: "Previous Instance Case", lastEventID: 1, expectedGap: true, expectedMissed: []int64{received[0].ID, received[1].ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := b.Subscribe(1, tt.lastEventID)
+			defer b.Unsubscribe(sub)
+
+			missed := make([]int64, 0)
+			for _, event := range sub.Missed {
+				missed = append(missed, event.ID)
+			}
+			assert.ElementsMatch(t, tt.expectedMissed, missed)
+			assert.Equal(t, tt.expectedGap, sub.Gap)
+			assert.Equal(t, received[1].ID, sub.LastID)
+		})
+	}
+
+	// Вытеснение событий из буфера
+	b.Publish(order(2))
+	sub := b.Subscribe(1, received[0].ID-1)
+	assert.True(t, sub.Gap)
+	assert.Len(t, sub.Missed, 1)
+	b.Unsubscribe(sub)
+
+	// Повторная отмена подписки и остановка закрывают канал один раз
+	b.Unsubscribe(first)
+	b.Unsubscribe(first)
+	_, ok := <-first.Events()
+	assert.False(t, ok)
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(1, 0)
+
+	for range subscriptionQueueSize + 1 {
+		b.Publish(models.NewBalanceUserEvent(1, models.Balance{}))
+	}
+
+	// Переполнение очереди отключает подписчика
+	for range subscriptionQueueSize {
+		_, ok := <-sub.Events()
+		require.True(t, ok)
+	}
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(1, 0)
+	b.Close()
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+
+	// После остановки новые подписки сразу закрыты, события не публикуются
+	b.Publish(models.NewBalanceUserEvent(1, models.Balance{}))
+	sub = b.Subscribe(1, 0)
+	_, ok = <-sub.Events()
+	assert.False(t, ok)
+	b.Unsubscribe(sub)
+}
@@ -0,0 +1,200 @@
+// Package loginlimit ограничивает перебор паролей: считает неудачные попытки входа
+// по логину и по IP адресу клиента в скользящем окне и временно блокирует вход
+// с экспоненциально растущей длительностью блокировки.
+package loginlimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Ограничение показателя степени, чтобы длительность блокировки не переполнялась
+const maxBackoffShift = 30
+
+type (
+	Config struct {
+		Reason             models.LoginLockoutReason // причина блокировки в журнале (по умолчанию вход)
+		MaxLoginFailures   int                       // неудачных попыток по логину до блокировки (0 — без ограничения)
+		MaxIPFailures      int                       // неудачных попыток с IP адреса до блокировки (0 — без ограничения)
+		Window             time.Duration             // окно подсчета неудачных попыток
+		LockoutDuration    time.Duration             // длительность первой блокировки
+		MaxLockoutDuration time.Duration             // максимальная длительность блокировки
+	}
+
+	// Журнал блокировок
+	Auditor interface {
+		AddLoginLockout(ctx context.Context, lockout models.LoginLockout) error
+	}
+
+	Limiter struct {
+		store Store
+		audit Auditor
+		conf  Config
+		now   func() time.Time
+	}
+
+	// Ошибка попытки входа во время блокировки
+	LockedError struct {
+		RetryAfter time.Duration
+	}
+
+	subject struct {
+		kind        models.LoginLockoutSubject
+		value       string
+		maxFailures int
+	}
+)
+
+func New(store Store, audit Auditor, conf Config) *Limiter {
+	return &Limiter{
+		store: store,
+		audit: audit,
+		conf:  conf,
+		now:   time.Now,
+	}
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", appErrors.ErrTooManyLoginAttempts, e.RetryAfter)
+}
+
+func (e *LockedError) Unwrap() error {
+	return appErrors.ErrTooManyLoginAttempts
+}
+
+// Проверка блокировки входа по логину и IP адресу. Возвращает *LockedError,
+// если вход заблокирован.
+func (l *Limiter) Check(ctx context.Context, login, ip string) error {
+	now := l.now()
+
+	var retryAfter time.Duration
+	for _, s := range l.subjects(login, ip) {
+		lockout, err := l.store.GetLockout(ctx, s.key())
+		if err != nil {
+			return fmt.Errorf("loginlimit.check: %w", err)
+		}
+		if wait := lockout.Until.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Учет неудачной попытки входа. При превышении порога вход блокируется.
+func (l *Limiter) Fail(ctx context.Context, login, ip string) error {
+	now := l.now()
+
+	for _, s := range l.subjects(login, ip) {
+		failures, err := l.store.AddFailure(ctx, s.key(), now, l.conf.Window)
+		if err != nil {
+			return fmt.Errorf("loginlimit.fail.addFailure: %w", err)
+		}
+		if failures < s.maxFailures {
+			continue
+		}
+
+		if err := l.lock(ctx, s, failures, now); err != nil {
+			return fmt.Errorf("loginlimit.fail: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Учет успешного входа: сбрасываются неудачные попытки и счетчик блокировок логина.
+// Счетчики IP адреса не сбрасываются, чтобы вход в свою учетную запись
+// не позволял продолжить перебор с того же адреса.
+func (l *Limiter) Succeed(ctx context.Context, login string) error {
+	key := subject{kind: models.LoginLockoutSubjectLogin, value: login}.key()
+
+	if err := l.store.ClearFailures(ctx, key); err != nil {
+		return fmt.Errorf("loginlimit.succeed.clearFailures: %w", err)
+	}
+	if err := l.store.SetLockout(ctx, key, Lockout{}); err != nil {
+		return fmt.Errorf("loginlimit.succeed.resetLockout: %w", err)
+	}
+	return nil
+}
+
+func (l *Limiter) lock(ctx context.Context, s subject, failures int, now time.Time) error {
+	lockout, err := l.store.GetLockout(ctx, s.key())
+	if err != nil {
+		return fmt.Errorf("lock.getLockout: %w", err)
+	}
+
+	// Счетчик блокировок забывается, если после последней прошло достаточно времени
+	if now.After(lockout.Until.Add(l.conf.MaxLockoutDuration)) {
+		lockout.Count = 0
+	}
+	lockout.Count++
+	lockout.Until = now.Add(l.lockoutDuration(lockout.Count))
+
+	if err := l.store.SetLockout(ctx, s.key(), lockout); err != nil {
+		return fmt.Errorf("lock.setLockout: %w", err)
+	}
+	if err := l.store.ClearFailures(ctx, s.key()); err != nil {
+		return fmt.Errorf("lock.clearFailures: %w", err)
+	}
+
+	log.Warn().
+		Str("reason", string(l.reason())).
+		Str("subject_type", string(s.kind)).
+		Str("subject", s.value).
+		Int("failures", failures).
+		Time("locked_until", lockout.Until).
+		Msg("locked due to too many failed attempts")
+
+	err = l.audit.AddLoginLockout(ctx, models.LoginLockout{
+		Reason:      l.reason(),
+		SubjectType: s.kind,
+		Subject:     s.value,
+		Failures:    failures,
+		LockedUntil: lockout.Until,
+	})
+	if err != nil {
+		return fmt.Errorf("lock.audit: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Limiter) reason() models.LoginLockoutReason {
+	if l.conf.Reason == "" {
+		return models.LoginLockoutReasonLogin
+	}
+	return l.conf.Reason
+}
+
+// Длительность n-й блокировки подряд: удваивается с каждой блокировкой
+func (l *Limiter) lockoutDuration(n int) time.Duration {
+	shift := min(n-1, maxBackoffShift)
+	d := l.conf.LockoutDuration << shift
+	if l.conf.MaxLockoutDuration > 0 && (d > l.conf.MaxLockoutDuration || d <= 0) {
+		return l.conf.MaxLockoutDuration
+	}
+	return d
+}
+
+func (l *Limiter) subjects(login, ip string) []subject {
+	subjects := make([]subject, 0, 2)
+	if l.conf.MaxLoginFailures > 0 && login != "" {
+		subjects = append(subjects, subject{kind: models.LoginLockoutSubjectLogin, value: login, maxFailures: l.conf.MaxLoginFailures})
+	}
+	if l.conf.MaxIPFailures > 0 && ip != "" {
+		subjects = append(subjects, subject{kind: models.LoginLockoutSubjectIP, value: ip, maxFailures: l.conf.MaxIPFailures})
+	}
+	return subjects
+}
+
+func (s subject) key() string {
+	return string(s.kind) + ":" + s.value
+}
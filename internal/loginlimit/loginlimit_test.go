@@ -0,0 +1,159 @@
+package loginlimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+type fakeAuditor struct {
+	mu       sync.Mutex
+	lockouts []models.LoginLockout
+}
+
+func (a *fakeAuditor) AddLoginLockout(ctx context.Context, lockout models.LoginLockout) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lockouts = append(a.lockouts, lockout)
+	return nil
+}
+
+func newTestLimiter(conf Config) (*Limiter, *fakeAuditor, *time.Time) {
+	audit := &fakeAuditor{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore(conf.MaxLockoutDuration), audit, conf)
+	l.now = func() time.Time { return now }
+	return l, audit, &now
+}
+
+var testConfig = Config{
+	MaxLoginFailures:   3,
+	MaxIPFailures:      5,
+	Window:             time.Minute,
+	LockoutDuration:    10 * time.Second,
+	MaxLockoutDuration: 30 * time.Second,
+}
+
+func failN(t *testing.T, l *Limiter, n int, login, ip string) {
+	t.Helper()
+	for range n {
+		require.NoError(t, l.Check(context.Background(), login, ip))
+		require.NoError(t, l.Fail(context.Background(), login, ip))
+	}
+}
+
+func TestLimiter_LoginLockout(t *testing.T) {
+	ctx := context.Background()
+	l, audit, now := newTestLimiter(testConfig)
+
+	// Попытки учитываются только по логину
+	failN(t, l, 3, "user", "")
+
+	// Логин заблокирован для любого адреса
+	err := l.Check(ctx, "user", "192.0.2.2")
+	var lockedErr *LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.ErrorIs(t, err, appErrors.ErrTooManyLoginAttempts)
+	assert.Equal(t, 10*time.Second, lockedErr.RetryAfter)
+	assert.NoError(t, l.Check(ctx, "other", "192.0.2.1"))
+
+	require.Len(t, audit.lockouts, 1)
+	assert.Equal(t, models.LoginLockoutReasonLogin, audit.lockouts[0].Reason)
+	assert.Equal(t, models.LoginLockoutSubjectLogin, audit.lockouts[0].SubjectType)
+	assert.Equal(t, "user", audit.lockouts[0].Subject)
+	assert.Equal(t, 3, audit.lockouts[0].Failures)
+
+	// Каждая следующая блокировка вдвое длиннее, но не длиннее максимальной
+	for _, expected := range []time.Duration{20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		*now = now.Add(lockedErr.RetryAfter)
+		failN(t, l, 3, "user", "")
+
+		err = l.Check(ctx, "user", "192.0.2.1")
+		require.ErrorAs(t, err, &lockedErr)
+		assert.Equal(t, expected, lockedErr.RetryAfter)
+	}
+}
+
+func TestLimiter_Reason(t *testing.T) {
+	conf := testConfig
+	conf.Reason = models.LoginLockoutReasonPasswordReset
+	l, audit, _ := newTestLimiter(conf)
+
+	failN(t, l, 3, "user", "")
+
+	require.Len(t, audit.lockouts, 1)
+	assert.Equal(t, models.LoginLockoutReasonPasswordReset, audit.lockouts[0].Reason)
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	l, _, now := newTestLimiter(testConfig)
+
+	// Попытки за пределами окна не учитываются
+	failN(t, l, 2, "user", "")
+	*now = now.Add(time.Minute)
+	failN(t, l, 2, "user", "")
+	assert.NoError(t, l.Check(ctx, "user", "192.0.2.1"))
+
+	require.NoError(t, l.Fail(ctx, "user", ""))
+	assert.Error(t, l.Check(ctx, "user", "192.0.2.1"))
+}
+
+func TestLimiter_IPLockout(t *testing.T) {
+	ctx := context.Background()
+	l, audit, _ := newTestLimiter(testConfig)
+
+	// Перебор разных логинов с одного адреса
+	for _, login := range []string{"a", "b", "c", "d", "e"} {
+		failN(t, l, 1, login, "192.0.2.1")
+	}
+
+	assert.ErrorIs(t, l.Check(ctx, "f", "192.0.2.1"), appErrors.ErrTooManyLoginAttempts)
+	assert.NoError(t, l.Check(ctx, "f", "192.0.2.2"))
+
+	require.Len(t, audit.lockouts, 1)
+	assert.Equal(t, models.LoginLockoutSubjectIP, audit.lockouts[0].SubjectType)
+	assert.Equal(t, "192.0.2.1", audit.lockouts[0].Subject)
+}
+
+func TestLimiter_Succeed(t *testing.T) {
+	ctx := context.Background()
+	l, _, now := newTestLimiter(testConfig)
+
+	// Успешный вход сбрасывает неудачные попытки и длительность блокировки
+	failN(t, l, 3, "user", "")
+	*now = now.Add(10 * time.Second)
+	require.NoError(t, l.Succeed(ctx, "user"))
+
+	failN(t, l, 3, "user", "")
+	var lockedErr *LockedError
+	require.ErrorAs(t, l.Check(ctx, "user", "192.0.2.1"), &lockedErr)
+	assert.Equal(t, 10*time.Second, lockedErr.RetryAfter)
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(30 * time.Second)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := store.AddFailure(ctx, "login:old", now, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.SetLockout(ctx, "login:old", Lockout{Until: now.Add(10 * time.Second), Count: 1}))
+	require.NoError(t, store.SetLockout(ctx, "login:recent", Lockout{Until: now.Add(time.Minute), Count: 1}))
+
+	// Через окно удаляются ключи без попыток в окне и блокировки с истекшим сроком хранения
+	now = now.Add(time.Minute)
+	_, err = store.AddFailure(ctx, "login:new", now, time.Minute)
+	require.NoError(t, err)
+
+	assert.NotContains(t, store.failures, "login:old")
+	assert.Contains(t, store.failures, "login:new")
+	assert.NotContains(t, store.lockouts, "login:old")
+	assert.Contains(t, store.lockouts, "login:recent")
+}
@@ -0,0 +1,113 @@
+package loginlimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Хранилище неудачных попыток входа и блокировок. Ключом служит
+	// логин или IP адрес клиента с префиксом вида субъекта.
+	Store interface {
+		// Добавление неудачной попытки. Возвращает количество попыток
+		// за последние window, включая добавленную.
+		AddFailure(ctx context.Context, key string, at time.Time, window time.Duration) (int, error)
+		ClearFailures(ctx context.Context, key string) error
+
+		GetLockout(ctx context.Context, key string) (Lockout, error)
+		SetLockout(ctx context.Context, key string, lockout Lockout) error
+	}
+
+	// Блокировка попыток входа
+	Lockout struct {
+		Until time.Time // время окончания блокировки
+		Count int       // количество блокировок подряд, определяет длительность следующей
+	}
+
+	// Хранилище в памяти процесса для развертывания в одном экземпляре.
+	// Устаревшие записи периодически удаляются при добавлении попыток,
+	// чтобы перебор логинов и адресов не занимал память без ограничений.
+	MemoryStore struct {
+		mu               sync.Mutex
+		failures         map[string][]time.Time
+		lockouts         map[string]Lockout
+		lockoutRetention time.Duration // время хранения блокировки после ее окончания
+		prunedAt         time.Time     // время последней очистки устаревших записей
+	}
+)
+
+// Блокировка хранится lockoutRetention после окончания, чтобы следующая
+// блокировка в этот период считалась повторной и была длиннее
+func NewMemoryStore(lockoutRetention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		failures:         make(map[string][]time.Time),
+		lockouts:         make(map[string]Lockout),
+		lockoutRetention: lockoutRetention,
+	}
+}
+
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, at time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Попытки старше окна отбрасываются
+	failures := s.failures[key]
+	from := at.Add(-window)
+	i := 0
+	for i < len(failures) && !failures[i].After(from) {
+		i++
+	}
+	failures = append(failures[i:], at)
+	s.failures[key] = failures
+
+	// Очистка выполняется не чаще одного раза за окно
+	if at.Sub(s.prunedAt) >= window {
+		s.prune(at, window)
+	}
+
+	return len(failures), nil
+}
+
+// Удаление ключей без попыток в окне и блокировок, срок хранения которых истек
+func (s *MemoryStore) prune(now time.Time, window time.Duration) {
+	from := now.Add(-window)
+	for key, failures := range s.failures {
+		if !failures[len(failures)-1].After(from) {
+			delete(s.failures, key)
+		}
+	}
+	for key, lockout := range s.lockouts {
+		if now.After(lockout.Until.Add(s.lockoutRetention)) {
+			delete(s.lockouts, key)
+		}
+	}
+	s.prunedAt = now
+}
+
+func (s *MemoryStore) ClearFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryStore) GetLockout(ctx context.Context, key string) (Lockout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lockouts[key], nil
+}
+
+func (s *MemoryStore) SetLockout(ctx context.Context, key string, lockout Lockout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lockout == (Lockout{}) {
+		delete(s.lockouts, key)
+		return nil
+	}
+	s.lockouts[key] = lockout
+	return nil
+}
@@ -1,11 +1,18 @@
 package luhn
 
-// Валидация числа по алгоритму Луна
+// Валидация непустого числа из цифр по алгоритму Луна
 func ValidateNumber(number string) bool {
+	if number == "" {
+		return false
+	}
+
 	total := 0
 	isSecondDigit := false
 
 	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			return false
+		}
 		digit := int(number[i] - '0')
 
 		if isSecondDigit {
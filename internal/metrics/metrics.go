@@ -0,0 +1,210 @@
+// Package metrics содержит метрики сервиса в формате Prometheus.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+const namespace = "gophermart"
+
+// Время на получение количества заказов при сборе метрик
+const ordersCountTimeout = 5 * time.Second
+
+var (
+	// Реестр метрик сервиса
+	Registry = prometheus.NewRegistry()
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	AccrualRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accrual_requests_total",
+		Help:      "Number of accrual system requests by response status code (error if no response was received).",
+	}, []string{"code"})
+
+	WorkerPoolQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workerpool_queue_depth",
+		Help:      "Number of submitted worker pool jobs waiting for a worker by pool.",
+	}, []string{"pool"})
+
+	WorkerPoolJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "workerpool_job_duration_seconds",
+		Help:      "Worker pool job processing duration by pool.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"pool"})
+
+	SchedulerRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_run_duration_seconds",
+		Help:      "Background task run duration by task and result.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"task", "result"})
+
+	SchedulerLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful background task run.",
+	}, []string{"task"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		AccrualRequests,
+		WorkerPoolQueueDepth,
+		WorkerPoolJobDuration,
+		SchedulerRunDuration,
+		SchedulerLastSuccess,
+	)
+}
+
+// Обработчик /metrics. Метрики реестра сервиса дополняются переданными
+// коллекторами, которые собираются только этим обработчиком.
+func Handler(extra ...prometheus.Collector) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(extra...)
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, reg}, promhttp.HandlerOpts{})
+}
+
+// Получение количества заказов по статусам
+type OrdersCounter func(ctx context.Context) (map[models.OrderStatus]int64, error)
+
+// Количество заказов по статусам, запрашиваемое из хранилища при каждом сборе метрик
+type ordersCollector struct {
+	count OrdersCounter
+	desc  *prometheus.Desc
+}
+
+func NewOrdersCollector(count OrdersCounter) prometheus.Collector {
+	return &ordersCollector{
+		count: count,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "orders"),
+			"Number of orders by status.", []string{"status"}, nil),
+	}
+}
+
+func (c *ordersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *ordersCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), ordersCountTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count orders for metrics")
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	// Статусы без заказов передаются нулевыми значениями
+	for _, status := range []models.OrderStatus{
+		models.OrderStatusNew,
+		models.OrderStatusProcessing,
+		models.OrderStatusInvalid,
+		models.OrderStatusProcessed,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
+
+// Подсчет заказов, информацию о начислениях по которым не удается получить
+// не менее заданного количества раз подряд
+type FailingOrdersCounter func(ctx context.Context) (int64, error)
+
+// Количество проблемных заказов, запрашиваемое из хранилища при каждом сборе метрик
+type failingOrdersCollector struct {
+	count FailingOrdersCounter
+	desc  *prometheus.Desc
+}
+
+func NewFailingOrdersCollector(count FailingOrdersCounter) prometheus.Collector {
+	return &failingOrdersCollector{
+		count: count,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "orders_accrual_failing"),
+			"Number of not processed orders whose accrual info failed to be received at least the configured number of times in a row.",
+			nil, nil),
+	}
+}
+
+func (c *failingOrdersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *failingOrdersCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), ordersCountTimeout)
+	defer cancel()
+
+	count, err := c.count(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count failing orders for metrics")
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count))
+}
+
+// Состояние ограничения запросов к системе расчета начислений: приостановлены
+// ли запросы и время, до которого они приостановлены
+type ThrottleState func() (throttled bool, until time.Time)
+
+// Состояние ограничения запросов к системе расчета начислений на момент сбора метрик
+type throttleCollector struct {
+	state     ThrottleState
+	throttled *prometheus.Desc
+	until     *prometheus.Desc
+}
+
+func NewAccrualThrottleCollector(state ThrottleState) prometheus.Collector {
+	return &throttleCollector{
+		state: state,
+		throttled: prometheus.NewDesc(prometheus.BuildFQName(namespace, "accrual", "throttled"),
+			"Whether accrual system requests are paused after a 429 response (1) or not (0).", nil, nil),
+		until: prometheus.NewDesc(prometheus.BuildFQName(namespace, "accrual", "throttle_until_timestamp_seconds"),
+			"Unix time until which accrual system requests are paused (0 if they were never paused).", nil, nil),
+	}
+}
+
+func (c *throttleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.throttled
+	ch <- c.until
+}
+
+func (c *throttleCollector) Collect(ch chan<- prometheus.Metric) {
+	throttled, until := c.state()
+
+	var throttledValue, untilValue float64
+	if throttled {
+		throttledValue = 1
+	}
+	if !until.IsZero() {
+		untilValue = float64(until.UnixNano()) / 1e9
+	}
+	ch <- prometheus.MustNewConstMetric(c.throttled, prometheus.GaugeValue, throttledValue)
+	ch <- prometheus.MustNewConstMetric(c.until, prometheus.GaugeValue, untilValue)
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func TestOrdersCollector(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       OrdersCounter
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name: "Success Case",
+			count: func(ctx context.Context) (map[models.OrderStatus]int64, error) {
+				return map[models.OrderStatus]int64{
+					models.OrderStatusNew:       2,
+					models.OrderStatusProcessed: 5,
+				}, nil
+			},
+			expected: `
+# HELP gophermart_orders Number of orders by status.
+# TYPE gophermart_orders gauge
+gophermart_orders{status="INVALID"} 0
+gophermart_orders{status="NEW"} 2
+gophermart_orders{status="PROCESSED"} 5
+gophermart_orders{status="PROCESSING"} 0
+`,
+		},
+		{
+			name: "Storage Error Case",
+			count: func(ctx context.Context) (map[models.OrderStatus]int64, error) {
+				return nil, errors.New("storage error")
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(NewOrdersCollector(tt.count))
+
+			err := testutil.GatherAndCompare(reg, strings.NewReader(tt.expected), "gophermart_orders")
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFailingOrdersCollector(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       FailingOrdersCounter
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name: "Success Case",
+			count: func(ctx context.Context) (int64, error) {
+				return 2, nil
+			},
+			expected: `
+# HELP gophermart_orders_accrual_failing Number of not processed orders whose accrual info failed to be received at least the configured number of times in a row.
+# TYPE gophermart_orders_accrual_failing gauge
+gophermart_orders_accrual_failing 2
+`,
+		},
+		{
+			name: "Storage Error Case",
+			count: func(ctx context.Context) (int64, error) {
+				return 0, errors.New("storage error")
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(NewFailingOrdersCollector(tt.count))
+
+			err := testutil.GatherAndCompare(reg, strings.NewReader(tt.expected), "gophermart_orders_accrual_failing")
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAccrualThrottleCollector(t *testing.T) {
+	until := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		state    ThrottleState
+		expected string
+	}{
+		{
+			name:  "Throttled Case",
+			state: func() (bool, time.Time) { return true, until },
+			expected: `
+# HELP gophermart_accrual_throttle_until_timestamp_seconds Unix time until which accrual system requests are paused (0 if they were never paused).
+# TYPE gophermart_accrual_throttle_until_timestamp_seconds gauge
+gophermart_accrual_throttle_until_timestamp_seconds 1.7e+09
+# HELP gophermart_accrual_throttled Whether accrual system requests are paused after a 429 response (1) or not (0).
+# TYPE gophermart_accrual_throttled gauge
+gophermart_accrual_throttled 1
+`,
+		},
+		{
+			name:  "Never Throttled Case",
+			state: func() (bool, time.Time) { return false, time.Time{} },
+			expected: `
+# HELP gophermart_accrual_throttle_until_timestamp_seconds Unix time until which accrual system requests are paused (0 if they were never paused).
+# TYPE gophermart_accrual_throttle_until_timestamp_seconds gauge
+gophermart_accrual_throttle_until_timestamp_seconds 0
+# HELP gophermart_accrual_throttled Whether accrual system requests are paused after a 429 response (1) or not (0).
+# TYPE gophermart_accrual_throttled gauge
+gophermart_accrual_throttled 0
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(NewAccrualThrottleCollector(tt.state))
+
+			err := testutil.GatherAndCompare(reg, strings.NewReader(tt.expected),
+				"gophermart_accrual_throttled", "gophermart_accrual_throttle_until_timestamp_seconds")
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -4,7 +4,7 @@ type (
 	AccrualResponse struct {
 		OrderNumber   string        `json:"order"`
 		AccrualStatus AccrualStatus `json:"status"`
-		Accrual       Money         `json:"accrual"`
+		Accrual       Money         `json:"accrual" swaggertype:"number"`
 	}
 
 	AccrualStatus string
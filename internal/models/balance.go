@@ -5,14 +5,12 @@ import (
 	"time"
 )
 
-type Money float64
-
 type (
 	Balance struct {
 		ID        int64 `json:"-"`
 		UserID    int64 `json:"-"`
-		Withdrawn Money `json:"withdrawn"`
-		Current   Money `json:"current"`
+		Withdrawn Money `json:"withdrawn" swaggertype:"number"`
+		Current   Money `json:"current" swaggertype:"number"`
 	}
 
 	Withdrawal struct {
@@ -20,12 +18,12 @@ type (
 		UserID      int64     `json:"-"`
 		Order       string    `json:"order"`
 		ProcessedAt time.Time `json:"processed_at"`
-		Sum         Money     `json:"sum"`
+		Sum         Money     `json:"sum" swaggertype:"number"`
 	}
 
 	WithdrawalRequest struct {
 		Order string `json:"order"`
-		Sum   Money  `json:"sum"`
+		Sum   Money  `json:"sum" swaggertype:"number"`
 	}
 )
 
@@ -34,7 +32,7 @@ func (b *Balance) String() string {
 		return "balance is nil pointer"
 	}
 
-	return fmt.Sprintf("UserID: %d, Withdrawn: %f, Current: %f", b.UserID, b.Withdrawn, b.Current)
+	return fmt.Sprintf("UserID: %d, Withdrawn: %s, Current: %s", b.UserID, b.Withdrawn, b.Current)
 }
 
 func (w *Withdrawal) String() string {
@@ -42,5 +40,5 @@ func (w *Withdrawal) String() string {
 		return "balance is nil pointer"
 	}
 
-	return fmt.Sprintf("UserID: %d, Order: %s, Sum: %f", w.UserID, w.Order, w.Sum)
+	return fmt.Sprintf("UserID: %d, Order: %s, Sum: %s", w.UserID, w.Order, w.Sum)
 }
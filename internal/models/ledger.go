@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Проводка в журнале движения баллов. Каждая проводка переносит положительную сумму
+// со счета дебета на счет кредита; одним из счетов всегда является счет пользователя.
+type (
+	LedgerEntry struct {
+		ID            int64           `json:"-"`
+		UserID        int64           `json:"-"`
+		Kind          LedgerEntryKind `json:"kind"`
+		DebitAccount  LedgerAccount   `json:"debit_account"`
+		CreditAccount LedgerAccount   `json:"credit_account"`
+		Amount        Money           `json:"amount" swaggertype:"number"`
+		OrderNumber   string          `json:"order,omitempty"`
+		CreatedAt     time.Time       `json:"created_at"`
+	}
+
+	LedgerEntryKind string
+	LedgerAccount   string
+
+	// Расхождение между сохраненным балансом пользователя и суммой проводок по его счету
+	BalanceDiscrepancy struct {
+		UserID  int64
+		Balance Balance
+		Ledger  Balance
+	}
+)
+
+const (
+	LedgerEntryAccrual    LedgerEntryKind = "ACCRUAL"
+	LedgerEntryWithdrawal LedgerEntryKind = "WITHDRAWAL"
+	LedgerEntryAdjustment LedgerEntryKind = "ADJUSTMENT" // корректировки расхождений при переносе истории в журнал
+)
+
+const (
+	LedgerAccountUser        LedgerAccount = "user"        // счет баллов пользователя
+	LedgerAccountAccruals    LedgerAccount = "accruals"    // источник начислений за заказы
+	LedgerAccountWithdrawals LedgerAccount = "withdrawals" // списания в счет оплаты заказов
+	LedgerAccountAdjustments LedgerAccount = "adjustments" // корректировки при переносе истории
+)
+
+// Проводка начисления баллов за заказ
+func NewAccrualEntry(userID int64, orderNumber string, amount Money) LedgerEntry {
+	return LedgerEntry{
+		UserID:        userID,
+		Kind:          LedgerEntryAccrual,
+		DebitAccount:  LedgerAccountAccruals,
+		CreditAccount: LedgerAccountUser,
+		Amount:        amount,
+		OrderNumber:   orderNumber,
+	}
+}
+
+// Проводка списания баллов в счет оплаты заказа
+func NewWithdrawalEntry(userID int64, orderNumber string, amount Money) LedgerEntry {
+	return LedgerEntry{
+		UserID:        userID,
+		Kind:          LedgerEntryWithdrawal,
+		DebitAccount:  LedgerAccountUser,
+		CreditAccount: LedgerAccountWithdrawals,
+		Amount:        amount,
+		OrderNumber:   orderNumber,
+	}
+}
+
+// Изменение баланса пользователя, вызванное проводкой
+func (e *LedgerEntry) BalanceChange() Money {
+	if e.CreditAccount == LedgerAccountUser {
+		return e.Amount
+	}
+	return -e.Amount
+}
+
+// Изменение суммы списанных баллов, вызванное проводкой
+func (e *LedgerEntry) WithdrawnChange() Money {
+	switch LedgerAccountWithdrawals {
+	case e.CreditAccount:
+		return e.Amount
+	case e.DebitAccount:
+		return -e.Amount
+	default:
+		return 0
+	}
+}
+
+func (e *LedgerEntry) String() string {
+	if e == nil {
+		return "ledger entry is nil pointer"
+	}
+
+	return fmt.Sprintf("UserID: %d, Kind: %s, Debit: %s, Credit: %s, Amount: %s",
+		e.UserID, e.Kind, e.DebitAccount, e.CreditAccount, e.Amount)
+}
+
+func (d *BalanceDiscrepancy) String() string {
+	if d == nil {
+		return "balance discrepancy is nil pointer"
+	}
+
+	return fmt.Sprintf("UserID: %d, Balance: (%s), Ledger: (%s)", d.UserID, &d.Balance, &d.Ledger)
+}
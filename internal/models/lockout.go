@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// Запись журнала блокировок входа
+	LoginLockout struct {
+		ID          int64
+		Reason      LoginLockoutReason // какие попытки вызвали блокировку
+		SubjectType LoginLockoutSubject
+		Subject     string // логин или IP адрес клиента
+		Failures    int    // неудачных попыток, вызвавших блокировку
+		LockedUntil time.Time
+		CreatedAt   time.Time
+	}
+
+	LoginLockoutSubject string
+	LoginLockoutReason  string
+)
+
+const (
+	LoginLockoutSubjectLogin LoginLockoutSubject = "login"
+	LoginLockoutSubjectIP    LoginLockoutSubject = "ip"
+
+	LoginLockoutReasonLogin         LoginLockoutReason = "login"          // неудачные попытки входа
+	LoginLockoutReasonPasswordReset LoginLockoutReason = "password_reset" // запросы на сброс пароля
+)
+
+func (l *LoginLockout) String() string {
+	if l == nil {
+		return "login lockout is nil pointer"
+	}
+
+	return fmt.Sprintf("Reason: %s, %s: %s, Failures: %d, LockedUntil: %s",
+		l.Reason, l.SubjectType, l.Subject, l.Failures, l.LockedUntil)
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Денежная сумма с фиксированной точностью, хранящаяся в копейках.
+// В JSON и в БД представляется десятичным числом с двумя знаками после запятой.
+type Money int64
+
+// Количество копеек в рубле
+const moneyScale = 100
+
+var ErrInvalidMoney = errors.New("invalid money value")
+
+// Создание суммы из рублей и копеек
+func NewMoney(units, cents int64) Money {
+	return Money(units*moneyScale + cents)
+}
+
+// Десятичная запись суммы: необязательный минус, целая часть и не более двух знаков после точки
+var moneyPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,2})?$`)
+
+// Разбор десятичной записи суммы. Дроби, экспоненциальная запись и значения
+// точнее копейки не принимаются.
+func ParseMoney(s string) (Money, error) {
+	if !moneyPattern.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	units, fraction, _ := strings.Cut(s, ".")
+	cents, err := strconv.ParseInt(units+fraction+strings.Repeat("0", 2-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+	}
+	return Money(cents), nil
+}
+
+func (m Money) Add(other Money) Money {
+	return m + other
+}
+
+func (m Money) Sub(other Money) Money {
+	return m - other
+}
+
+func (m Money) IsNegative() bool {
+	return m < 0
+}
+
+// Количество копеек
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Десятичная запись суммы без незначащих нулей: 200, 729.98, 0.5
+func (m Money) String() string {
+	sign := ""
+	v := new(big.Int).SetInt64(int64(m))
+	if v.Sign() < 0 {
+		sign = "-"
+		v.Neg(v)
+	}
+
+	units, cents := new(big.Int).QuoRem(v, big.NewInt(moneyScale), new(big.Int))
+	switch c := cents.Int64(); {
+	case c == 0:
+		return sign + units.String()
+	case c%10 == 0:
+		return fmt.Sprintf("%s%s.%d", sign, units, c/10)
+	default:
+		return fmt.Sprintf("%s%s.%02d", sign, units, c)
+	}
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	// Допускается запись суммы строкой
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Значение для записи в БД (столбцы типа numeric)
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Чтение значения из БД
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case int64:
+		*m = NewMoney(v, 0)
+		return nil
+	case float64:
+		return m.scanString(strconv.FormatFloat(v, 'f', 2, 64))
+	case string:
+		return m.scanString(v)
+	case []byte:
+		return m.scanString(string(v))
+	default:
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidMoney, src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_ParseMoney(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedMoney Money
+		expectedErr   error
+	}{
+		{name: "Integer Case", value: "200", expectedMoney: NewMoney(200, 0)},
+		{name: "Fraction Case", value: "729.98", expectedMoney: NewMoney(729, 98)},
+		{name: "One decimal Case", value: "751.1", expectedMoney: NewMoney(751, 10)},
+		{name: "Negative Case", value: "-0.05", expectedMoney: Money(-5)},
+		{name: "Invalid Case", value: "ten", expectedErr: ErrInvalidMoney},
+		{name: "Rational Case", value: "1/3", expectedErr: ErrInvalidMoney},
+		{name: "Exponent Case", value: "1.5e2", expectedErr: ErrInvalidMoney},
+		{name: "Sub-kopeck Case", value: "0.005", expectedErr: ErrInvalidMoney},
+		{name: "Trailing point Case", value: "10.", expectedErr: ErrInvalidMoney},
+		{name: "Missing units Case", value: ".5", expectedErr: ErrInvalidMoney},
+		{name: "Plus sign Case", value: "+1", expectedErr: ErrInvalidMoney},
+		{name: "Empty Case", value: "", expectedErr: ErrInvalidMoney},
+		{name: "Out of range Case", value: "100000000000000000000", expectedErr: ErrInvalidMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := ParseMoney(tt.value)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMoney, money)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "200", NewMoney(200, 0).String())
+	assert.Equal(t, "729.98", NewMoney(729, 98).String())
+	assert.Equal(t, "0.5", NewMoney(0, 50).String())
+	assert.Equal(t, "0.05", Money(5).String())
+	assert.Equal(t, "-12.3", NewMoney(-12, -30).String())
+	assert.Equal(t, "0", Money(0).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	withdrawalReq := WithdrawalRequest{}
+	err := json.Unmarshal([]byte(`{"order":"2377225624","sum":751.1}`), &withdrawalReq)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(751, 10), withdrawalReq.Sum)
+
+	// Суммы точнее копейки и в экспоненциальной записи отклоняются, а не округляются
+	err = json.Unmarshal([]byte(`{"order":"2377225624","sum":0.001}`), &withdrawalReq)
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+	err = json.Unmarshal([]byte(`{"order":"2377225624","sum":"1e2"}`), &withdrawalReq)
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+
+	data, err := json.Marshal(Balance{Current: NewMoney(500, 50), Withdrawn: NewMoney(42, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"withdrawn":42,"current":500.5}`, string(data))
+
+	// Многократное сложение не накапливает погрешность
+	var sum Money
+	for range 1000 {
+		sum = sum.Add(NewMoney(0, 10))
+	}
+	assert.Equal(t, NewMoney(100, 0), sum)
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name          string
+		src           any
+		expectedMoney Money
+	}{
+		{name: "String Case", src: "123.45", expectedMoney: NewMoney(123, 45)},
+		{name: "Bytes Case", src: []byte("0.10"), expectedMoney: NewMoney(0, 10)},
+		{name: "Float Case", src: float64(0.1), expectedMoney: NewMoney(0, 10)},
+		{name: "Int Case", src: int64(7), expectedMoney: NewMoney(7, 0)},
+		{name: "Nil Case", src: nil, expectedMoney: Money(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money := Money(100)
+			require.NoError(t, money.Scan(tt.src))
+			assert.Equal(t, tt.expectedMoney, money)
+		})
+	}
+}
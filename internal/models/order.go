@@ -11,11 +11,34 @@ type (
 		UserID     int64       `json:"-"`
 		Number     string      `json:"number"`
 		Status     OrderStatus `json:"status"`
-		Accrual    Money       `json:"accrual,omitempty"`
+		Accrual    Money       `json:"accrual,omitempty" swaggertype:"number"`
 		UploadedAt time.Time   `json:"uploaded_at"`
+
+		AccrualStatus    AccrualStatus `json:"-"` // статус, полученный от системы расчета начислений
+		AccrualFailures  int           `json:"-"` // количество неудачных запросов информации о начислении подряд
+		LastAccrualError string        `json:"-"` // текст последней ошибки запроса информации о начислении
 	}
 
 	OrderStatus string
+
+	// Смена статуса заказа
+	OrderStatusChange struct {
+		Status        OrderStatus   `json:"status"`
+		AccrualStatus AccrualStatus `json:"accrual_status,omitempty"` // пусто для статуса NEW при загрузке
+		ChangedAt     time.Time     `json:"changed_at"`
+	}
+
+	// Заказ с историей смены статусов в порядке их изменения
+	OrderDetails struct {
+		Order
+		History []OrderStatusChange `json:"history"`
+	}
+
+	// Неудачный запрос информации о начислении по заказу
+	OrderAccrualFailure struct {
+		Number string
+		Error  string
+	}
 )
 
 const (
@@ -49,5 +72,5 @@ func (o *Order) String() string {
 		return "order is nil pointer"
 	}
 
-	return fmt.Sprintf("Number: %s, Status: %s, Accrual: %f", o.Number, o.Status, o.Accrual)
+	return fmt.Sprintf("Number: %s, Status: %s, Accrual: %s", o.Number, o.Status, o.Accrual)
 }
@@ -0,0 +1,21 @@
+package models
+
+// Максимальное количество номеров заказов в одной пакетной загрузке
+const MaxOrderBatchSize = 1000
+
+type (
+	// Результат загрузки номера заказа в пакете
+	OrderUploadStatus string
+
+	OrderUploadResult struct {
+		Number string            `json:"number"`
+		Status OrderUploadStatus `json:"status"`
+	}
+)
+
+const (
+	OrderUploadAccepted        OrderUploadStatus = "accepted"         // новый номер принят в обработку
+	OrderUploadAlreadyUploaded OrderUploadStatus = "already_uploaded" // номер уже загружен этим пользователем
+	OrderUploadConflict        OrderUploadStatus = "conflict"         // номер уже загружен другим пользователем
+	OrderUploadInvalid         OrderUploadStatus = "invalid"          // неверный формат номера
+)
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+type (
+	// Переход заказа из одного статуса в другой
+	OrderStatusTransition struct {
+		OrderID       int64
+		Number        string
+		From          OrderStatus
+		To            OrderStatus
+		AccrualStatus AccrualStatus // статус, полученный от системы расчета начислений
+		StartedAt     time.Time     // время установки статуса From
+		ChangedAt     time.Time     // время установки статуса To
+		UploadedAt    time.Time
+	}
+
+	// Задержки обработки одного заказа
+	OrderLatency struct {
+		Number      string
+		Transitions []OrderStatusTransition
+		Completed   bool          // заказ в конечном статусе
+		Total       time.Duration // от загрузки до конечного статуса, для незавершенных — до последней смены статуса
+	}
+
+	// Распределение задержек
+	LatencyStats struct {
+		Count int
+		P50   time.Duration
+		P90   time.Duration
+		P99   time.Duration
+		Max   time.Duration
+	}
+
+	// Распределение задержек перехода между парой статусов
+	TransitionLatencyStats struct {
+		From OrderStatus
+		To   OrderStatus
+		LatencyStats
+	}
+
+	// Задержки обработки заказов за период
+	OrderLatencyStats struct {
+		Transitions []TransitionLatencyStats // по парам статусов в порядке From, To
+		Completion  LatencyStats             // от загрузки до конечного статуса
+	}
+)
+
+// Время, проведенное заказом в статусе From
+func (t OrderStatusTransition) Duration() time.Duration {
+	return t.ChangedAt.Sub(t.StartedAt)
+}
+
+// Переходы между последовательными записями истории статусов заказа
+func NewOrderStatusTransitions(order Order, history []OrderStatusChange) []OrderStatusTransition {
+	transitions := make([]OrderStatusTransition, 0, len(history))
+	for i := 1; i < len(history); i++ {
+		transitions = append(transitions, OrderStatusTransition{
+			OrderID:       order.ID,
+			Number:        order.Number,
+			From:          history[i-1].Status,
+			To:            history[i].Status,
+			AccrualStatus: history[i].AccrualStatus,
+			StartedAt:     history[i-1].ChangedAt,
+			ChangedAt:     history[i].ChangedAt,
+			UploadedAt:    order.UploadedAt,
+		})
+	}
+	return transitions
+}
+
+// Конечный статус заказа, после которого информация о начислении не запрашивается
+func (s OrderStatus) Final() bool {
+	return s == OrderStatusProcessed || s == OrderStatusInvalid
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Размер страницы списка заказов
+const (
+	DefaultOrdersPageLimit = 50
+	MaxOrdersPageLimit     = 1000
+)
+
+type (
+	// Параметры выборки страницы заказов пользователя. Заказы упорядочиваются
+	// по времени загрузки, при совпадении времени — по идентификатору.
+	OrdersQuery struct {
+		Statuses     []OrderStatus // пустой список — заказы в любом статусе
+		UploadedFrom *time.Time    // включительно
+		UploadedTo   *time.Time    // не включительно
+		Descending   bool
+		Limit        int
+		After        *OrderCursor // позиция, после которой начинается страница
+	}
+
+	// Позиция заказа в выборке
+	OrderCursor struct {
+		UploadedAt time.Time `json:"t"`
+		ID         int64     `json:"id"`
+		Descending bool      `json:"desc,omitempty"`
+	}
+
+	// Страница списка заказов
+	OrdersPage struct {
+		Orders     []Order `json:"orders"`
+		NextCursor string  `json:"next_cursor,omitempty"` // пусто на последней странице
+	}
+)
+
+// Позиция заказа для выборки в заданном порядке
+func NewOrderCursor(order Order, descending bool) *OrderCursor {
+	return &OrderCursor{
+		UploadedAt: order.UploadedAt,
+		ID:         order.ID,
+		Descending: descending,
+	}
+}
+
+// Непрозрачное представление позиции для передачи клиенту
+func (c *OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func ParseOrderCursor(s string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("models.parseOrderCursor: %w", appErrors.ErrInvalidOrdersCursor)
+	}
+
+	cursor := &OrderCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("models.parseOrderCursor: %w", appErrors.ErrInvalidOrdersCursor)
+	}
+	return cursor, nil
+}
+
+// Известный статус заказа
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case OrderStatusNew, OrderStatusProcessing, OrderStatusInvalid, OrderStatusProcessed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Событие жизненного цикла заказов и баланса пользователя. События записываются
+// в outbox в той же транзакции, что и изменение, и доставляются внешним системам
+// не менее одного раза в порядке их записи для каждого пользователя.
+type (
+	OutboxEvent struct {
+		ID        int64           `json:"id"`
+		UserID    int64           `json:"user_id"`
+		Type      OutboxEventType `json:"type"`
+		Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+		CreatedAt time.Time       `json:"created_at"`
+		Attempts  int             `json:"-"` // количество неудачных попыток доставки
+
+		// Время следующей попытки доставки или окончания аренды выбранного для доставки события
+		NextAttemptAt time.Time `json:"-"`
+	}
+
+	OutboxEventType string
+
+	// Данные событий заказа
+	OrderEventPayload struct {
+		Number  string      `json:"number"`
+		Status  OrderStatus `json:"status"`
+		Accrual Money       `json:"accrual,omitempty" swaggertype:"number"`
+	}
+
+	// Данные события списания
+	WithdrawalEventPayload struct {
+		Order string `json:"order"`
+		Sum   Money  `json:"sum" swaggertype:"number"`
+	}
+
+	// Результат доставки события. Событие, попытка доставки которого не выполнялась,
+	// возвращается в статусе pending без ошибки, и счетчик попыток не увеличивается.
+	OutboxDelivery struct {
+		EventID       int64
+		Status        OutboxEventStatus
+		Error         string    // ошибка попытки; пустая, если попытка удалась или не выполнялась
+		NextAttemptAt time.Time // время следующей попытки для статуса pending
+	}
+
+	OutboxEventStatus string
+)
+
+const (
+	OutboxEventPending   OutboxEventStatus = "pending"   // ожидает доставки
+	OutboxEventDelivered OutboxEventStatus = "delivered" // доставлено
+	OutboxEventDead      OutboxEventStatus = "dead"      // попытки исчерпаны, доставка прекращена
+)
+
+const (
+	OutboxEventOrderRegistered    OutboxEventType = "order.registered"
+	OutboxEventOrderStatusChanged OutboxEventType = "order.status_changed" // смена статуса, кроме перехода в PROCESSED
+	OutboxEventOrderCredited      OutboxEventType = "order.credited"
+	OutboxEventBalanceWithdrawn   OutboxEventType = "balance.withdrawn"
+)
+
+// Событие загрузки нового заказа
+func NewOrderRegisteredEvent(userID int64, orderNumber string) OutboxEvent {
+	return newOutboxEvent(userID, OutboxEventOrderRegistered, OrderEventPayload{
+		Number: orderNumber,
+		Status: OrderStatusNew,
+	})
+}
+
+// Событие смены статуса заказа. Переход в PROCESSED означает завершение
+// расчета начисления и публикуется как order.credited.
+func NewOrderStatusEvent(order Order) OutboxEvent {
+	eventType := OutboxEventOrderStatusChanged
+	if order.Status == OrderStatusProcessed {
+		eventType = OutboxEventOrderCredited
+	}
+	return newOutboxEvent(order.UserID, eventType, OrderEventPayload{
+		Number:  order.Number,
+		Status:  order.Status,
+		Accrual: order.Accrual,
+	})
+}
+
+// Событие списания баллов в счет оплаты заказа
+func NewWithdrawalEvent(userID int64, orderNumber string, sum Money) OutboxEvent {
+	return newOutboxEvent(userID, OutboxEventBalanceWithdrawn, WithdrawalEventPayload{
+		Order: orderNumber,
+		Sum:   sum,
+	})
+}
+
+func newOutboxEvent(userID int64, eventType OutboxEventType, payload any) OutboxEvent {
+	data, _ := json.Marshal(payload)
+	return OutboxEvent{
+		UserID:  userID,
+		Type:    eventType,
+		Payload: data,
+	}
+}
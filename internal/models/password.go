@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// Запрос на смену пароля
+	PasswordChangeRequest struct {
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+
+	// Запрос на сброс пароля
+	PasswordResetRequest struct {
+		Login string `json:"login"`
+	}
+
+	// Подтверждение сброса пароля
+	PasswordResetConfirmRequest struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	// Сохраненный токен сброса пароля. Токен одноразовый и действует ограниченное время.
+	PasswordResetToken struct {
+		ID        int64
+		UserID    int64
+		TokenHash string
+		ExpiresAt time.Time
+		CreatedAt time.Time
+		UsedAt    *time.Time // время использования
+	}
+)
+
+func (t *PasswordResetToken) String() string {
+	if t == nil {
+		return "password reset token is nil pointer"
+	}
+
+	return fmt.Sprintf("UserID: %d, ExpiresAt: %s", t.UserID, t.ExpiresAt)
+}
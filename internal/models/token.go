@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// Пара токенов, выдаваемая при аутентификации и обновлении
+	TokenPair struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"` // время жизни access токена в секундах
+	}
+
+	// Запрос на обновление токенов или выход
+	RefreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Сохраненный refresh токен. Все токены, полученные последовательной ротацией
+	// одного выданного при входе токена, образуют семейство.
+	RefreshToken struct {
+		ID        int64
+		UserID    int64
+		FamilyID  string
+		TokenHash string
+		ExpiresAt time.Time
+		CreatedAt time.Time
+		UsedAt    *time.Time // время ротации
+		RevokedAt *time.Time // время отзыва
+	}
+)
+
+func (t *RefreshToken) String() string {
+	if t == nil {
+		return "refresh token is nil pointer"
+	}
+
+	return fmt.Sprintf("UserID: %d, FamilyID: %s, ExpiresAt: %s", t.UserID, t.FamilyID, t.ExpiresAt)
+}
@@ -5,9 +5,10 @@ import (
 )
 
 type User struct {
-	ID       int64  `json:"-"`
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	ID           int64  `json:"-"`
+	Login        string `json:"login"`
+	Password     string `json:"password"`
+	TokenVersion int64  `json:"-"` // увеличивается при смене пароля, отзывая выданные ранее токены
 }
 
 func (u *User) String() string {
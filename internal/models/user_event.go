@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type (
+	// Событие потока обновлений пользователя. Идентификаторы событий возрастают
+	// в пределах экземпляра сервиса и используются для возобновления потока.
+	UserEvent struct {
+		ID        int64
+		UserID    int64
+		Type      UserEventType
+		Data      json.RawMessage
+		CreatedAt time.Time
+	}
+
+	UserEventType string
+)
+
+const (
+	UserEventOrder   UserEventType = "order"   // смена статуса заказа, данные OrderEventPayload
+	UserEventBalance UserEventType = "balance" // изменение баланса, данные Balance
+	UserEventReset   UserEventType = "reset"   // пропущенные события недоступны, состояние нужно запросить заново
+)
+
+// Событие смены статуса заказа
+func NewOrderUserEvent(order Order) UserEvent {
+	return newUserEvent(order.UserID, UserEventOrder, OrderEventPayload{
+		Number:  order.Number,
+		Status:  order.Status,
+		Accrual: order.Accrual,
+	})
+}
+
+// Событие изменения баланса пользователя
+func NewBalanceUserEvent(userID int64, balance Balance) UserEvent {
+	return newUserEvent(userID, UserEventBalance, balance)
+}
+
+func newUserEvent(userID int64, eventType UserEventType, data any) UserEvent {
+	raw, _ := json.Marshal(data)
+	return UserEvent{
+		UserID: userID,
+		Type:   eventType,
+		Data:   raw,
+	}
+}
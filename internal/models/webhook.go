@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	MaxUserWebhooks              = 10   // максимальное количество вебхуков пользователя
+	DefaultWebhookDeliveryLimit  = 50   // размер журнала доставок по умолчанию
+	MaxWebhookDeliveryLimit      = 1000 // максимальный размер журнала доставок
+	WebhookDeliveryErrorMaxBytes = 512  // максимальная длина сохраняемой ошибки доставки
+)
+
+type (
+	// Адрес пользователя для уведомлений о заказах. Секрет подписи
+	// возвращается только при регистрации вебхука.
+	Webhook struct {
+		ID        int64     `json:"id"`
+		UserID    int64     `json:"-"`
+		URL       string    `json:"url"`
+		Secret    string    `json:"secret,omitempty"` // только в ответе на регистрацию
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Запрос на регистрацию вебхука
+	WebhookRequest struct {
+		URL string `json:"url"` // абсолютный http(s) адрес, разрешающийся в публичные IP
+	}
+
+	// Доставка события на вебхук
+	WebhookDelivery struct {
+		ID            int64                 `json:"id"`
+		WebhookID     int64                 `json:"webhook_id"`
+		EventID       int64                 `json:"event_id"`
+		EventType     OutboxEventType       `json:"event_type"`
+		Payload       json.RawMessage       `json:"payload" swaggertype:"object"`
+		Status        WebhookDeliveryStatus `json:"status"` // pending, delivered или dead
+		Attempts      int                   `json:"attempts"`
+		ResponseCode  int                   `json:"response_code,omitempty"` // код ответа последней попытки
+		LastError     string                `json:"last_error,omitempty"`
+		NextAttemptAt time.Time             `json:"next_attempt_at"`
+		CreatedAt     time.Time             `json:"created_at"`
+		DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+
+		// Адрес и секрет вебхука для отправки
+		URL    string `json:"-"`
+		Secret string `json:"-"`
+	}
+
+	WebhookDeliveryStatus string
+
+	// Результат попытки доставки на вебхук
+	WebhookDeliveryResult struct {
+		DeliveryID    int64
+		Status        WebhookDeliveryStatus
+		ResponseCode  int
+		Error         string
+		NextAttemptAt time.Time // время следующей попытки для статуса pending
+	}
+)
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // ожидает очередной попытки
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered" // получен ответ 2xx
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"      // попытки исчерпаны, доставка прекращена
+)
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Размер страницы истории списаний
+const (
+	DefaultWithdrawalsPageLimit = 50
+	MaxWithdrawalsPageLimit     = 1000
+)
+
+type (
+	// Параметры выборки страницы списаний пользователя. Списания упорядочиваются
+	// по времени обработки, при совпадении времени — по идентификатору.
+	WithdrawalsQuery struct {
+		ProcessedFrom *time.Time // включительно
+		ProcessedTo   *time.Time // не включительно
+		MinSum        *Money     // включительно
+		MaxSum        *Money     // включительно
+		Descending    bool
+		Limit         int
+		After         *WithdrawalCursor // позиция, после которой начинается страница
+	}
+
+	// Позиция списания в выборке
+	WithdrawalCursor struct {
+		ProcessedAt time.Time `json:"t"`
+		ID          int64     `json:"id"`
+		Descending  bool      `json:"desc,omitempty"`
+	}
+
+	// Итоги по всем списаниям, подходящим под фильтры выборки, без учета позиции
+	WithdrawalsSummary struct {
+		Total Money `json:"total" swaggertype:"number"`
+		Count int64 `json:"count"`
+	}
+
+	// Страница истории списаний
+	WithdrawalsPage struct {
+		Withdrawals []Withdrawal       `json:"withdrawals"`
+		Summary     WithdrawalsSummary `json:"summary"`
+		NextCursor  string             `json:"next_cursor,omitempty"` // пусто на последней странице
+	}
+)
+
+// Позиция списания для выборки в заданном порядке
+func NewWithdrawalCursor(withdrawal Withdrawal, descending bool) *WithdrawalCursor {
+	return &WithdrawalCursor{
+		ProcessedAt: withdrawal.ProcessedAt,
+		ID:          withdrawal.ID,
+		Descending:  descending,
+	}
+}
+
+// Непрозрачное представление позиции для передачи клиенту
+func (c *WithdrawalCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func ParseWithdrawalCursor(s string) (*WithdrawalCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("models.parseWithdrawalCursor: %w", appErrors.ErrInvalidWithdrawalsCursor)
+	}
+
+	cursor := &WithdrawalCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("models.parseWithdrawalCursor: %w", appErrors.ErrInvalidWithdrawalsCursor)
+	}
+	return cursor, nil
+}
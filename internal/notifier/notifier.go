@@ -0,0 +1,100 @@
+// Package notifier доставляет пользователям служебные уведомления,
+// например токены сброса пароля. Способ доставки определяется реализацией Notifier.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Замена секретов в уведомлениях, записываемых в журнал
+const redactedSecret = "[REDACTED]"
+
+type (
+	// Уведомление пользователя
+	Notification struct {
+		Recipient string `json:"recipient"` // логин пользователя
+		Subject   string `json:"subject"`
+		Body      string `json:"body"`
+		// Значения из текста уведомления, например токены, которые не должны попадать в журнал
+		Secrets []string `json:"-"`
+	}
+
+	// Способ доставки уведомлений
+	Notifier interface {
+		Notify(ctx context.Context, n Notification) error
+	}
+
+	// Запись уведомлений в журнал сервиса. Секреты в тексте уведомления
+	// заменяются на redactedSecret, если их запись не включена явно
+	// для локальной разработки.
+	LogNotifier struct {
+		revealSecrets bool
+	}
+
+	// Добавление уведомлений в файл, по одному JSON объекту в строке
+	FileNotifier struct {
+		mu   sync.Mutex
+		path string
+	}
+
+	fileRecord struct {
+		Notification
+		SentAt time.Time `json:"sent_at"`
+	}
+)
+
+func NewLogNotifier(revealSecrets bool) *LogNotifier {
+	return &LogNotifier{revealSecrets: revealSecrets}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	body := notification.Body
+	if !n.revealSecrets {
+		for _, secret := range notification.Secrets {
+			if secret != "" {
+				body = strings.ReplaceAll(body, secret, redactedSecret)
+			}
+		}
+	}
+
+	log.Info().
+		Str("recipient", notification.Recipient).
+		Str("subject", notification.Subject).
+		Str("body", body).
+		Msg("notification")
+	return nil
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, notification Notification) error {
+	data, err := json.Marshal(fileRecord{Notification: notification, SentAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("notifier.file.notify: %w", err)
+	}
+	data = append(data, '\n')
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("notifier.file.notify: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("notifier.file.notify: %w", err)
+	}
+	return nil
+}
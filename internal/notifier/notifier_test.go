@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFileNotifier(path)
+
+	notifications := []Notification{
+		{Recipient: "first", Subject: "subject", Body: "body 1"},
+		{Recipient: "second", Subject: "subject", Body: "body 2"},
+	}
+	for _, notification := range notifications {
+		require.NoError(t, n.Notify(context.Background(), notification))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Уведомления дописываются в файл по одному в строке
+	records := []fileRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := fileRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.False(t, record.SentAt.IsZero())
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, records, len(notifications))
+	for i, record := range records {
+		assert.Equal(t, notifications[i], record.Notification)
+	}
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	notification := Notification{
+		Recipient: "user",
+		Subject:   "Password reset",
+		Body:      "Use token secret-token to reset your password.",
+		Secrets:   []string{"secret-token"},
+	}
+
+	tests := []struct {
+		name          string
+		revealSecrets bool
+		expectedBody  string
+	}{
+		{
+			name:         "Redacted Case",
+			expectedBody: "Use token [REDACTED] to reset your password.",
+		},
+		{
+			name:          "Reveal Secrets Case",
+			revealSecrets: true,
+			expectedBody:  "Use token secret-token to reset your password.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			prevLogger := log.Logger
+			log.Logger = zerolog.New(&buf)
+			t.Cleanup(func() { log.Logger = prevLogger })
+
+			require.NoError(t, NewLogNotifier(tt.revealSecrets).Notify(context.Background(), notification))
+
+			record := map[string]string{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, tt.expectedBody, record["body"])
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package outbox доставляет во внешние системы события, записанные в outbox
+// хранилища в одной транзакции с изменением. Доставка выполняется не менее
+// одного раза, события каждого пользователя доставляются в порядке их записи.
+// Неудачная доставка повторяется по экспоненциальной задержке; событие, не доставленное
+// за MaxAttempts попыток, переводится в dead и больше не отправляется.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Ограничение показателя степени, чтобы задержка не переполнялась
+const maxBackoffShift = 30
+
+// Время на сохранение результатов доставки после отмены контекста
+const completeTimeout = 5 * time.Second
+
+type (
+	Config struct {
+		MaxAttempts int           // попыток до прекращения доставки события
+		Backoff     time.Duration // задержка перед второй попыткой, удваивается с каждой следующей
+		MaxBackoff  time.Duration // максимальная задержка между попытками
+		Lease       time.Duration // время, на которое выбранные события закрепляются за экземпляром сервиса
+		BatchSize   int           // максимальное количество событий за один запуск
+	}
+
+	// Хранилище событий
+	Store interface {
+		ClaimOutboxEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error)
+		CompleteOutboxEvents(ctx context.Context, deliveries []models.OutboxDelivery) error
+	}
+
+	Relay struct {
+		store     Store
+		publisher Publisher
+		conf      Config
+		now       func() time.Time
+	}
+)
+
+func NewRelay(store Store, publisher Publisher, conf Config) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		conf:      conf,
+		now:       time.Now,
+	}
+}
+
+// Доставка очередной пачки недоставленных событий. События выбираются и результаты
+// сохраняются в отдельных коротких транзакциях, публикация выполняется между ними
+// и ограничена половиной аренды, чтобы результаты были сохранены до ее окончания.
+func (r *Relay) DeliverPending(ctx context.Context) error {
+	now := r.now()
+	events, err := r.store.ClaimOutboxEvents(ctx, now, now.Add(r.conf.Lease), r.conf.BatchSize)
+	if err != nil {
+		return fmt.Errorf("outbox.deliverPending.claim: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	publishCtx, cancelPublish := context.WithTimeout(ctx, r.conf.Lease/2)
+	deliveries := r.deliver(publishCtx, events)
+	cancelPublish()
+
+	// Результаты сохраняются и после отмены контекста, чтобы не публиковать события повторно
+	completeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), completeTimeout)
+	defer cancel()
+
+	if err := r.store.CompleteOutboxEvents(completeCtx, deliveries); err != nil {
+		return fmt.Errorf("outbox.deliverPending.complete: %w", err)
+	}
+	return nil
+}
+
+// События публикуются по одному в порядке записи. После неудачной доставки
+// остальные события того же пользователя откладываются, чтобы они не обогнали
+// недоставленное. Событие, не доставленное за MaxAttempts попыток, больше
+// не отправляется и не задерживает следующие события пользователя.
+// Событиям без попытки доставки аренда снимается.
+func (r *Relay) deliver(ctx context.Context, events []models.OutboxEvent) []models.OutboxDelivery {
+	deliveries := make([]models.OutboxDelivery, 0, len(events))
+	blocked := map[int64]bool{}
+	for _, event := range events {
+		delivery := models.OutboxDelivery{
+			EventID:       event.ID,
+			Status:        models.OutboxEventPending,
+			NextAttemptAt: r.now(),
+		}
+		if blocked[event.UserID] || ctx.Err() != nil {
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+
+		err := r.publisher.Publish(ctx, event)
+		switch {
+		case err == nil:
+			delivery.Status = models.OutboxEventDelivered
+		case event.Attempts+1 >= r.conf.MaxAttempts:
+			log.Error().
+				Err(err).
+				Int64("event_id", event.ID).
+				Str("event_type", string(event.Type)).
+				Int("attempts", event.Attempts+1).
+				Msg("outbox event moved to dead letters")
+			delivery.Status = models.OutboxEventDead
+			delivery.Error = err.Error()
+		default:
+			log.Warn().
+				Err(err).
+				Int64("event_id", event.ID).
+				Str("event_type", string(event.Type)).
+				Int("attempts", event.Attempts+1).
+				Msg("failed to publish outbox event")
+			delivery.Error = err.Error()
+			delivery.NextAttemptAt = r.now().Add(r.backoff(event.Attempts + 1))
+			blocked[event.UserID] = true
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// Задержка перед попыткой, следующей за attempt
+func (r *Relay) backoff(attempt int) time.Duration {
+	shift := min(attempt-1, maxBackoffShift)
+	delay := r.conf.Backoff << shift
+	if delay <= 0 || delay > r.conf.MaxBackoff {
+		return r.conf.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+// Публикатор, отклоняющий события заказов из failing
+type fakePublisher struct {
+	mu        sync.Mutex
+	failing   map[string]bool
+	published []models.OutboxEvent
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payload := models.OrderEventPayload{}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if p.failing[payload.Number] {
+		return errors.New("unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+// Ретранслятор с управляемыми часами
+func newTestRelay(storage Store, publisher Publisher, maxAttempts int) (*Relay, *time.Time) {
+	now := time.Now()
+	relay := NewRelay(storage, publisher, Config{
+		MaxAttempts: maxAttempts,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		Lease:       time.Minute,
+		BatchSize:   10,
+	})
+	relay.now = func() time.Time { return now }
+	return relay, &now
+}
+
+func TestRelay_DeliverPending(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewStorage()
+	publisher := &fakePublisher{failing: map[string]bool{"12345678903": true}}
+	firstUserID, err := storage.AddUser(ctx, "first", "hash")
+	require.NoError(t, err)
+	secondUserID, err := storage.AddUser(ctx, "second", "hash")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, firstUserID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, secondUserID, "2377225624"))
+	require.NoError(t, storage.RegisterOrder(ctx, firstUserID, "79927398713"))
+	relay, now := newTestRelay(storage, publisher, 10)
+
+	// События пользователя после недоставленного откладываются,
+	// события других пользователей доставляются
+	require.NoError(t, relay.DeliverPending(ctx))
+	require.Len(t, publisher.published, 1)
+	assert.Equal(t, secondUserID, publisher.published[0].UserID)
+
+	// До окончания задержки повторная попытка не выполняется
+	publisher.failing = nil
+	require.NoError(t, relay.DeliverPending(ctx))
+	require.Len(t, publisher.published, 1)
+
+	// После задержки доставка продолжается с недоставленного события
+	*now = now.Add(time.Second)
+	require.NoError(t, relay.DeliverPending(ctx))
+	require.Len(t, publisher.published, 3)
+	assert.Equal(t, firstUserID, publisher.published[1].UserID)
+	assert.Equal(t, 1, publisher.published[1].Attempts)
+	assert.Less(t, publisher.published[1].ID, publisher.published[2].ID)
+
+	require.NoError(t, relay.DeliverPending(ctx))
+	assert.Len(t, publisher.published, 3)
+}
+
+func TestRelay_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewStorage()
+	publisher := &fakePublisher{failing: map[string]bool{"12345678903": true}}
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "79927398713"))
+	relay, now := newTestRelay(storage, publisher, 2)
+
+	require.NoError(t, relay.DeliverPending(ctx))
+	assert.Empty(t, publisher.published)
+
+	// Событие, не доставленное за MaxAttempts попыток, больше не задерживает
+	// следующие события пользователя и не отправляется повторно
+	*now = now.Add(time.Second)
+	require.NoError(t, relay.DeliverPending(ctx))
+	require.Len(t, publisher.published, 1)
+	payload := models.OrderEventPayload{}
+	require.NoError(t, json.Unmarshal(publisher.published[0].Payload, &payload))
+	assert.Equal(t, "79927398713", payload.Number)
+
+	publisher.failing = nil
+	*now = now.Add(time.Hour)
+	require.NoError(t, relay.DeliverPending(ctx))
+	assert.Len(t, publisher.published, 1)
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay, _ := newTestRelay(nil, nil, 10)
+
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{name: "First Attempt Case", attempt: 1, want: time.Second},
+		{name: "Doubled Case", attempt: 3, want: 4 * time.Second},
+		{name: "Max Backoff Case", attempt: 7, want: time.Minute},
+		{name: "Overflow Case", attempt: 100, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, relay.backoff(tt.attempt))
+		})
+	}
+}
+
+func TestWebhookPublisher_Publish(t *testing.T) {
+	event := models.NewWithdrawalEvent(1, "2377225624", models.NewMoney(100, 0))
+	event.ID = 42
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "Delivered Case",
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Server Error Case",
+			statusCode: http.StatusServiceUnavailable,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, http.MethodPost, req.Method)
+				assert.Equal(t, "42", req.Header.Get("Event-Id"))
+				assert.Equal(t, string(models.OutboxEventBalanceWithdrawn), req.Header.Get("Event-Type"))
+
+				received := models.OutboxEvent{}
+				assert.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+				assert.Equal(t, event.ID, received.ID)
+				assert.JSONEq(t, string(event.Payload), string(received.Payload))
+				rw.WriteHeader(tt.statusCode)
+			}))
+			defer srv.Close()
+
+			err := NewWebhookPublisher(srv.URL, time.Second).Publish(context.Background(), event)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
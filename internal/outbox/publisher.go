@@ -0,0 +1,150 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+type (
+	// Способ доставки событий во внешние системы
+	Publisher interface {
+		Publish(ctx context.Context, event models.OutboxEvent) error
+	}
+
+	// Запись событий в поток, по одному JSON объекту в строке
+	WriterPublisher struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+
+	// Добавление событий в файл, по одному JSON объекту в строке
+	FilePublisher struct {
+		mu   sync.Mutex
+		path string
+	}
+
+	// Публикация событий несколькими способами. Событие считается доставленным, только если
+	// его приняли все публикаторы, поэтому при повторе оно может быть передано им еще раз.
+	MultiPublisher struct {
+		publishers []Publisher
+	}
+
+	// Отправка событий POST запросом с JSON телом. Успешной считается доставка
+	// с кодом ответа 2xx. Получатель может отбрасывать повторы по заголовку Event-Id.
+	WebhookPublisher struct {
+		url    string
+		client *http.Client
+	}
+)
+
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+func (p *MultiPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("outbox.multi.publish: %w", err)
+	}
+	return nil
+}
+
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("outbox.writer.publish: %w", err)
+	}
+	data = append(data, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(data); err != nil {
+		return fmt.Errorf("outbox.writer.publish: %w", err)
+	}
+	return nil
+}
+
+func NewFilePublisher(path string) *FilePublisher {
+	return &FilePublisher{path: path}
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("outbox.file.publish: %w", err)
+	}
+	data = append(data, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("outbox.file.publish: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("outbox.file.publish: %w", err)
+	}
+	return nil
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("outbox.webhook.publish.marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("outbox.webhook.publish.newRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-Id", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("Event-Type", string(event.Type))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("outbox.webhook.publish.do: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("outbox.webhook.publish: unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package scheduler периодически выполняет фоновую задачу с ограничением
+// времени каждого запуска, случайной задержкой и учетом состояния.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ulixes-bloom/ya-gophermart/internal/scheduler"
+
+type (
+	// Фоновая задача
+	Task func(ctx context.Context) error
+
+	// Параметры запуска задачи
+	Options struct {
+		Interval time.Duration // интервал между окончанием запуска и началом следующего
+		Timeout  time.Duration // максимальная длительность одного запуска (0 — без ограничения)
+		Jitter   time.Duration // максимальная случайная добавка к интервалу
+	}
+
+	// Состояние задачи
+	Health struct {
+		Name                string     `json:"name"`
+		Running             bool       `json:"running"`
+		StartedAt           time.Time  `json:"started_at"`
+		LastRunAt           *time.Time `json:"last_run_at,omitempty"`     // нет, если задача не запускалась
+		LastSuccessAt       *time.Time `json:"last_success_at,omitempty"` // нет, если успешных запусков не было
+		LastError           string     `json:"last_error,omitempty"`
+		ConsecutiveFailures int        `json:"consecutive_failures"`
+	}
+
+	Scheduler struct {
+		name string
+		task Task
+		opts Options
+
+		running chan struct{} // семафор, исключающий одновременные запуски задачи
+
+		mu     sync.RWMutex
+		health Health
+	}
+)
+
+func New(name string, task Task, opts Options) *Scheduler {
+	return &Scheduler{
+		name:    name,
+		task:    task,
+		opts:    opts,
+		running: make(chan struct{}, 1),
+		health:  Health{Name: name, StartedAt: time.Now()},
+	}
+}
+
+// Запуск задачи каждые Interval (+ случайная задержка до Jitter) до отмены ctx.
+// Отмена ctx не прерывает начатый запуск: каждый запуск получает контекст,
+// производный от runCtx и ограниченный Timeout.
+func (s *Scheduler) Run(ctx, runCtx context.Context) {
+	// Без положительного интервала задача запускалась бы без остановки
+	if s.opts.Interval <= 0 {
+		log.Error().Str("task", s.name).Dur("interval", s.opts.Interval).
+			Msg("scheduled task not started: non-positive interval")
+		return
+	}
+
+	s.mu.Lock()
+	s.health.StartedAt = time.Now()
+	s.mu.Unlock()
+
+	timer := time.NewTimer(s.nextDelay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := s.RunOnce(runCtx); err != nil {
+				log.Error().Err(err).Str("task", s.name).Msg("scheduled task failed")
+			}
+			timer.Reset(s.nextDelay())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Однократный запуск задачи. Если задача уже выполняется,
+// возвращается ErrTaskAlreadyRunning.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	select {
+	case s.running <- struct{}{}:
+		defer func() { <-s.running }()
+	default:
+		return appErrors.ErrTaskAlreadyRunning
+	}
+
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	s.mu.Lock()
+	s.health.Running = true
+	startedAt := time.Now()
+	s.health.LastRunAt = &startedAt
+	s.mu.Unlock()
+
+	// Каждый запуск задачи начинает отдельную трассу
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "scheduler."+s.name, trace.WithNewRoot())
+	defer span.End()
+
+	start := time.Now()
+	err := s.task(ctx)
+	finished := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Running = false
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.SchedulerRunDuration.WithLabelValues(s.name, "error").Observe(finished.Sub(start).Seconds())
+		s.health.LastError = err.Error()
+		s.health.ConsecutiveFailures++
+		return fmt.Errorf("scheduler.runOnce.%s: %w", s.name, err)
+	}
+	metrics.SchedulerRunDuration.WithLabelValues(s.name, "success").Observe(finished.Sub(start).Seconds())
+	metrics.SchedulerLastSuccess.WithLabelValues(s.name).Set(float64(finished.Unix()))
+	s.health.LastSuccessAt = &finished
+	s.health.LastError = ""
+	s.health.ConsecutiveFailures = 0
+
+	return nil
+}
+
+// Текущее состояние задачи
+func (s *Scheduler) Health() Health {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.health
+}
+
+// Задача считается исправной, если последний успешный запуск (или старт
+// планировщика, если успешных запусков еще не было) был не раньше MaxStaleness назад
+func (s *Scheduler) Healthy(now time.Time) bool {
+	h := s.Health()
+
+	lastSuccess := h.StartedAt
+	if h.LastSuccessAt != nil {
+		lastSuccess = *h.LastSuccessAt
+	}
+	return now.Sub(lastSuccess) <= s.MaxStaleness()
+}
+
+// Максимально допустимое время с последнего успешного запуска:
+// два полных цикла с учетом случайной задержки и длительности запуска
+func (s *Scheduler) MaxStaleness() time.Duration {
+	return 2 * (s.opts.Interval + s.opts.Jitter + s.opts.Timeout)
+}
+
+func (s *Scheduler) nextDelay() time.Duration {
+	if s.opts.Jitter <= 0 {
+		return s.opts.Interval
+	}
+	return s.opts.Interval + rand.N(s.opts.Jitter)
+}
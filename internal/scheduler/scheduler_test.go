@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+func TestScheduler_Run(t *testing.T) {
+	var runs atomic.Int64
+	task := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+	// Задача продолжает запускаться после истечения таймаута одного запуска
+	s := New("test", task, Options{
+		Interval: 10 * time.Millisecond,
+		Timeout:  20 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.Run(ctx, context.Background())
+
+	assert.Greater(t, runs.Load(), int64(5))
+	h := s.Health()
+	assert.False(t, h.Running)
+	assert.NotNil(t, h.LastSuccessAt)
+	assert.Zero(t, h.ConsecutiveFailures)
+}
+
+func TestScheduler_Run_NonPositiveInterval(t *testing.T) {
+	var runs atomic.Int64
+	s := New("test", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}, Options{})
+
+	// Задача не запускается, а Run возвращается, не дожидаясь отмены ctx
+	done := make(chan struct{})
+	go func() {
+		s.Run(context.Background(), context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler is still running")
+	}
+	assert.Zero(t, runs.Load())
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	taskErr := errors.New("accrual service unavailable")
+
+	tests := []struct {
+		name             string
+		task             Task
+		timeout          time.Duration
+		expectedErr      error
+		expectedFailures int
+	}{
+		{
+			name:             "Success Case",
+			task:             func(ctx context.Context) error { return nil },
+			expectedErr:      nil,
+			expectedFailures: 0,
+		},
+		{
+			name:             "Task error Case",
+			task:             func(ctx context.Context) error { return taskErr },
+			expectedErr:      taskErr,
+			expectedFailures: 1,
+		},
+		{
+			name: "Timeout Case",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			timeout:          10 * time.Millisecond,
+			expectedErr:      context.DeadlineExceeded,
+			expectedFailures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("test", tt.task, Options{Timeout: tt.timeout})
+
+			err := s.RunOnce(context.Background())
+
+			h := s.Health()
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.NotEmpty(t, h.LastError)
+				assert.Nil(t, h.LastSuccessAt)
+			} else {
+				assert.NoError(t, err)
+				assert.Empty(t, h.LastError)
+				assert.NotNil(t, h.LastSuccessAt)
+			}
+			assert.Equal(t, tt.expectedFailures, h.ConsecutiveFailures)
+		})
+	}
+}
+
+func TestScheduler_RunOnce_Overlapping(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s := New("test", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, Options{})
+
+	done := make(chan error)
+	go func() {
+		done <- s.RunOnce(context.Background())
+	}()
+	<-started
+
+	// Пока задача выполняется, повторный запуск не начинается
+	assert.True(t, s.Health().Running)
+	assert.ErrorIs(t, s.RunOnce(context.Background()), appErrors.ErrTaskAlreadyRunning)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.NoError(t, s.RunOnce(context.Background()))
+	assert.Len(t, started, 1)
+}
+
+func TestScheduler_Healthy(t *testing.T) {
+	s := New("test", func(ctx context.Context) error { return nil }, Options{
+		Interval: time.Minute,
+		Timeout:  time.Minute,
+	})
+	startedAt := s.Health().StartedAt
+
+	assert.True(t, s.Healthy(startedAt.Add(s.MaxStaleness())))
+	assert.False(t, s.Healthy(startedAt.Add(s.MaxStaleness()+time.Second)))
+
+	require.NoError(t, s.RunOnce(context.Background()))
+	lastSuccessAt := s.Health().LastSuccessAt
+	assert.True(t, s.Healthy(lastSuccessAt.Add(s.MaxStaleness())))
+}
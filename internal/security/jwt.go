@@ -10,38 +10,47 @@ import (
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int64
+	UserID       int64
+	TokenVersion int64 `json:",omitempty"` // версия токенов пользователя на момент выдачи
 }
 
-func BuildJWTString(userID int64, secretKey string, tokenLifetime time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+func BuildJWTString(userID, tokenVersion int64, keyring *Keyring, tokenLifetime time.Duration) (string, error) {
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", fmt.Errorf("security.jwt.buildJWTString: %w", err)
+	}
+
+	tokenString, err := keyring.Sign(Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenLifetime)),
 		},
-		UserID: userID,
+		UserID:       userID,
+		TokenVersion: tokenVersion,
 	})
-
-	tokenString, err := token.SignedString([]byte(secretKey))
 	if err != nil {
-		return "", fmt.Errorf("%w", err)
+		return "", fmt.Errorf("security.jwt.buildJWTString: %w", err)
 	}
 
 	return tokenString, nil
 }
 
-func GetUserID(tokenString, secretKey string) (int64, error) {
+// Проверка подписи и срока действия токена
+func ParseJWT(tokenString string, keyring *Keyring) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-	if err != nil {
+	if err := keyring.Parse(tokenString, claims); err != nil {
 		log.Error().Msg(err.Error())
-		return -1, fmt.Errorf("security.jwt.getUserID: %w", err)
+		return nil, fmt.Errorf("security.jwt.parseJWT: %w", err)
 	}
 
-	if !token.Valid {
-		return -1, fmt.Errorf("security.jwt.getUserID: token not valid")
+	return claims, nil
+}
+
+func GetUserID(tokenString string, keyring *Keyring) (int64, error) {
+	claims, err := ParseJWT(tokenString, keyring)
+	if err != nil {
+		return -1, fmt.Errorf("security.jwt.getUserID: %w", err)
 	}
 
 	return claims.UserID, nil
@@ -0,0 +1,287 @@
+package security
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Идентификатор ключа, создаваемого из TokenSecretKey. Этим ключом проверяются
+// и токены без заголовка kid, выпущенные до появления набора ключей.
+const DefaultKeyID = "default"
+
+// Минимальный размер RSA ключа
+const minRSAKeyBits = 2048
+
+type (
+	// Ключ подписи токенов
+	SigningKey struct {
+		ID        string
+		method    jwt.SigningMethod
+		signKey   any // секрет HMAC или закрытый ключ; nil для ключей, доступных только для проверки
+		verifyKey any // секрет HMAC или открытый ключ
+	}
+
+	// Набор ключей: токены подписываются активным ключом и проверяются
+	// любым ключом набора по заголовку kid. Ключ, выведенный из набора,
+	// перестает принимать выпущенные им токены.
+	Keyring struct {
+		activeID string
+		keys     map[string]*SigningKey
+	}
+
+	// Открытый ключ в формате JWK (RFC 7517)
+	JWK struct {
+		KeyType   string `json:"kty"`
+		KeyID     string `json:"kid"`
+		Algorithm string `json:"alg"`
+		Use       string `json:"use"`
+		N         string `json:"n,omitempty"`   // модуль RSA
+		E         string `json:"e,omitempty"`   // экспонента RSA
+		Curve     string `json:"crv,omitempty"` // кривая OKP
+		X         string `json:"x,omitempty"`   // открытый ключ OKP
+	}
+
+	// Набор открытых ключей
+	JWKSet struct {
+		Keys []JWK `json:"keys"`
+	}
+)
+
+// Ключ HS256 с общим секретом
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{
+		ID:        id,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// Ключ RS256 или EdDSA из PEM: закрытый ключ (PKCS#1 или PKCS#8)
+// либо только открытый ключ (PKIX или PKCS#1)
+func ParsePEMKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("security.parsePEMKey: %s: no PEM data", id)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("security.parsePEMKey: %s: %w: PEM type %q", id, appErrors.ErrUnsupportedSigningKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("security.parsePEMKey: %s: %w", id, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("security.parsePEMKey: %s: %w: RSA key shorter than %d bits", id, appErrors.ErrUnsupportedSigningKey, minRSAKeyBits)
+		}
+		return &SigningKey{ID: id, method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: id, method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: id, method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: id, method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("security.parsePEMKey: %s: %w: %T", id, appErrors.ErrUnsupportedSigningKey, key)
+	}
+}
+
+// Алгоритм подписи ключа
+func (k *SigningKey) Algorithm() string {
+	return k.method.Alg()
+}
+
+// Набор ключей с активным ключом activeID
+func NewKeyring(activeID string, keys ...*SigningKey) (*Keyring, error) {
+	kr := &Keyring{
+		activeID: activeID,
+		keys:     make(map[string]*SigningKey, len(keys)),
+	}
+	for _, key := range keys {
+		if _, ok := kr.keys[key.ID]; ok {
+			return nil, fmt.Errorf("security.newKeyring: duplicate key id %q", key.ID)
+		}
+		kr.keys[key.ID] = key
+	}
+
+	active, ok := kr.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("security.newKeyring: %w: %q", appErrors.ErrUnknownSigningKey, activeID)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("security.newKeyring: %w: %q", appErrors.ErrSigningKeyCannotSign, activeID)
+	}
+
+	return kr, nil
+}
+
+// Набор из единственного ключа HS256
+func NewHMACKeyring(secret string) *Keyring {
+	kr, _ := NewKeyring(DefaultKeyID, NewHMACKey(DefaultKeyID, []byte(secret)))
+	return kr
+}
+
+// Загрузка набора ключей из каталога. Идентификатором ключа служит имя файла:
+// <kid>.pem — ключ RS256/EdDSA в формате PEM (открытый ключ доступен только для проверки),
+// <kid>.secret — секрет HS256. Остальные файлы игнорируются.
+// Если activeID пуст, активным становится ключ с наибольшим идентификатором,
+// способный подписывать токены.
+func LoadKeyring(dir, activeID string) (*Keyring, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("security.loadKeyring: %w", err)
+	}
+
+	keys := []*SigningKey{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		id := strings.TrimSuffix(name, ext)
+		if ext != ".pem" && ext != ".secret" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("security.loadKeyring: %w", err)
+		}
+
+		if ext == ".secret" {
+			secret := bytes.TrimSpace(data)
+			if len(secret) == 0 {
+				return nil, fmt.Errorf("security.loadKeyring: %s: empty secret", name)
+			}
+			keys = append(keys, NewHMACKey(id, secret))
+			continue
+		}
+
+		key, err := ParsePEMKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("security.loadKeyring: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if activeID == "" {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+		for _, key := range keys {
+			if key.signKey != nil {
+				activeID = key.ID
+			}
+		}
+	}
+
+	kr, err := NewKeyring(activeID, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("security.loadKeyring: %w", err)
+	}
+	return kr, nil
+}
+
+// Идентификатор активного ключа
+func (kr *Keyring) ActiveKeyID() string {
+	return kr.activeID
+}
+
+// Подпись токена активным ключом
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key := kr.keys[kr.activeID]
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", fmt.Errorf("security.keyring.sign: %w", err)
+	}
+	return tokenString, nil
+}
+
+// Проверка токена ключом, указанным в заголовке kid. Алгоритм токена
+// должен совпадать с алгоритмом ключа.
+func (kr *Keyring) Parse(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		id, _ := t.Header["kid"].(string)
+		if id == "" {
+			id = DefaultKeyID
+		}
+
+		key, ok := kr.keys[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", appErrors.ErrUnknownSigningKey, id)
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, appErrors.ErrTokenAlgorithmMismatch
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return fmt.Errorf("security.keyring.parse: %w", err)
+	}
+
+	if !token.Valid {
+		return fmt.Errorf("security.keyring.parse: token not valid")
+	}
+	return nil
+}
+
+// Открытые ключи набора. Секреты HS256 не публикуются.
+func (kr *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range kr.keys {
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+func (k *SigningKey) jwk() (JWK, bool) {
+	jwk := JWK{KeyID: k.ID, Algorithm: k.method.Alg(), Use: "sig"}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
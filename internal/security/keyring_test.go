@@ -0,0 +1,180 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+func writeKeyFile(t *testing.T, dir, name string, pemType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+}
+
+func newKeysDir(t *testing.T) (string, *rsa.PrivateKey, ed25519.PrivateKey) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKeyFile(t, dir, "2024-rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	writeKeyFile(t, dir, "2025-ed.pem", "PRIVATE KEY", der)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2023-hmac.secret"), []byte("secret\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0o600))
+
+	return dir, rsaKey, edKey
+}
+
+func TestKeyring_SignAndParse(t *testing.T) {
+	dir, _, _ := newKeysDir(t)
+
+	tests := []struct {
+		name        string
+		activeID    string
+		expectedAlg string
+	}{
+		{name: "HS256 Case", activeID: "2023-hmac", expectedAlg: "HS256"},
+		{name: "RS256 Case", activeID: "2024-rsa", expectedAlg: "RS256"},
+		{name: "EdDSA Case", activeID: "2025-ed", expectedAlg: "EdDSA"},
+		{name: "Default active key Case", activeID: "", expectedAlg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := LoadKeyring(dir, tt.activeID)
+			require.NoError(t, err)
+
+			tokenString, err := BuildJWTString(1, 0, keyring, time.Minute)
+			require.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAlg, token.Method.Alg())
+			assert.Equal(t, keyring.ActiveKeyID(), token.Header["kid"])
+
+			userID, err := GetUserID(tokenString, keyring)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), userID)
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	dir, _, _ := newKeysDir(t)
+
+	oldKeyring, err := LoadKeyring(dir, "2024-rsa")
+	require.NoError(t, err)
+	oldToken, err := BuildJWTString(1, 0, oldKeyring, time.Minute)
+	require.NoError(t, err)
+
+	// После смены активного ключа старые токены продолжают проверяться
+	newKeyring, err := LoadKeyring(dir, "2025-ed")
+	require.NoError(t, err)
+	_, err = GetUserID(oldToken, newKeyring)
+	assert.NoError(t, err)
+
+	// После вывода ключа из набора выпущенные им токены отклоняются
+	require.NoError(t, os.Remove(filepath.Join(dir, "2024-rsa.pem")))
+	retiredKeyring, err := LoadKeyring(dir, "2025-ed")
+	require.NoError(t, err)
+	_, err = GetUserID(oldToken, retiredKeyring)
+	assert.ErrorIs(t, err, appErrors.ErrUnknownSigningKey)
+}
+
+func TestKeyring_Parse(t *testing.T) {
+	dir, rsaKey, _ := newKeysDir(t)
+	keyring, err := LoadKeyring(dir, "2024-rsa")
+	require.NoError(t, err)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           1,
+	}
+
+	// Токен HS256, подписанный открытым RSA ключом, не принимается за RS256
+	pubDER := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "2024-rsa"
+	forgedString, err := forged.SignedString(pubDER)
+	require.NoError(t, err)
+	_, err = GetUserID(forgedString, keyring)
+	assert.ErrorIs(t, err, appErrors.ErrTokenAlgorithmMismatch)
+
+	// Токены без kid проверяются ключом по умолчанию
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("SECRET_KEY"))
+	require.NoError(t, err)
+	userID, err := GetUserID(legacy, NewHMACKeyring("SECRET_KEY"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), userID)
+	_, err = GetUserID(legacy, keyring)
+	assert.ErrorIs(t, err, appErrors.ErrUnknownSigningKey)
+}
+
+func TestLoadKeyring_Errors(t *testing.T) {
+	t.Run("Public key only Case", func(t *testing.T) {
+		dir := t.TempDir()
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		require.NoError(t, err)
+		writeKeyFile(t, dir, "public.pem", "PUBLIC KEY", der)
+
+		_, err = LoadKeyring(dir, "public")
+		assert.ErrorIs(t, err, appErrors.ErrSigningKeyCannotSign)
+	})
+
+	t.Run("Unknown active key Case", func(t *testing.T) {
+		dir, _, _ := newKeysDir(t)
+		_, err := LoadKeyring(dir, "missing")
+		assert.ErrorIs(t, err, appErrors.ErrUnknownSigningKey)
+	})
+
+	t.Run("Short RSA key Case", func(t *testing.T) {
+		dir := t.TempDir()
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		writeKeyFile(t, dir, "short.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+		_, err = LoadKeyring(dir, "short")
+		assert.ErrorIs(t, err, appErrors.ErrUnsupportedSigningKey)
+	})
+}
+
+func TestKeyring_JWKS(t *testing.T) {
+	dir, rsaKey, edKey := newKeysDir(t)
+	keyring, err := LoadKeyring(dir, "")
+	require.NoError(t, err)
+
+	// Секрет HS256 не публикуется
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, "2024-rsa", jwks.Keys[0].KeyID)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), jwks.Keys[0].N)
+
+	assert.Equal(t, "2025-ed", jwks.Keys[1].KeyID)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey)), jwks.Keys[1].X)
+}
@@ -1,19 +1,225 @@
 package security
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// Алгоритмы хеширования паролей
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+type (
+	// Параметры хеширования паролей
+	PasswordHashConfig struct {
+		Algorithm     string // алгоритм хеширования новых паролей
+		BcryptCost    int
+		Argon2Time    uint32 // количество проходов
+		Argon2Memory  uint32 // объем памяти в КиБ
+		Argon2Threads uint8
+	}
+
+	// Хеширование паролей выбранным алгоритмом. Проверяются хеши всех
+	// поддерживаемых алгоритмов, алгоритм определяется по префиксу хеша.
+	PasswordHasher struct {
+		algorithms map[string]passwordHashAlgorithm
+		current    string
+	}
+
+	passwordHashAlgorithm interface {
+		// Признак хеша, созданного алгоритмом
+		match(hash string) bool
+		hash(password string) (string, error)
+		verify(password, hash string) error
+		// Хеш создан с параметрами, отличными от текущих
+		outdated(hash string) bool
+	}
+
+	bcryptAlgorithm struct {
+		cost int
+	}
+
+	argon2idAlgorithm struct {
+		time    uint32
+		memory  uint32
+		threads uint8
+	}
+
+	// Параметры и значения хеша argon2id в формате PHC
+	argon2idHash struct {
+		version uint32
+		argon2idAlgorithm
+		salt []byte
+		key  []byte
+	}
+)
+
+func NewPasswordHasher(conf PasswordHashConfig) *PasswordHasher {
+	return &PasswordHasher{
+		algorithms: map[string]passwordHashAlgorithm{
+			PasswordHashBcrypt: &bcryptAlgorithm{cost: conf.BcryptCost},
+			PasswordHashArgon2id: &argon2idAlgorithm{
+				time:    conf.Argon2Time,
+				memory:  conf.Argon2Memory,
+				threads: conf.Argon2Threads,
+			},
+		},
+		current: conf.Algorithm,
+	}
+}
+
+// Хеширование пароля текущим алгоритмом
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	alg, ok := h.algorithms[h.current]
+	if !ok {
+		return "", fmt.Errorf("security.passwordHasher.hash: %w: %q", appErrors.ErrUnsupportedPasswordHash, h.current)
+	}
+
+	hash, err := alg.hash(password)
+	if err != nil {
+		return "", fmt.Errorf("security.passwordHasher.hash: %w", err)
+	}
+	return hash, nil
+}
+
+// Проверка пароля. При несовпадении возвращается ErrInvalidPassword.
+func (h *PasswordHasher) Verify(password, hash string) error {
+	name, alg := h.detect(hash)
+	if alg == nil {
+		return fmt.Errorf("security.passwordHasher.verify: %w", appErrors.ErrUnsupportedPasswordHash)
+	}
+
+	if err := alg.verify(password, hash); err != nil {
+		return fmt.Errorf("security.passwordHasher.verify.%s: %w", name, err)
+	}
+	return nil
+}
+
+// Хеш создан другим алгоритмом или с устаревшими параметрами
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	name, alg := h.detect(hash)
+	if alg == nil || name != h.current {
+		return true
+	}
+	return alg.outdated(hash)
+}
+
+func (h *PasswordHasher) detect(hash string) (string, passwordHashAlgorithm) {
+	for name, alg := range h.algorithms {
+		if alg.match(hash) {
+			return name, alg
+		}
+	}
+	return "", nil
+}
+
+func (a *bcryptAlgorithm) match(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (a *bcryptAlgorithm) hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
 	if err != nil {
-		return "", fmt.Errorf("security.hashPassword: %w", err)
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (a *bcryptAlgorithm) verify(password, hash string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return appErrors.ErrInvalidPassword
+	}
+	return err
+}
+
+func (a *bcryptAlgorithm) outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != a.cost
+}
+
+func (a *argon2idAlgorithm) match(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (a *argon2idAlgorithm) hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
 	}
-	return string(hashedPassword), nil
+
+	key := argon2.IDKey([]byte(password), salt, a.time, a.memory, a.threads, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.memory, a.time, a.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
-func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+func (a *argon2idAlgorithm) verify(password, hash string) error {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt,
+		parsed.time, parsed.memory, parsed.threads, uint32(len(parsed.key)))
+	if subtle.ConstantTimeCompare(key, parsed.key) != 1 {
+		return appErrors.ErrInvalidPassword
+	}
+	return nil
+}
+
+func (a *argon2idAlgorithm) outdated(hash string) bool {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return parsed.version != argon2.Version ||
+		parsed.argon2idAlgorithm != *a ||
+		len(parsed.salt) != argon2SaltLength ||
+		len(parsed.key) != argon2KeyLength
+}
+
+// Разбор хеша вида $argon2id$v=19$m=65536,t=3,p=2$<соль>$<ключ>
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return nil, appErrors.ErrUnsupportedPasswordHash
+	}
+
+	parsed := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &parsed.version); err != nil {
+		return nil, fmt.Errorf("%w: %w", appErrors.ErrUnsupportedPasswordHash, err)
+	}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.memory, &parsed.time, &parsed.threads)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", appErrors.ErrUnsupportedPasswordHash, err)
+	}
+
+	if parsed.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("%w: %w", appErrors.ErrUnsupportedPasswordHash, err)
+	}
+	if parsed.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("%w: %w", appErrors.ErrUnsupportedPasswordHash, err)
+	}
+	if len(parsed.key) == 0 {
+		return nil, appErrors.ErrUnsupportedPasswordHash
+	}
+
+	return parsed, nil
 }
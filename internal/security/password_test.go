@@ -0,0 +1,142 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Минимальные параметры для быстрых тестов
+var testHashConfig = PasswordHashConfig{
+	Algorithm:     PasswordHashBcrypt,
+	BcryptCost:    4,
+	Argon2Time:    1,
+	Argon2Memory:  64,
+	Argon2Threads: 1,
+}
+
+func TestPasswordHasher_HashAndVerify(t *testing.T) {
+	tests := []struct {
+		name           string
+		algorithm      string
+		expectedPrefix string
+	}{
+		{
+			name:           "Bcrypt Case",
+			algorithm:      PasswordHashBcrypt,
+			expectedPrefix: "$2a$04$",
+		},
+		{
+			name:           "Argon2id Case",
+			algorithm:      PasswordHashArgon2id,
+			expectedPrefix: "$argon2id$v=19$m=64,t=1,p=1$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testHashConfig
+			conf.Algorithm = tt.algorithm
+			hasher := NewPasswordHasher(conf)
+
+			hash, err := hasher.Hash("password")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.expectedPrefix), hash)
+
+			// Соль случайна: хеши одного пароля различаются
+			otherHash, err := hasher.Hash("password")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, otherHash)
+
+			assert.NoError(t, hasher.Verify("password", hash))
+			assert.ErrorIs(t, hasher.Verify("wrong", hash), appErrors.ErrInvalidPassword)
+			assert.False(t, hasher.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestPasswordHasher_VerifyAnyAlgorithm(t *testing.T) {
+	bcryptHash, err := NewPasswordHasher(testHashConfig).Hash("password")
+	require.NoError(t, err)
+
+	argonConf := testHashConfig
+	argonConf.Algorithm = PasswordHashArgon2id
+	argonHasher := NewPasswordHasher(argonConf)
+
+	// Хеши других алгоритмов проверяются, но требуют перехеширования
+	assert.NoError(t, argonHasher.Verify("password", bcryptHash))
+	assert.True(t, argonHasher.NeedsRehash(bcryptHash))
+
+	assert.ErrorIs(t, argonHasher.Verify("password", "plain"), appErrors.ErrUnsupportedPasswordHash)
+	assert.ErrorIs(t, argonHasher.Verify("password", "$argon2id$v=19$broken"), appErrors.ErrUnsupportedPasswordHash)
+	assert.True(t, argonHasher.NeedsRehash("plain"))
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	bcryptHash, err := NewPasswordHasher(testHashConfig).Hash("password")
+	require.NoError(t, err)
+
+	argonConf := testHashConfig
+	argonConf.Algorithm = PasswordHashArgon2id
+	argonHash, err := NewPasswordHasher(argonConf).Hash("password")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		conf     func(conf PasswordHashConfig) PasswordHashConfig
+		hash     string
+		expected bool
+	}{
+		{
+			name:     "Same bcrypt cost Case",
+			conf:     func(conf PasswordHashConfig) PasswordHashConfig { return conf },
+			hash:     bcryptHash,
+			expected: false,
+		},
+		{
+			name: "Increased bcrypt cost Case",
+			conf: func(conf PasswordHashConfig) PasswordHashConfig {
+				conf.BcryptCost = 5
+				return conf
+			},
+			hash:     bcryptHash,
+			expected: true,
+		},
+		{
+			name: "Changed argon2id memory Case",
+			conf: func(conf PasswordHashConfig) PasswordHashConfig {
+				conf.Algorithm = PasswordHashArgon2id
+				conf.Argon2Memory = 128
+				return conf
+			},
+			hash:     argonHash,
+			expected: true,
+		},
+		{
+			name: "Changed argon2id passes Case",
+			conf: func(conf PasswordHashConfig) PasswordHashConfig {
+				conf.Algorithm = PasswordHashArgon2id
+				conf.Argon2Time = 2
+				return conf
+			},
+			hash:     argonHash,
+			expected: true,
+		},
+		{
+			name:     "Argon2id to bcrypt Case",
+			conf:     func(conf PasswordHashConfig) PasswordHashConfig { return conf },
+			hash:     argonHash,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := NewPasswordHasher(tt.conf(testHashConfig))
+			assert.Equal(t, tt.expected, hasher.NeedsRehash(tt.hash))
+		})
+	}
+}
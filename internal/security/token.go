@@ -0,0 +1,59 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Случайный идентификатор токена (jti) или семейства refresh токенов
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("security.newTokenID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Новый непрозрачный refresh токен
+func NewRefreshToken() (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("security.newRefreshToken: %w", err)
+	}
+	return token, nil
+}
+
+// Новый непрозрачный токен сброса пароля
+func NewPasswordResetToken() (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("security.newPasswordResetToken: %w", err)
+	}
+	return token, nil
+}
+
+// Новый секрет подписи запросов на вебхук
+func NewWebhookSecret() (string, error) {
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("security.newWebhookSecret: %w", err)
+	}
+	return secret, nil
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Хеш непрозрачного токена для хранения: сам токен в хранилище не сохраняется
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) GetBalanceByUser(ctx context.Context, userID int64) (*models.Balance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	balance, ok := m.balances[userID]
+	if !ok {
+		return nil, fmt.Errorf("memory.getBalanceByUser: %w", appErrors.ErrUserNotFound)
+	}
+
+	dbBalance := *balance
+	return &dbBalance, nil
+}
+
+func (m *memstorage) GetWithdrawalsByUser(ctx context.Context, userID int64) ([]models.Withdrawal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dbWithdrawals := []models.Withdrawal{}
+	for _, withdrawal := range m.withdrawals {
+		if withdrawal.UserID == userID {
+			dbWithdrawals = append(dbWithdrawals, withdrawal)
+		}
+	}
+
+	return dbWithdrawals, nil
+}
+
+func (m *memstorage) GetWithdrawalsPageByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) ([]models.Withdrawal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	withdrawals := []models.Withdrawal{}
+	for _, withdrawal := range m.withdrawals {
+		if withdrawal.UserID == userID && matchWithdrawalsFilter(withdrawal, query) &&
+			matchWithdrawalCursor(withdrawal, query) {
+			withdrawals = append(withdrawals, withdrawal)
+		}
+	}
+	sort.Slice(withdrawals, func(i, j int) bool {
+		if query.Descending {
+			return withdrawalPrecedes(withdrawals[j], withdrawals[i].ProcessedAt, withdrawals[i].ID)
+		}
+		return withdrawalPrecedes(withdrawals[i], withdrawals[j].ProcessedAt, withdrawals[j].ID)
+	})
+
+	if len(withdrawals) > query.Limit {
+		withdrawals = withdrawals[:query.Limit]
+	}
+	return withdrawals, nil
+}
+
+func (m *memstorage) GetWithdrawalsSummaryByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsSummary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := &models.WithdrawalsSummary{}
+	for _, withdrawal := range m.withdrawals {
+		if withdrawal.UserID == userID && matchWithdrawalsFilter(withdrawal, query) {
+			summary.Total = summary.Total.Add(withdrawal.Sum)
+			summary.Count++
+		}
+	}
+	return summary, nil
+}
+
+func (m *memstorage) WithdrawFromUserBalance(ctx context.Context, userID int64, orderNumber string, sum models.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	balance, ok := m.balances[userID]
+	if !ok {
+		return fmt.Errorf("memory.withdrawFromUserBalance.updateBalance: %w", appErrors.ErrUserNotFound)
+	}
+	if balance.Current.Sub(sum).IsNegative() {
+		return appErrors.ErrNegativeBalance
+	}
+
+	for _, withdrawal := range m.withdrawals {
+		if withdrawal.Order == orderNumber {
+			return fmt.Errorf("memory.withdrawFromUserBalance.insertWithdrawal: order %s was already used for withdrawal", orderNumber)
+		}
+	}
+
+	balance.Current = balance.Current.Sub(sum)
+	balance.Withdrawn = balance.Withdrawn.Add(sum)
+
+	m.lastWithdrawalID++
+	m.withdrawals = append(m.withdrawals, models.Withdrawal{
+		ID:          m.lastWithdrawalID,
+		UserID:      userID,
+		Order:       orderNumber,
+		ProcessedAt: time.Now(),
+		Sum:         sum,
+	})
+	m.appendLedgerEntry(models.NewWithdrawalEntry(userID, orderNumber, sum))
+	m.appendOutboxEvent(models.NewWithdrawalEvent(userID, orderNumber, sum))
+
+	return nil
+}
+
+// Соответствие списания фильтрам выборки
+func matchWithdrawalsFilter(withdrawal models.Withdrawal, query *models.WithdrawalsQuery) bool {
+	if query.ProcessedFrom != nil && withdrawal.ProcessedAt.Before(*query.ProcessedFrom) {
+		return false
+	}
+	if query.ProcessedTo != nil && !withdrawal.ProcessedAt.Before(*query.ProcessedTo) {
+		return false
+	}
+	if query.MinSum != nil && withdrawal.Sum < *query.MinSum {
+		return false
+	}
+	if query.MaxSum != nil && withdrawal.Sum > *query.MaxSum {
+		return false
+	}
+	return true
+}
+
+// Списание находится после позиции выборки
+func matchWithdrawalCursor(withdrawal models.Withdrawal, query *models.WithdrawalsQuery) bool {
+	after := query.After
+	if after == nil {
+		return true
+	}
+	if query.Descending {
+		return withdrawalPrecedes(withdrawal, after.ProcessedAt, after.ID)
+	}
+	return !withdrawalPrecedes(withdrawal, after.ProcessedAt, after.ID) &&
+		!(withdrawal.ProcessedAt.Equal(after.ProcessedAt) && withdrawal.ID == after.ID)
+}
+
+// Списание предшествует позиции (processedAt, id) при упорядочивании по возрастанию
+func withdrawalPrecedes(withdrawal models.Withdrawal, processedAt time.Time, id int64) bool {
+	if !withdrawal.ProcessedAt.Equal(processedAt) {
+		return withdrawal.ProcessedAt.Before(processedAt)
+	}
+	return withdrawal.ID < id
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) GetLedgerByUser(ctx context.Context, userID int64) ([]models.LedgerEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []models.LedgerEntry{}
+	for _, entry := range m.ledger {
+		if entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (m *memstorage) GetBalanceDiscrepancies(ctx context.Context) ([]models.BalanceDiscrepancy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ledgerBalances := make(map[int64]*models.Balance, len(m.balances))
+	for _, entry := range m.ledger {
+		balance, ok := ledgerBalances[entry.UserID]
+		if !ok {
+			balance = &models.Balance{UserID: entry.UserID}
+			ledgerBalances[entry.UserID] = balance
+		}
+		balance.Current = balance.Current.Add(entry.BalanceChange())
+		balance.Withdrawn = balance.Withdrawn.Add(entry.WithdrawnChange())
+	}
+
+	discrepancies := []models.BalanceDiscrepancy{}
+	for userID, balance := range m.balances {
+		ledgerBalance := models.Balance{UserID: userID}
+		if b, ok := ledgerBalances[userID]; ok {
+			ledgerBalance = *b
+		}
+
+		if balance.Current != ledgerBalance.Current || balance.Withdrawn != ledgerBalance.Withdrawn {
+			discrepancies = append(discrepancies, models.BalanceDiscrepancy{
+				UserID:  userID,
+				Balance: *balance,
+				Ledger:  ledgerBalance,
+			})
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].UserID < discrepancies[j].UserID
+	})
+
+	return discrepancies, nil
+}
+
+// Добавление проводки в журнал. Вызывается под блокировкой на запись.
+func (m *memstorage) appendLedgerEntry(entry models.LedgerEntry) {
+	m.lastLedgerEntryID++
+	entry.ID = m.lastLedgerEntryID
+	entry.CreatedAt = time.Now()
+	m.ledger = append(m.ledger, entry)
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) AddLoginLockout(ctx context.Context, lockout models.LoginLockout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastLoginLockoutID++
+	lockout.ID = m.lastLoginLockoutID
+	lockout.CreatedAt = time.Now()
+	m.loginLockouts = append(m.loginLockouts, lockout)
+
+	return nil
+}
+
+func (m *memstorage) GetLoginLockouts(ctx context.Context, subjectType models.LoginLockoutSubject, subject string) ([]models.LoginLockout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	lockouts := []models.LoginLockout{}
+	for _, lockout := range m.loginLockouts {
+		if lockout.SubjectType == subjectType && lockout.Subject == subject {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+
+	return lockouts, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Хранилище, размещающее все данные в памяти процесса.
+// Каждая операция выполняется под общей блокировкой, что делает её атомарной
+// и изолированной от остальных операций (аналог транзакции в БД).
+type memstorage struct {
+	mu sync.RWMutex
+
+	users       map[int64]*models.User               // пользователи по ID
+	logins      map[string]int64                     // ID пользователя по логину
+	orders      map[string]*models.Order             // заказы по номеру
+	orderStatus map[int64][]models.OrderStatusChange // история статусов по ID заказа
+	balances    map[int64]*models.Balance            // балансы по ID пользователя
+	withdrawals []models.Withdrawal                  // списания в порядке их проведения
+	ledger      []models.LedgerEntry                 // журнал проводок в порядке их добавления
+	outbox      []models.OutboxEvent                 // недоставленные события в порядке их записи
+
+	webhooks          map[int64]*models.Webhook // вебхуки по ID
+	webhookDeliveries []*models.WebhookDelivery // доставки на вебхуки в порядке их создания
+
+	refreshTokens map[string]*models.RefreshToken // refresh токены по хешу
+	revokedTokens map[string]time.Time            // время истечения отозванных access токенов по jti
+	loginLockouts []models.LoginLockout           // журнал блокировок входа
+
+	passwordResetTokens map[string]*models.PasswordResetToken // токены сброса пароля по хешу
+
+	lastUserID         int64
+	lastOrderID        int64
+	lastBalanceID      int64
+	lastWithdrawalID   int64
+	lastLedgerEntryID  int64
+	lastRefreshTokenID int64
+	lastLoginLockoutID int64
+	lastResetTokenID   int64
+	lastOutboxEventID  int64
+	lastWebhookID      int64
+
+	lastWebhookDeliveryID int64
+}
+
+func NewStorage() *memstorage {
+	return &memstorage{
+		users:       make(map[int64]*models.User),
+		logins:      make(map[string]int64),
+		orders:      make(map[string]*models.Order),
+		orderStatus: make(map[int64][]models.OrderStatusChange),
+		balances:    make(map[int64]*models.Balance),
+		webhooks:    make(map[int64]*models.Webhook),
+
+		refreshTokens: make(map[string]*models.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+
+		passwordResetTokens: make(map[string]*models.PasswordResetToken),
+	}
+}
+
+func (m *memstorage) Close() error {
+	return nil
+}
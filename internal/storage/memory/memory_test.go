@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/app"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/storagetest"
+)
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, newStorage)
+}
+
+func TestStorage_BalanceDiscrepancies(t *testing.T) {
+	storagetest.RunBalanceDiscrepancies(t, newStorage,
+		func(ctx context.Context, storage app.Storage, userID int64, current models.Money) error {
+			m := storage.(*memstorage)
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.balances[userID].Current = current
+			return nil
+		})
+}
+
+func TestStorage_OrdersOrder(t *testing.T) {
+	storagetest.RunOrdersOrder(t, newStorage,
+		func(ctx context.Context, storage app.Storage, number string, uploadedAt time.Time) error {
+			m := storage.(*memstorage)
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.orders[number].UploadedAt = uploadedAt
+			return nil
+		})
+}
+
+func newStorage(ctx context.Context, t *testing.T) app.Storage {
+	return NewStorage()
+}
@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) RegisterOrder(ctx context.Context, userID int64, orderNumber string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existingOrder, ok := m.orders[orderNumber]; ok {
+		if existingOrder.UserID == userID {
+			return appErrors.ErrOrderWasUploadedByCurrentUser
+		}
+		return appErrors.ErrOrderWasUploadedByAnotherUser
+	}
+
+	if _, ok := m.users[userID]; !ok {
+		return fmt.Errorf("memory.registerOrder: %w", appErrors.ErrUserNotFound)
+	}
+
+	m.addOrder(userID, orderNumber)
+
+	return nil
+}
+
+func (m *memstorage) RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, fmt.Errorf("memory.registerOrders: %w", appErrors.ErrUserNotFound)
+	}
+
+	results := make([]models.OrderUploadResult, 0, len(orderNumbers))
+	for _, number := range orderNumbers {
+		result := models.OrderUploadResult{Number: number, Status: models.OrderUploadAccepted}
+		if existingOrder, ok := m.orders[number]; ok {
+			result.Status = models.OrderUploadConflict
+			if existingOrder.UserID == userID {
+				result.Status = models.OrderUploadAlreadyUploaded
+			}
+			results = append(results, result)
+			continue
+		}
+
+		m.addOrder(userID, number)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (m *memstorage) GetOrderByNumber(ctx context.Context, orderNumber string) (*models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[orderNumber]
+	if !ok {
+		return nil, fmt.Errorf("memory.getOrderByNumber: %w", appErrors.ErrOrderNotFound)
+	}
+
+	dbOrder := *order
+	return &dbOrder, nil
+}
+
+func (m *memstorage) GetOrderStatusHistory(ctx context.Context, orderID int64) ([]models.OrderStatusChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history := append([]models.OrderStatusChange{}, m.orderStatus[orderID]...)
+	return history, nil
+}
+
+func (m *memstorage) GetOrderStatusTransitions(ctx context.Context, from, to time.Time) ([]models.OrderStatusTransition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	transitions := []models.OrderStatusTransition{}
+	for _, order := range m.orders {
+		for _, transition := range models.NewOrderStatusTransitions(*order, m.orderStatus[order.ID]) {
+			if !transition.ChangedAt.Before(from) && transition.ChangedAt.Before(to) {
+				transitions = append(transitions, transition)
+			}
+		}
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		if !transitions[i].ChangedAt.Equal(transitions[j].ChangedAt) {
+			return transitions[i].ChangedAt.Before(transitions[j].ChangedAt)
+		}
+		return transitions[i].OrderID < transitions[j].OrderID
+	})
+
+	return transitions, nil
+}
+
+func (m *memstorage) GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := []models.Order{}
+	for _, order := range m.orders {
+		if order.UserID == userID {
+			orders = append(orders, *order)
+		}
+	}
+	sortOrders(orders)
+
+	return orders, nil
+}
+
+func (m *memstorage) GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) ([]models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := []models.Order{}
+	for _, order := range m.orders {
+		if order.UserID == userID && matchOrdersQuery(order, query) {
+			orders = append(orders, *order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if query.Descending {
+			return orderPrecedes(orders[j], orders[i].UploadedAt, orders[i].ID)
+		}
+		return orderPrecedes(orders[i], orders[j].UploadedAt, orders[j].ID)
+	})
+
+	if len(orders) > query.Limit {
+		orders = orders[:query.Limit]
+	}
+	return orders, nil
+}
+
+func (m *memstorage) GetOrdersByStatus(ctx context.Context, statuses []models.OrderStatus) ([]models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := []models.Order{}
+	for _, order := range m.orders {
+		if slices.Contains(statuses, order.Status) {
+			orders = append(orders, *order)
+		}
+	}
+	sortOrders(orders)
+
+	return orders, nil
+}
+
+func (m *memstorage) GetOrdersCountByStatus(ctx context.Context) (map[models.OrderStatus]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[models.OrderStatus]int64)
+	for _, order := range m.orders {
+		counts[order.Status]++
+	}
+
+	return counts, nil
+}
+
+func (m *memstorage) SetOrdersAccrualAndUpdateBalance(ctx context.Context, orders []models.Order) ([]models.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updatedOrders := make([]models.Order, 0, len(orders))
+	for _, order := range orders {
+		dbOrder, ok := m.orders[order.Number]
+		if !ok || !isNotProcessed(dbOrder.Status) || dbOrder.Status == order.Status {
+			continue
+		}
+		dbOrder.Accrual = order.Accrual
+		dbOrder.Status = order.Status
+		m.appendOrderStatus(dbOrder.ID, dbOrder.Status, order.AccrualStatus, time.Now())
+		m.appendOutboxEvent(models.NewOrderStatusEvent(*dbOrder))
+		updatedOrders = append(updatedOrders, *dbOrder)
+
+		if dbOrder.Status != models.OrderStatusProcessed || dbOrder.Accrual <= 0 {
+			continue
+		}
+		if balance, ok := m.balances[dbOrder.UserID]; ok {
+			balance.Current = balance.Current.Add(dbOrder.Accrual)
+			m.appendLedgerEntry(models.NewAccrualEntry(dbOrder.UserID, dbOrder.Number, dbOrder.Accrual))
+		}
+	}
+
+	return updatedOrders, nil
+}
+
+func (m *memstorage) UpdateOrdersAccrualFailures(ctx context.Context, failures []models.OrderAccrualFailure, succeeded []string) ([]models.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, number := range succeeded {
+		if dbOrder, ok := m.orders[number]; ok {
+			dbOrder.AccrualFailures = 0
+			dbOrder.LastAccrualError = ""
+		}
+	}
+
+	failedOrders := make([]models.Order, 0, len(failures))
+	for _, failure := range failures {
+		dbOrder, ok := m.orders[failure.Number]
+		if !ok {
+			continue
+		}
+		dbOrder.AccrualFailures++
+		dbOrder.LastAccrualError = failure.Error
+		failedOrders = append(failedOrders, *dbOrder)
+	}
+
+	return failedOrders, nil
+}
+
+func (m *memstorage) GetOrdersByAccrualFailures(ctx context.Context, minFailures int) ([]models.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := []models.Order{}
+	for _, order := range m.orders {
+		if order.AccrualFailures >= minFailures && isNotProcessed(order.Status) {
+			orders = append(orders, *order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].AccrualFailures != orders[j].AccrualFailures {
+			return orders[i].AccrualFailures > orders[j].AccrualFailures
+		}
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders, nil
+}
+
+func (m *memstorage) CountOrdersByAccrualFailures(ctx context.Context, minFailures int) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, order := range m.orders {
+		if order.AccrualFailures >= minFailures && isNotProcessed(order.Status) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Статусы, из которых допускается изменение заказа
+func isNotProcessed(status models.OrderStatus) bool {
+	return status == models.OrderStatusNew || status == models.OrderStatusProcessing
+}
+
+// Добавление нового заказа. Вызывается под блокировкой на запись.
+func (m *memstorage) addOrder(userID int64, orderNumber string) {
+	m.lastOrderID++
+	order := &models.Order{
+		ID:         m.lastOrderID,
+		UserID:     userID,
+		Number:     orderNumber,
+		Status:     models.OrderStatusNew,
+		UploadedAt: time.Now(),
+	}
+	m.orders[orderNumber] = order
+	m.appendOrderStatus(order.ID, order.Status, "", order.UploadedAt)
+	m.appendOutboxEvent(models.NewOrderRegisteredEvent(userID, orderNumber))
+}
+
+// Добавление записи в историю статусов заказа. Вызывается под блокировкой на запись.
+func (m *memstorage) appendOrderStatus(orderID int64, status models.OrderStatus, accrualStatus models.AccrualStatus, changedAt time.Time) {
+	m.orderStatus[orderID] = append(m.orderStatus[orderID], models.OrderStatusChange{
+		Status:        status,
+		AccrualStatus: accrualStatus,
+		ChangedAt:     changedAt,
+	})
+}
+
+// Соответствие заказа фильтрам и позиции выборки
+func matchOrdersQuery(order *models.Order, query *models.OrdersQuery) bool {
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, order.Status) {
+		return false
+	}
+	if query.UploadedFrom != nil && order.UploadedAt.Before(*query.UploadedFrom) {
+		return false
+	}
+	if query.UploadedTo != nil && !order.UploadedAt.Before(*query.UploadedTo) {
+		return false
+	}
+	if after := query.After; after != nil {
+		if query.Descending {
+			return orderPrecedes(*order, after.UploadedAt, after.ID)
+		}
+		return !orderPrecedes(*order, after.UploadedAt, after.ID) &&
+			!(order.UploadedAt.Equal(after.UploadedAt) && order.ID == after.ID)
+	}
+	return true
+}
+
+// Заказ предшествует позиции (uploadedAt, id) при упорядочивании по возрастанию
+func orderPrecedes(order models.Order, uploadedAt time.Time, id int64) bool {
+	if !order.UploadedAt.Equal(uploadedAt) {
+		return order.UploadedAt.Before(uploadedAt)
+	}
+	return order.ID < id
+}
+
+// Упорядочивание заказов по времени загрузки, а при равном времени — по порядку регистрации
+func sortOrders(orders []models.Order) {
+	sort.Slice(orders, func(i, j int) bool {
+		return orderPrecedes(orders[i], orders[j].UploadedAt, orders[j].ID)
+	})
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Выбор для доставки не более limit недоставленных событий в порядке их записи.
+// Выбираются события только тех пользователей, время попытки первого недоставленного
+// события которых наступило к now. Выбранные события закрепляются до leaseUntil.
+func (m *memstorage) ClaimOutboxEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// В outbox хранятся только недоставленные события, поэтому первое
+	// событие пользователя в списке является его первым недоставленным
+	due := map[int64]bool{}
+	for _, event := range m.outbox {
+		if _, ok := due[event.UserID]; !ok {
+			due[event.UserID] = !event.NextAttemptAt.After(now)
+		}
+	}
+
+	events := []models.OutboxEvent{}
+	for i := range m.outbox {
+		if len(events) == limit {
+			break
+		}
+		if !due[m.outbox[i].UserID] {
+			continue
+		}
+		m.outbox[i].NextAttemptAt = leaseUntil
+		events = append(events, m.outbox[i])
+	}
+
+	return events, nil
+}
+
+// Сохранение результатов доставки. Доставленные события и события,
+// доставка которых прекращена, удаляются из outbox.
+func (m *memstorage) CompleteOutboxEvents(ctx context.Context, deliveries []models.OutboxDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byID := make(map[int64]models.OutboxDelivery, len(deliveries))
+	for _, delivery := range deliveries {
+		byID[delivery.EventID] = delivery
+	}
+
+	pending := m.outbox[:0]
+	for _, event := range m.outbox {
+		delivery, ok := byID[event.ID]
+		if ok {
+			if delivery.Status != models.OutboxEventPending {
+				continue
+			}
+			if delivery.Error != "" {
+				event.Attempts++
+			}
+			event.NextAttemptAt = delivery.NextAttemptAt
+		}
+		pending = append(pending, event)
+	}
+	m.outbox = pending
+
+	return nil
+}
+
+// Вызывается под блокировкой на запись.
+func (m *memstorage) appendOutboxEvent(event models.OutboxEvent) {
+	m.lastOutboxEventID++
+	event.ID = m.lastOutboxEventID
+	event.CreatedAt = time.Now()
+	event.NextAttemptAt = event.CreatedAt
+	m.outbox = append(m.outbox, event)
+}
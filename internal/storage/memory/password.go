@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) AddPasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[token.UserID]; !ok {
+		return fmt.Errorf("memory.addPasswordResetToken: %w", appErrors.ErrUserNotFound)
+	}
+
+	m.lastResetTokenID++
+	token.ID = m.lastResetTokenID
+	token.CreatedAt = time.Now()
+
+	dbToken := *token
+	m.passwordResetTokens[token.TokenHash] = &dbToken
+	return nil
+}
+
+func (m *memstorage) GetUserByPasswordResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	token, ok := m.validPasswordResetToken(tokenHash, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("memory.getUserByPasswordResetToken: %w", appErrors.ErrPasswordResetTokenInvalid)
+	}
+
+	dbUser := *m.users[token.UserID]
+	return &dbUser, nil
+}
+
+func (m *memstorage) ResetUserPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	token, ok := m.validPasswordResetToken(tokenHash, now)
+	if !ok {
+		return -1, fmt.Errorf("memory.resetUserPassword: %w", appErrors.ErrPasswordResetTokenInvalid)
+	}
+
+	m.setUserPassword(token.UserID, passwordHash, now)
+	return token.UserID, nil
+}
+
+// Неиспользованный и неистекший токен сброса пароля. Вызывается под блокировкой.
+func (m *memstorage) validPasswordResetToken(tokenHash string, now time.Time) (*models.PasswordResetToken, bool) {
+	token, ok := m.passwordResetTokens[tokenHash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, false
+	}
+	return token, true
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) AddRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addRefreshToken(token)
+	return nil
+}
+
+func (m *memstorage) RotateRefreshToken(ctx context.Context, tokenHash string, newToken *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	dbToken, ok := m.refreshTokens[tokenHash]
+	if !ok || !dbToken.ExpiresAt.After(now) {
+		return fmt.Errorf("memory.rotateRefreshToken: %w", appErrors.ErrRefreshTokenInvalid)
+	}
+
+	// Повторное использование токена: отзывается все семейство
+	if dbToken.UsedAt != nil || dbToken.RevokedAt != nil {
+		m.revokeRefreshTokenFamily(dbToken.FamilyID, now)
+		return fmt.Errorf("memory.rotateRefreshToken: %w", appErrors.ErrRefreshTokenReused)
+	}
+
+	dbToken.UsedAt = &now
+	newToken.UserID = dbToken.UserID
+	newToken.FamilyID = dbToken.FamilyID
+	m.addRefreshToken(newToken)
+
+	return nil
+}
+
+func (m *memstorage) RevokeRefreshTokenFamily(ctx context.Context, userID int64, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dbToken, ok := m.refreshTokens[tokenHash]
+	if !ok || dbToken.UserID != userID {
+		return fmt.Errorf("memory.revokeRefreshTokenFamily: %w", appErrors.ErrRefreshTokenInvalid)
+	}
+
+	m.revokeRefreshTokenFamily(dbToken.FamilyID, time.Now())
+	return nil
+}
+
+func (m *memstorage) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Удаление записей об истекших токенах: они отклоняются и без списка отзыва
+	now := time.Now()
+	for id, exp := range m.revokedTokens {
+		if exp.Before(now) {
+			delete(m.revokedTokens, id)
+		}
+	}
+
+	m.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+func (m *memstorage) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.revokedTokens[tokenID]
+	return ok, nil
+}
+
+// Добавление refresh токена. Вызывается под блокировкой на запись.
+func (m *memstorage) addRefreshToken(token *models.RefreshToken) {
+	m.lastRefreshTokenID++
+	token.ID = m.lastRefreshTokenID
+	token.CreatedAt = time.Now()
+
+	dbToken := *token
+	m.refreshTokens[token.TokenHash] = &dbToken
+}
+
+// Отзыв всех токенов семейства. Вызывается под блокировкой на запись.
+func (m *memstorage) revokeRefreshTokenFamily(familyID string, now time.Time) {
+	for _, token := range m.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) AddUser(ctx context.Context, login, passwordHash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.logins[login]; ok {
+		return -1, fmt.Errorf("memory.addUser: %w", appErrors.ErrUserLoginAlreadyExists)
+	}
+
+	m.lastUserID++
+	id := m.lastUserID
+	m.users[id] = &models.User{
+		ID:       id,
+		Login:    login,
+		Password: passwordHash,
+	}
+	m.logins[login] = id
+
+	m.lastBalanceID++
+	m.balances[id] = &models.Balance{
+		ID:     m.lastBalanceID,
+		UserID: id,
+	}
+
+	return id, nil
+}
+
+func (m *memstorage) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.logins[login]
+	if !ok {
+		return nil, fmt.Errorf("memory.getUserByLogin: %w", appErrors.ErrUserNotFound)
+	}
+
+	dbUser := *m.users[id]
+	return &dbUser, nil
+}
+
+func (m *memstorage) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("memory.getUserByID: %w", appErrors.ErrUserNotFound)
+	}
+
+	dbUser := *user
+	return &dbUser, nil
+}
+
+func (m *memstorage) UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return fmt.Errorf("memory.updateUserPassword: %w", appErrors.ErrUserNotFound)
+	}
+
+	m.setUserPassword(userID, passwordHash, time.Now())
+	return nil
+}
+
+func (m *memstorage) RehashUserPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return fmt.Errorf("memory.rehashUserPassword: %w", appErrors.ErrUserNotFound)
+	}
+
+	// Пароль мог быть изменен после проверки: новый хеш не сохраняется
+	if user.Password == oldHash {
+		user.Password = newHash
+	}
+	return nil
+}
+
+// Установка нового пароля: выданные ранее access токены, refresh токены
+// и токены сброса пароля пользователя перестают приниматься.
+// Вызывается под блокировкой на запись.
+func (m *memstorage) setUserPassword(userID int64, passwordHash string, now time.Time) {
+	user := m.users[userID]
+	user.Password = passwordHash
+	user.TokenVersion++
+
+	for _, token := range m.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	for _, token := range m.passwordResetTokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (m *memstorage) AddWebhook(ctx context.Context, webhook *models.Webhook, maxWebhooks int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[webhook.UserID]; !ok {
+		return fmt.Errorf("memory.addWebhook: %w", appErrors.ErrUserNotFound)
+	}
+
+	count := 0
+	for _, w := range m.webhooks {
+		if w.UserID == webhook.UserID {
+			count++
+		}
+	}
+	if count >= maxWebhooks {
+		return appErrors.ErrTooManyWebhooks
+	}
+
+	m.lastWebhookID++
+	webhook.ID = m.lastWebhookID
+	webhook.CreatedAt = time.Now()
+	dbWebhook := *webhook
+	m.webhooks[webhook.ID] = &dbWebhook
+
+	return nil
+}
+
+func (m *memstorage) GetWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	webhooks := []models.Webhook{}
+	for _, webhook := range m.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+func (m *memstorage) GetWebhook(ctx context.Context, webhookID int64) (*models.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	webhook, ok := m.webhooks[webhookID]
+	if !ok {
+		return nil, fmt.Errorf("memory.getWebhook: %w", appErrors.ErrWebhookNotFound)
+	}
+
+	dbWebhook := *webhook
+	return &dbWebhook, nil
+}
+
+func (m *memstorage) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[webhookID]; !ok {
+		return fmt.Errorf("memory.deleteWebhook: %w", appErrors.ErrWebhookNotFound)
+	}
+	delete(m.webhooks, webhookID)
+	m.webhookDeliveries = slices.DeleteFunc(m.webhookDeliveries, func(d *models.WebhookDelivery) bool {
+		return d.WebhookID == webhookID
+	})
+
+	return nil
+}
+
+func (m *memstorage) AddWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queued := map[int64]bool{}
+	for _, d := range m.webhookDeliveries {
+		if d.EventID == event.ID {
+			queued[d.WebhookID] = true
+		}
+	}
+
+	webhookIDs := []int64{}
+	for _, webhook := range m.webhooks {
+		if webhook.UserID == event.UserID && !queued[webhook.ID] {
+			webhookIDs = append(webhookIDs, webhook.ID)
+		}
+	}
+	slices.Sort(webhookIDs)
+
+	now := time.Now()
+	for _, webhookID := range webhookIDs {
+		m.lastWebhookDeliveryID++
+		m.webhookDeliveries = append(m.webhookDeliveries, &models.WebhookDelivery{
+			ID:            m.lastWebhookDeliveryID,
+			WebhookID:     webhookID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       event.Payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return nil
+}
+
+// Выбор для отправки не более limit ожидающих доставок, время попытки которых
+// наступило к now. Выбранные доставки закрепляются до leaseUntil.
+func (m *memstorage) ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := []*models.WebhookDelivery{}
+	for _, d := range m.webhookDeliveries {
+		if d.Status == models.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	due = due[:min(limit, len(due))]
+
+	deliveries := make([]models.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		delivery := *d
+		delivery.URL = m.webhooks[d.WebhookID].URL
+		delivery.Secret = m.webhooks[d.WebhookID].Secret
+		deliveries = append(deliveries, delivery)
+		d.NextAttemptAt = leaseUntil
+	}
+
+	return deliveries, nil
+}
+
+func (m *memstorage) CompleteWebhookDeliveries(ctx context.Context, results []models.WebhookDeliveryResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byID := make(map[int64]models.WebhookDeliveryResult, len(results))
+	for _, result := range results {
+		byID[result.DeliveryID] = result
+	}
+	// Доставки удаленных во время отправки вебхуков уже отсутствуют в списке
+	for _, d := range m.webhookDeliveries {
+		result, ok := byID[d.ID]
+		if !ok {
+			continue
+		}
+		d.Status = result.Status
+		d.Attempts++
+		d.ResponseCode = result.ResponseCode
+		d.LastError = result.Error
+		switch result.Status {
+		case models.WebhookDeliveryPending:
+			d.NextAttemptAt = result.NextAttemptAt
+		case models.WebhookDeliveryDelivered:
+			deliveredAt := time.Now()
+			d.DeliveredAt = &deliveredAt
+		}
+	}
+
+	return nil
+}
+
+func (m *memstorage) GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(m.webhookDeliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if d := m.webhookDeliveries[i]; d.WebhookID == webhookID {
+			deliveries = append(deliveries, *d)
+		}
+	}
+
+	return deliveries, nil
+}
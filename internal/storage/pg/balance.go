@@ -48,8 +48,62 @@ func (pg *pgstorage) GetWithdrawalsByUser(ctx context.Context, userID int64) ([]
 	return dbWithdrawal, nil
 }
 
+func (pg *pgstorage) GetWithdrawalsPageByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) ([]models.Withdrawal, error) {
+	where := withdrawalsFilter(userID, query)
+
+	// Позиция задается парой (processed_at, id), что соответствует индексу withdrawals_user_id_processed_at_idx
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		where.add("(processed_at, id) "+comparison+" (%s, %s)", query.After.ProcessedAt.UTC(), query.After.ID)
+	}
+
+	limit := where.arg(query.Limit)
+	rows, err := pg.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, order_number, processed_at, sum
+		FROM withdrawals
+		WHERE %s
+		ORDER BY processed_at %s, id %s
+		LIMIT %s;`, where, direction, direction, limit), where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getWithdrawalsPageByUser.selectWithdrawals: %w", err)
+	}
+	defer rows.Close()
+
+	withdrawals := []models.Withdrawal{}
+	for rows.Next() {
+		withdrawal := models.Withdrawal{UserID: userID}
+		err := rows.Scan(&withdrawal.ID, &withdrawal.Order, &withdrawal.ProcessedAt, &withdrawal.Sum)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getWithdrawalsPageByUser.scanWithdrawal: %w", err)
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getWithdrawalsPageByUser.err: %w", err)
+	}
+
+	return withdrawals, nil
+}
+
+func (pg *pgstorage) GetWithdrawalsSummaryByUser(ctx context.Context, userID int64, query *models.WithdrawalsQuery) (*models.WithdrawalsSummary, error) {
+	where := withdrawalsFilter(userID, query)
+	row := pg.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(SUM(sum), 0), COUNT(*)
+		FROM withdrawals
+		WHERE %s;`, where), where.args...)
+
+	summary := &models.WithdrawalsSummary{}
+	if err := row.Scan(&summary.Total, &summary.Count); err != nil {
+		return nil, fmt.Errorf("pg.getWithdrawalsSummaryByUser.scanSummary: %w", err)
+	}
+	return summary, nil
+}
+
 func (pg *pgstorage) WithdrawFromUserBalance(ctx context.Context, userID int64, orderNumber string, sum models.Money) error {
-	tx, err := pg.db.Begin()
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("pg.withdrawFromUserBalance.beginTx: %w", err)
 	}
@@ -64,7 +118,7 @@ func (pg *pgstorage) WithdrawFromUserBalance(ctx context.Context, userID int64,
 	if err != nil {
 		return fmt.Errorf("pg.withdrawFromUserBalance.updateBalance: %w", err)
 	}
-	if newBalance < 0 {
+	if newBalance.IsNegative() {
 		return appErrors.ErrNegativeBalance
 	}
 
@@ -75,9 +129,38 @@ func (pg *pgstorage) WithdrawFromUserBalance(ctx context.Context, userID int64,
 		return fmt.Errorf("pg.withdrawFromUserBalance.insertWithdrawal: %w", err)
 	}
 
+	err = insertLedgerEntry(ctx, tx, models.NewWithdrawalEntry(userID, orderNumber, sum))
+	if err != nil {
+		return fmt.Errorf("pg.withdrawFromUserBalance.insertLedgerEntry: %w", err)
+	}
+
+	err = insertOutboxEvent(ctx, tx, models.NewWithdrawalEvent(userID, orderNumber, sum))
+	if err != nil {
+		return fmt.Errorf("pg.withdrawFromUserBalance.insertOutboxEvent: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("pg.withdrawFromUserBalance.commit: %w", err)
 	}
 
 	return nil
 }
+
+// Условия выборки списаний пользователя без учета позиции
+func withdrawalsFilter(userID int64, query *models.WithdrawalsQuery) *whereBuilder {
+	where := &whereBuilder{}
+	where.add("user_id=%s", userID)
+	if query.ProcessedFrom != nil {
+		where.add("processed_at >= %s", query.ProcessedFrom.UTC())
+	}
+	if query.ProcessedTo != nil {
+		where.add("processed_at < %s", query.ProcessedTo.UTC())
+	}
+	if query.MinSum != nil {
+		where.add("sum >= %s", *query.MinSum)
+	}
+	if query.MaxSum != nil {
+		where.add("sum <= %s", *query.MaxSum)
+	}
+	return where
+}
@@ -0,0 +1,88 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (pg *pgstorage) GetLedgerByUser(ctx context.Context, userID int64) ([]models.LedgerEntry, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT id, kind, debit_account, credit_account, amount, COALESCE(order_number, ''), created_at
+		FROM ledger_entries
+		WHERE user_id=$1
+		ORDER BY id;`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getLedgerByUser.selectEntries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.LedgerEntry{}
+	for rows.Next() {
+		entry := models.LedgerEntry{UserID: userID}
+		err := rows.Scan(&entry.ID, &entry.Kind, &entry.DebitAccount, &entry.CreditAccount,
+			&entry.Amount, &entry.OrderNumber, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getLedgerByUser.scanEntry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getLedgerByUser.err: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (pg *pgstorage) GetBalanceDiscrepancies(ctx context.Context) ([]models.BalanceDiscrepancy, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT b.user_id, b.current, b.withdrawn, COALESCE(l.current, 0), COALESCE(l.withdrawn, 0)
+		FROM balances b
+		LEFT JOIN (
+			SELECT user_id,
+				SUM(CASE WHEN credit_account = 'user' THEN amount ELSE -amount END) AS current,
+				SUM(CASE
+					WHEN credit_account = 'withdrawals' THEN amount
+					WHEN debit_account = 'withdrawals' THEN -amount
+					ELSE 0
+				END) AS withdrawn
+			FROM ledger_entries
+			GROUP BY user_id
+		) l ON l.user_id = b.user_id
+		WHERE b.current <> COALESCE(l.current, 0) OR b.withdrawn <> COALESCE(l.withdrawn, 0)
+		ORDER BY b.user_id;`)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getBalanceDiscrepancies.select: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []models.BalanceDiscrepancy{}
+	for rows.Next() {
+		d := models.BalanceDiscrepancy{}
+		err := rows.Scan(&d.UserID, &d.Balance.Current, &d.Balance.Withdrawn, &d.Ledger.Current, &d.Ledger.Withdrawn)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getBalanceDiscrepancies.scan: %w", err)
+		}
+		d.Balance.UserID, d.Ledger.UserID = d.UserID, d.UserID
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getBalanceDiscrepancies.err: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// Добавление проводки в журнал в рамках транзакции
+func insertLedgerEntry(ctx context.Context, tx *sql.Tx, entry models.LedgerEntry) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_entries (user_id, kind, debit_account, credit_account, amount, order_number)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''));`,
+		entry.UserID, entry.Kind, entry.DebitAccount, entry.CreditAccount, entry.Amount, entry.OrderNumber)
+	if err != nil {
+		return fmt.Errorf("pg.insertLedgerEntry: %w", err)
+	}
+	return nil
+}
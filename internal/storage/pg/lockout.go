@@ -0,0 +1,47 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (pg *pgstorage) AddLoginLockout(ctx context.Context, lockout models.LoginLockout) error {
+	_, err := pg.db.ExecContext(ctx, `
+		INSERT INTO login_lockouts (reason, subject_type, subject, failures, locked_until)
+		VALUES ($1, $2, $3, $4, $5);`,
+		lockout.Reason, lockout.SubjectType, lockout.Subject, lockout.Failures, lockout.LockedUntil)
+	if err != nil {
+		return fmt.Errorf("pg.addLoginLockout: %w", err)
+	}
+	return nil
+}
+
+func (pg *pgstorage) GetLoginLockouts(ctx context.Context, subjectType models.LoginLockoutSubject, subject string) ([]models.LoginLockout, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT id, reason, subject_type, subject, failures, locked_until, created_at
+		FROM login_lockouts
+		WHERE subject_type=$1 AND subject=$2
+		ORDER BY id;`, subjectType, subject)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getLoginLockouts.selectLockouts: %w", err)
+	}
+	defer rows.Close()
+
+	lockouts := []models.LoginLockout{}
+	for rows.Next() {
+		lockout := models.LoginLockout{}
+		err := rows.Scan(&lockout.ID, &lockout.Reason, &lockout.SubjectType, &lockout.Subject, &lockout.Failures,
+			&lockout.LockedUntil, &lockout.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getLoginLockouts.scanLockout: %w", err)
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getLoginLockouts.err: %w", err)
+	}
+
+	return lockouts, nil
+}
@@ -0,0 +1,297 @@
+// Package migrations содержит версионированные миграции схемы БД и механизм их применения.
+//
+// Миграции хранятся во встроенной директории sql в виде пар файлов
+// <версия>_<название>.up.sql и <версия>_<название>.down.sql и применяются строго
+// по возрастанию версии. Примененные версии фиксируются в таблице schema_migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Идентификатор advisory lock'а, защищающего от одновременного применения миграций
+// несколькими экземплярами сервиса
+const advisoryLockID int64 = 3_817_420_615
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type (
+	Migration struct {
+		Version int64
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	// Состояние миграции в БД
+	Status struct {
+		Version   int64
+		Name      string
+		AppliedAt *time.Time
+	}
+
+	Migrator struct {
+		db         *sql.DB
+		migrations []Migration
+	}
+)
+
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(sqlFiles)
+	if err != nil {
+		return nil, fmt.Errorf("migrations.new: %w", err)
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Применение всех еще не примененных миграций
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("migrations.up: %w", err)
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO schema_migrations (version, name)
+					VALUES ($1, $2);`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrations.up.%d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Info().
+				Int64("version", migration.Version).
+				Str("name", migration.Name).
+				Msg("migration applied")
+		}
+
+		return nil
+	})
+}
+
+// Откат последней примененной миграции
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("migrations.down: %w", err)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `
+					DELETE FROM schema_migrations
+					WHERE version=$1;`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrations.down.%d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Info().
+				Int64("version", migration.Version).
+				Str("name", migration.Name).
+				Msg("migration rolled back")
+			return nil
+		}
+
+		log.Info().Msg("no applied migrations to roll back")
+		return nil
+	})
+}
+
+// Получение состояния всех известных миграций
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			status := Status{
+				Version: migration.Version,
+				Name:    migration.Name,
+			}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrations.status: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// Выполнение функции на выделенном соединении под advisory lock'ом
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrations.withLock.conn: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, advisoryLockID); err != nil {
+		return fmt.Errorf("migrations.withLock.lock: %w", err)
+	}
+	defer func() {
+		// Используется отдельный контекст, чтобы снять блокировку даже после отмены ctx
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1);`, advisoryLockID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release migrations advisory lock")
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    bigint    PRIMARY KEY,
+			name       varchar   NOT NULL,
+			applied_at timestamp NOT NULL DEFAULT NOW()
+		);`); err != nil {
+		return fmt.Errorf("migrations.withLock.createTable: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// Получение примененных версий и времени их применения
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version, applied_at
+		FROM schema_migrations;`)
+	if err != nil {
+		return nil, fmt.Errorf("migrations.appliedVersions.select: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("migrations.appliedVersions.scan: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("migrations.appliedVersions.err: %w", err)
+	}
+
+	return applied, nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Загрузка миграций из файловой системы
+func load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("migrations.load.glob: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		match := fileNameRe.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("migrations.load: invalid migration file name %q", file)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations.load.parseVersion: %w", err)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("migrations.load.readFile: %w", err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations.load: version %d has different names: %q and %q",
+				version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migrations.load: version %d must have both up and down scripts", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_Load(t *testing.T) {
+	tests := []struct {
+		name               string
+		fsys               fstest.MapFS
+		expectedVersions   []int64
+		expectedErrContain string
+	}{
+		{
+			name: "Success Case",
+			fsys: fstest.MapFS{
+				"sql/0002_second.up.sql":   {Data: []byte("SELECT 2;")},
+				"sql/0002_second.down.sql": {Data: []byte("SELECT -2;")},
+				"sql/0001_first.up.sql":    {Data: []byte("SELECT 1;")},
+				"sql/0001_first.down.sql":  {Data: []byte("SELECT -1;")},
+			},
+			expectedVersions: []int64{1, 2},
+		},
+		{
+			name: "Missing down script Case",
+			fsys: fstest.MapFS{
+				"sql/0001_first.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectedErrContain: "must have both up and down scripts",
+		},
+		{
+			name: "Invalid file name Case",
+			fsys: fstest.MapFS{
+				"sql/first.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectedErrContain: "invalid migration file name",
+		},
+		{
+			name: "Different names for one version Case",
+			fsys: fstest.MapFS{
+				"sql/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+				"sql/0001_other.down.sql": {Data: []byte("SELECT -1;")},
+			},
+			expectedErrContain: "has different names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.fsys)
+
+			if tt.expectedErrContain != "" {
+				assert.ErrorContains(t, err, tt.expectedErrContain)
+				return
+			}
+
+			require.NoError(t, err)
+			versions := make([]int64, 0, len(migrations))
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, tt.expectedVersions, versions)
+		})
+	}
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := load(sqlFiles)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// Версии встроенных миграций идут подряд, начиная с 1
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+	}
+}
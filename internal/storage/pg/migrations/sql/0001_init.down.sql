@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS balances;
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS users;
+DROP TYPE IF EXISTS order_status;
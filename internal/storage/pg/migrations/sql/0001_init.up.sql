@@ -0,0 +1,39 @@
+DO $$ BEGIN
+	CREATE TYPE order_status AS ENUM ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED');
+EXCEPTION
+	WHEN duplicate_object THEN null;
+END $$;
+
+CREATE TABLE IF NOT EXISTS users
+(
+	id       bigint  PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	login    varchar NOT NULL UNIQUE,
+	password varchar NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orders
+(
+	id          bigint  PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	number      varchar NOT NULL UNIQUE,
+	user_id     bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	status      order_status NOT NULL,
+	accrual     double precision NOT NULL DEFAULT 0,
+	uploaded_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS withdrawals
+(
+	id           bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id      bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	order_number varchar NOT NULL UNIQUE,
+	processed_at timestamp NOT NULL DEFAULT NOW(),
+	sum          double precision NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS balances
+(
+	id        bigint  PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id   bigint NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+	withdrawn double precision NOT NULL DEFAULT 0,
+	current   double precision NOT NULL DEFAULT 0
+);
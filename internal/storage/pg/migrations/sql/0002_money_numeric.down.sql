@@ -0,0 +1,9 @@
+ALTER TABLE orders
+	ALTER COLUMN accrual TYPE double precision USING accrual::double precision;
+
+ALTER TABLE withdrawals
+	ALTER COLUMN sum TYPE double precision USING sum::double precision;
+
+ALTER TABLE balances
+	ALTER COLUMN withdrawn TYPE double precision USING withdrawn::double precision,
+	ALTER COLUMN current TYPE double precision USING current::double precision;
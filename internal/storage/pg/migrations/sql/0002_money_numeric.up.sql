@@ -0,0 +1,9 @@
+ALTER TABLE orders
+	ALTER COLUMN accrual TYPE numeric(16, 2) USING round(accrual::numeric, 2);
+
+ALTER TABLE withdrawals
+	ALTER COLUMN sum TYPE numeric(16, 2) USING round(sum::numeric, 2);
+
+ALTER TABLE balances
+	ALTER COLUMN withdrawn TYPE numeric(16, 2) USING round(withdrawn::numeric, 2),
+	ALTER COLUMN current TYPE numeric(16, 2) USING round(current::numeric, 2);
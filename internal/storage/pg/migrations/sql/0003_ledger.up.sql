@@ -0,0 +1,42 @@
+CREATE TABLE ledger_entries
+(
+	id             bigint  PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id        bigint  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	kind           varchar NOT NULL CHECK (kind IN ('ACCRUAL', 'WITHDRAWAL', 'ADJUSTMENT', 'REVERSAL')),
+	debit_account  varchar NOT NULL,
+	credit_account varchar NOT NULL,
+	amount         numeric(16, 2) NOT NULL CHECK (amount > 0),
+	order_number   varchar,
+	created_at     timestamp NOT NULL DEFAULT NOW(),
+	CHECK (debit_account <> credit_account),
+	CHECK (debit_account = 'user' OR credit_account = 'user')
+);
+
+CREATE INDEX ledger_entries_user_id_idx ON ledger_entries (user_id, id);
+
+-- Перенос истории начислений и списаний в журнал
+INSERT INTO ledger_entries (user_id, kind, debit_account, credit_account, amount, order_number, created_at)
+SELECT user_id, 'ACCRUAL', 'accruals', 'user', accrual, number, uploaded_at
+FROM orders
+WHERE status = 'PROCESSED' AND accrual > 0;
+
+INSERT INTO ledger_entries (user_id, kind, debit_account, credit_account, amount, order_number, created_at)
+SELECT user_id, 'WITHDRAWAL', 'user', 'withdrawals', sum, order_number, processed_at
+FROM withdrawals
+WHERE sum > 0;
+
+-- Входящие корректировки для балансов, не совпадающих с перенесенной историей
+INSERT INTO ledger_entries (user_id, kind, debit_account, credit_account, amount)
+SELECT d.user_id,
+	'ADJUSTMENT',
+	CASE WHEN d.diff > 0 THEN 'adjustments' ELSE 'user' END,
+	CASE WHEN d.diff > 0 THEN 'user' ELSE 'adjustments' END,
+	abs(d.diff)
+FROM (
+	SELECT b.user_id, b.current - COALESCE(SUM(
+		CASE WHEN l.credit_account = 'user' THEN l.amount ELSE -l.amount END), 0) AS diff
+	FROM balances b
+	LEFT JOIN ledger_entries l ON l.user_id = b.user_id
+	GROUP BY b.user_id, b.current
+) d
+WHERE d.diff <> 0;
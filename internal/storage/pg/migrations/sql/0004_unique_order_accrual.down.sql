@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ledger_entries_accrual_order_idx;
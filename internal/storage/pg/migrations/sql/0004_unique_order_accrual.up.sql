@@ -0,0 +1,4 @@
+-- Начисление за каждый заказ может быть проведено только один раз
+CREATE UNIQUE INDEX ledger_entries_accrual_order_idx
+	ON ledger_entries (order_number)
+	WHERE kind = 'ACCRUAL';
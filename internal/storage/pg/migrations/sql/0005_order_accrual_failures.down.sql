@@ -0,0 +1,3 @@
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS accrual_failures,
+	DROP COLUMN IF EXISTS last_accrual_error;
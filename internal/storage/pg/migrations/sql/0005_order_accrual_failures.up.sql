@@ -0,0 +1,3 @@
+ALTER TABLE orders
+	ADD COLUMN accrual_failures   integer NOT NULL DEFAULT 0,
+	ADD COLUMN last_accrual_error text;
@@ -0,0 +1,20 @@
+CREATE TABLE refresh_tokens
+(
+	id         bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id    bigint      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	family_id  varchar     NOT NULL,
+	token_hash varchar     NOT NULL UNIQUE,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	used_at    timestamptz,
+	revoked_at timestamptz
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- Отозванные до истечения срока действия access токены
+CREATE TABLE revoked_tokens
+(
+	token_id   varchar     PRIMARY KEY,
+	expires_at timestamptz NOT NULL
+);
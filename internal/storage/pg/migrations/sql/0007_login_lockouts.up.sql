@@ -0,0 +1,12 @@
+-- Журнал блокировок входа из-за перебора паролей
+CREATE TABLE login_lockouts
+(
+	id           bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	subject_type varchar     NOT NULL CHECK (subject_type IN ('login', 'ip')),
+	subject      varchar     NOT NULL,
+	failures     integer     NOT NULL,
+	locked_until timestamptz NOT NULL,
+	created_at   timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX login_lockouts_subject_idx ON login_lockouts (subject_type, subject, id);
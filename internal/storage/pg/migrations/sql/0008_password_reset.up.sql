@@ -0,0 +1,16 @@
+-- Версия токенов пользователя: увеличивается при смене пароля,
+-- после чего выданные ранее access токены не принимаются
+ALTER TABLE users ADD COLUMN token_version bigint NOT NULL DEFAULT 0;
+
+-- Одноразовые токены сброса пароля
+CREATE TABLE password_reset_tokens
+(
+	id         bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id    bigint      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash varchar     NOT NULL UNIQUE,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	used_at    timestamptz
+);
+
+CREATE INDEX password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
@@ -0,0 +1,3 @@
+-- Индексы для постраничной выборки заказов пользователя по времени загрузки
+CREATE INDEX orders_user_id_uploaded_at_idx ON orders (user_id, uploaded_at, id);
+CREATE INDEX orders_user_id_status_uploaded_at_idx ON orders (user_id, status, uploaded_at, id);
@@ -0,0 +1,2 @@
+-- Индекс для постраничной выборки списаний пользователя по времени обработки
+CREATE INDEX withdrawals_user_id_processed_at_idx ON withdrawals (user_id, processed_at, id);
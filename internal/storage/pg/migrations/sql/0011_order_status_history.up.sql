@@ -0,0 +1,21 @@
+-- История смены статусов заказов
+CREATE TABLE order_status_history
+(
+	id         bigint       PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id   bigint       NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+	status     order_status NOT NULL,
+	changed_at timestamp    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id, id);
+
+-- Для существующих заказов известно только время загрузки. Текущий статус,
+-- если он отличается от NEW, записывается со временем применения миграции.
+INSERT INTO order_status_history (order_id, status, changed_at)
+SELECT id, 'NEW', uploaded_at
+FROM orders;
+
+INSERT INTO order_status_history (order_id, status)
+SELECT id, status
+FROM orders
+WHERE status<>'NEW';
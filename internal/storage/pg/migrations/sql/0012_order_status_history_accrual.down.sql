@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS order_status_history_changed_at_idx;
+ALTER TABLE order_status_history DROP COLUMN IF EXISTS accrual_status;
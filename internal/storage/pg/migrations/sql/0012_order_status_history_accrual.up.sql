@@ -0,0 +1,5 @@
+-- Статус, полученный от системы расчета начислений при смене статуса заказа
+ALTER TABLE order_status_history ADD COLUMN accrual_status varchar;
+
+-- Индекс для выборки переходов за период
+CREATE INDEX order_status_history_changed_at_idx ON order_status_history (changed_at);
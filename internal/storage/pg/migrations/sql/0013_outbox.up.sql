@@ -0,0 +1,15 @@
+-- События для внешних систем, записываемые в транзакции изменения
+CREATE TABLE outbox_events
+(
+	id           bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id      bigint      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	event_type   varchar     NOT NULL,
+	payload      jsonb       NOT NULL,
+	created_at   timestamptz NOT NULL DEFAULT NOW(),
+	delivered_at timestamptz,
+	attempts     integer     NOT NULL DEFAULT 0,
+	last_error   varchar
+);
+
+-- Индекс для выборки недоставленных событий в порядке записи
+CREATE INDEX outbox_events_pending_idx ON outbox_events (id) WHERE delivered_at IS NULL;
@@ -0,0 +1,31 @@
+CREATE TABLE webhooks
+(
+	id         bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id    bigint      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	url        varchar     NOT NULL,
+	secret     varchar     NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+
+-- Доставки событий outbox на вебхуки пользователя
+CREATE TABLE webhook_deliveries
+(
+	id              bigint      PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	webhook_id      bigint      NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event_id        bigint      NOT NULL,
+	event_type      varchar     NOT NULL,
+	payload         jsonb       NOT NULL,
+	status          varchar     NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+	attempts        integer     NOT NULL DEFAULT 0,
+	response_code   integer,
+	last_error      varchar,
+	next_attempt_at timestamptz NOT NULL DEFAULT NOW(),
+	created_at      timestamptz NOT NULL DEFAULT NOW(),
+	delivered_at    timestamptz,
+	UNIQUE (webhook_id, event_id)
+);
+
+-- Индекс для выборки доставок, время попытки которых наступило
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,8 @@
+-- Время следующей попытки доставки события и прекращение доставки после исчерпания попыток
+ALTER TABLE outbox_events
+	ADD COLUMN next_attempt_at timestamptz NOT NULL DEFAULT NOW(),
+	ADD COLUMN dead_at         timestamptz;
+
+-- Индекс для выборки первого недоставленного события каждого пользователя
+DROP INDEX IF EXISTS outbox_events_pending_idx;
+CREATE INDEX outbox_events_pending_idx ON outbox_events (user_id, id) WHERE delivered_at IS NULL AND dead_at IS NULL;
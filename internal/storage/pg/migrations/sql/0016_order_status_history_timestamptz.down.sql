@@ -0,0 +1,4 @@
+ALTER TABLE ledger_entries ALTER COLUMN created_at TYPE timestamp;
+ALTER TABLE withdrawals ALTER COLUMN processed_at TYPE timestamp;
+ALTER TABLE orders ALTER COLUMN uploaded_at TYPE timestamp;
+ALTER TABLE order_status_history ALTER COLUMN changed_at TYPE timestamp;
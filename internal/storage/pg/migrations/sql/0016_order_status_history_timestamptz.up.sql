@@ -0,0 +1,6 @@
+-- Время в таблицах с NOW() хранится с часовым поясом, чтобы не зависеть от часового пояса сессии.
+-- Сохраненные значения записаны в часовом поясе сессии и преобразуются в нем же.
+ALTER TABLE order_status_history ALTER COLUMN changed_at TYPE timestamptz;
+ALTER TABLE orders ALTER COLUMN uploaded_at TYPE timestamptz;
+ALTER TABLE withdrawals ALTER COLUMN processed_at TYPE timestamptz;
+ALTER TABLE ledger_entries ALTER COLUMN created_at TYPE timestamptz;
@@ -0,0 +1 @@
+ALTER TABLE login_lockouts DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,3 @@
+-- Причина блокировки: неудачные попытки входа или запросы на сброс пароля
+ALTER TABLE login_lockouts
+	ADD COLUMN reason varchar NOT NULL DEFAULT 'login' CHECK (reason IN ('login', 'password_reset'));
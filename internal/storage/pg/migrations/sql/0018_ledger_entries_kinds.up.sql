@@ -0,0 +1,4 @@
+-- Сторнирующие проводки не создаются, допускаются только используемые виды проводок
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_kind_check;
+ALTER TABLE ledger_entries
+	ADD CONSTRAINT ledger_entries_kind_check CHECK (kind IN ('ACCRUAL', 'WITHDRAWAL', 'ADJUSTMENT'));
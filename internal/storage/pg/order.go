@@ -2,40 +2,173 @@ package pg
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
 )
 
 func (pg *pgstorage) RegisterOrder(ctx context.Context, userID int64, orderNumber string) error {
-	_, err := pg.db.ExecContext(ctx, `
-		INSERT INTO orders (user_id, number, status)
-		VALUES ($1, $2, $3);`, userID, orderNumber, "NEW")
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.registerOrder.beginTx: %w", err)
+	}
+	defer tx.Rollback()
 
+	status, err := registerOrder(ctx, tx, userID, orderNumber)
 	if err != nil {
-		if pgError, ok := err.(*pgconn.PgError); ok &&
-			pgerrcode.IsIntegrityConstraintViolation(pgError.Code) &&
-			pgError.ConstraintName == "orders_number_key" {
+		return fmt.Errorf("pg.registerOrder: %w", err)
+	}
+	switch status {
+	case models.OrderUploadAlreadyUploaded:
+		return appErrors.ErrOrderWasUploadedByCurrentUser
+	case models.OrderUploadConflict:
+		return appErrors.ErrOrderWasUploadedByAnotherUser
+	}
 
-			existingOrder, err := pg.getOrderByNumber(ctx, orderNumber)
-			if err != nil {
-				return fmt.Errorf(`pg.registerOrder: %w`, err)
-			}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.registerOrder.commit: %w", err)
+	}
 
-			if existingOrder.UserID == userID {
-				return appErrors.ErrOrderWasUploadedByCurrentUser
-			} else {
-				return appErrors.ErrOrderWasUploadedByAnotherUser
-			}
+	return nil
+}
+
+// Загрузка пакета номеров заказов в одной транзакции. Уже загруженные номера
+// не меняются, для них возвращается владелец номера.
+func (pg *pgstorage) RegisterOrders(ctx context.Context, userID int64, orderNumbers []string) ([]models.OrderUploadResult, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pg.registerOrders.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]models.OrderUploadResult, 0, len(orderNumbers))
+	for _, number := range orderNumbers {
+		status, err := registerOrder(ctx, tx, userID, number)
+		if err != nil {
+			return nil, fmt.Errorf("pg.registerOrders: %w", err)
 		}
-		return fmt.Errorf("pg.registerOrder: %w", err)
+		results = append(results, models.OrderUploadResult{Number: number, Status: status})
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("pg.registerOrders.commit: %w", err)
+	}
+
+	return results, nil
+}
+
+// Добавление заказа вместе с первой записью истории статусов и событием загрузки.
+// Для уже загруженного номера заказ не меняется, а статус результата зависит от владельца номера.
+func registerOrder(ctx context.Context, tx *sql.Tx, userID int64, orderNumber string) (models.OrderUploadStatus, error) {
+	var orderID int64
+	err := tx.QueryRowContext(ctx, `
+		WITH inserted AS (
+			INSERT INTO orders (user_id, number, status)
+			VALUES ($1, $2, 'NEW')
+			ON CONFLICT (number) DO NOTHING
+			RETURNING id, status, uploaded_at
+		)
+		INSERT INTO order_status_history (order_id, status, changed_at)
+		SELECT id, status, uploaded_at
+		FROM inserted
+		RETURNING order_id;`, userID, orderNumber).Scan(&orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var ownerID int64
+		err = tx.QueryRowContext(ctx, `
+			SELECT user_id
+			FROM orders
+			WHERE number=$1;`, orderNumber).Scan(&ownerID)
+		if err != nil {
+			return "", fmt.Errorf("pg.registerOrder.selectOwner: %w", err)
+		}
+
+		if ownerID == userID {
+			return models.OrderUploadAlreadyUploaded, nil
+		}
+		return models.OrderUploadConflict, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("pg.registerOrder.insertOrder: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.NewOrderRegisteredEvent(userID, orderNumber)); err != nil {
+		return "", fmt.Errorf("pg.registerOrder.insertOutboxEvent: %w", err)
+	}
+
+	return models.OrderUploadAccepted, nil
+}
+
+func (pg *pgstorage) GetOrderByNumber(ctx context.Context, orderNumber string) (*models.Order, error) {
+	return pg.getOrderByNumber(ctx, orderNumber)
+}
+
+func (pg *pgstorage) GetOrderStatusHistory(ctx context.Context, orderID int64) ([]models.OrderStatusChange, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT status, COALESCE(accrual_status, ''), changed_at
+		FROM order_status_history
+		WHERE order_id=$1
+		ORDER BY id;`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getOrderStatusHistory.selectHistory: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.OrderStatusChange{}
+	for rows.Next() {
+		change := models.OrderStatusChange{}
+		if err := rows.Scan(&change.Status, &change.AccrualStatus, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("pg.getOrderStatusHistory.scanChange: %w", err)
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getOrderStatusHistory.err: %w", err)
+	}
+
+	return history, nil
+}
+
+// Переходы между статусами заказов, завершившиеся в периоде [from, to)
+func (pg *pgstorage) GetOrderStatusTransitions(ctx context.Context, from, to time.Time) ([]models.OrderStatusTransition, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT h.order_id, o.number, prev.status, h.status, COALESCE(h.accrual_status, ''),
+			prev.changed_at, h.changed_at, o.uploaded_at
+		FROM order_status_history h
+		JOIN orders o ON o.id=h.order_id
+		JOIN LATERAL (
+			SELECT status, changed_at
+			FROM order_status_history p
+			WHERE p.order_id=h.order_id AND p.id<h.id
+			ORDER BY p.id DESC
+			LIMIT 1
+		) prev ON true
+		WHERE h.changed_at >= $1 AND h.changed_at < $2
+		ORDER BY h.changed_at, h.order_id;`, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("pg.getOrderStatusTransitions.selectTransitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []models.OrderStatusTransition{}
+	for rows.Next() {
+		t := models.OrderStatusTransition{}
+		err := rows.Scan(&t.OrderID, &t.Number, &t.From, &t.To, &t.AccrualStatus,
+			&t.StartedAt, &t.ChangedAt, &t.UploadedAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getOrderStatusTransitions.scanTransition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getOrderStatusTransitions.err: %w", err)
+	}
+
+	return transitions, nil
 }
 
 func (pg *pgstorage) GetOrdersByUser(ctx context.Context, userID int64) ([]models.Order, error) {
@@ -43,7 +176,7 @@ func (pg *pgstorage) GetOrdersByUser(ctx context.Context, userID int64) ([]model
 		SELECT number, status, accrual, uploaded_at
 		FROM orders
 		WHERE user_id=$1
-		ORDER BY uploaded_at;`, userID)
+		ORDER BY uploaded_at, id;`, userID)
 	if err != nil {
 		return nil, fmt.Errorf("pg.getOrdersByUser.selectOrders: %w", err)
 	}
@@ -65,6 +198,56 @@ func (pg *pgstorage) GetOrdersByUser(ctx context.Context, userID int64) ([]model
 	return orders, nil
 }
 
+func (pg *pgstorage) GetOrdersPageByUser(ctx context.Context, userID int64, query *models.OrdersQuery) ([]models.Order, error) {
+	where := &whereBuilder{}
+	where.add("user_id=%s", userID)
+	if len(query.Statuses) > 0 {
+		where.add("status = ANY(%s::order_status[])", pq.Array(query.Statuses))
+	}
+	if query.UploadedFrom != nil {
+		where.add("uploaded_at >= %s", query.UploadedFrom.UTC())
+	}
+	if query.UploadedTo != nil {
+		where.add("uploaded_at < %s", query.UploadedTo.UTC())
+	}
+
+	// Позиция задается парой (uploaded_at, id), что соответствует индексу orders_user_id_uploaded_at_idx
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		where.add("(uploaded_at, id) "+comparison+" (%s, %s)", query.After.UploadedAt.UTC(), query.After.ID)
+	}
+
+	limit := where.arg(query.Limit)
+	rows, err := pg.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, number, status, accrual, uploaded_at
+		FROM orders
+		WHERE %s
+		ORDER BY uploaded_at %s, id %s
+		LIMIT %s;`, where, direction, direction, limit), where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getOrdersPageByUser.selectOrders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order := models.Order{UserID: userID}
+		err := rows.Scan(&order.ID, &order.Number, &order.Status, &order.Accrual, &order.UploadedAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getOrdersPageByUser.scanOrder: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getOrdersPageByUser.err: %w", err)
+	}
+
+	return orders, nil
+}
+
 func (pg *pgstorage) GetOrdersByStatus(ctx context.Context, statuses []models.OrderStatus) ([]models.Order, error) {
 	orders := []models.Order{}
 
@@ -92,36 +275,204 @@ func (pg *pgstorage) GetOrdersByStatus(ctx context.Context, statuses []models.Or
 	return orders, nil
 }
 
-func (pg *pgstorage) SetOrdersAccrualAndUpdateBalance(ctx context.Context, orders []models.Order) error {
-	tx, err := pg.db.Begin()
+func (pg *pgstorage) GetOrdersCountByStatus(ctx context.Context) (map[models.OrderStatus]int64, error) {
+	counts := make(map[models.OrderStatus]int64)
+
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT status, COUNT(*)
+		FROM orders
+		GROUP BY status;`)
 	if err != nil {
-		return fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.beginTx: %w", err)
+		return nil, fmt.Errorf("pg.getOrdersCountByStatus.selectCounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			status models.OrderStatus
+			count  int64
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("pg.getOrdersCountByStatus.scanCount: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getOrdersCountByStatus.err: %w", err)
+	}
+
+	return counts, nil
+}
+
+// Сохранение информации о начислениях по заказам. Переход статуса допускается только
+// из NEW или PROCESSING, поэтому баллы за заказ зачисляются на баланс ровно один раз,
+// даже при повторных или конкурирующих вызовах. Возвращает заказы, статус которых изменился.
+func (pg *pgstorage) SetOrdersAccrualAndUpdateBalance(ctx context.Context, orders []models.Order) ([]models.Order, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.beginTx: %w", err)
 	}
 	defer tx.Rollback()
 
+	updatedOrders := make([]models.Order, 0, len(orders))
 	for _, order := range orders {
-		_, err := tx.ExecContext(ctx, `
-			UPDATE orders
+		updatedOrder := models.Order{
+			Number:  order.Number,
+			Status:  order.Status,
+			Accrual: order.Accrual,
+		}
+
+		// Блокировка строки заказа гарантирует, что конкурирующая транзакция
+		// увидит уже измененный статус и не выполнит повторное начисление
+		err := tx.QueryRowContext(ctx, `
+			UPDATE orders o
 			SET accrual=$1, status=$2
-			WHERE number=$3;`, order.Accrual, order.Status, order.Number)
+			FROM (
+				SELECT id, status
+				FROM orders
+				WHERE number=$3
+				FOR UPDATE
+			) old
+			WHERE o.id=old.id
+				AND old.status IN ('NEW', 'PROCESSING')
+				AND old.status<>$2
+			RETURNING o.id, o.user_id, o.uploaded_at;`, order.Accrual, order.Status, order.Number).
+			Scan(&updatedOrder.ID, &updatedOrder.UserID, &updatedOrder.UploadedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Заказ уже в конечном статусе или статус не изменился
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.updateOrder: %w", err)
+		}
+		updatedOrders = append(updatedOrders, updatedOrder)
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO order_status_history (order_id, status, accrual_status)
+			VALUES ($1, $2, NULLIF($3, ''));`, updatedOrder.ID, updatedOrder.Status, order.AccrualStatus)
 		if err != nil {
-			return fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.updateOrder: %w", err)
+			return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.insertStatusHistory: %w", err)
+		}
+
+		err = insertOutboxEvent(ctx, tx, models.NewOrderStatusEvent(updatedOrder))
+		if err != nil {
+			return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.insertOutboxEvent: %w", err)
+		}
+
+		if updatedOrder.Status != models.OrderStatusProcessed || updatedOrder.Accrual <= 0 {
+			continue
 		}
 
 		_, err = tx.ExecContext(ctx, `
 			UPDATE balances
 			SET current=balances.current+$1
-			WHERE user_id=$2;`, order.Accrual, order.UserID)
+			WHERE user_id=$2;`, updatedOrder.Accrual, updatedOrder.UserID)
 		if err != nil {
-			return fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.updateBalance: %w", err)
+			return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.updateBalance: %w", err)
+		}
+
+		err = insertLedgerEntry(ctx, tx, models.NewAccrualEntry(updatedOrder.UserID, updatedOrder.Number, updatedOrder.Accrual))
+		if err != nil {
+			return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.insertLedgerEntry: %w", err)
 		}
 	}
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.commit: %w", err)
+		return nil, fmt.Errorf("pg.setOrdersAccrualAndUpdateBalance.commit: %w", err)
 	}
 
-	return nil
+	return updatedOrders, nil
+}
+
+// Учет результатов запросов информации о начислениях: счетчики неудач увеличиваются
+// для заказов из failures и сбрасываются для успешно обработанных заказов.
+// Возвращает заказы из failures с обновленными счетчиками.
+func (pg *pgstorage) UpdateOrdersAccrualFailures(ctx context.Context, failures []models.OrderAccrualFailure, succeeded []string) ([]models.Order, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pg.updateOrdersAccrualFailures.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(succeeded) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE orders
+			SET accrual_failures=0, last_accrual_error=NULL
+			WHERE number = ANY($1) AND accrual_failures > 0;`, pq.Array(succeeded))
+		if err != nil {
+			return nil, fmt.Errorf("pg.updateOrdersAccrualFailures.resetFailures: %w", err)
+		}
+	}
+
+	failedOrders := make([]models.Order, 0, len(failures))
+	for _, failure := range failures {
+		order := models.Order{Number: failure.Number}
+		err := tx.QueryRowContext(ctx, `
+			UPDATE orders
+			SET accrual_failures=accrual_failures+1, last_accrual_error=$1
+			WHERE number=$2
+			RETURNING id, user_id, status, accrual, uploaded_at, accrual_failures, last_accrual_error;`,
+			failure.Error, failure.Number).
+			Scan(&order.ID, &order.UserID, &order.Status, &order.Accrual, &order.UploadedAt,
+				&order.AccrualFailures, &order.LastAccrualError)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pg.updateOrdersAccrualFailures.incrementFailures: %w", err)
+		}
+		failedOrders = append(failedOrders, order)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("pg.updateOrdersAccrualFailures.commit: %w", err)
+	}
+
+	return failedOrders, nil
+}
+
+// Получение необработанных заказов, запрос информации о начислениях по которым
+// завершился неудачей не менее minFailures раз подряд
+func (pg *pgstorage) GetOrdersByAccrualFailures(ctx context.Context, minFailures int) ([]models.Order, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT id, number, user_id, status, accrual, uploaded_at, accrual_failures, COALESCE(last_accrual_error, '')
+		FROM orders
+		WHERE accrual_failures >= $1 AND status IN ('NEW', 'PROCESSING')
+		ORDER BY accrual_failures DESC, id;`, minFailures)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getOrdersByAccrualFailures.selectOrders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order := models.Order{}
+		err := rows.Scan(&order.ID, &order.Number, &order.UserID, &order.Status, &order.Accrual,
+			&order.UploadedAt, &order.AccrualFailures, &order.LastAccrualError)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getOrdersByAccrualFailures.scanOrder: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getOrdersByAccrualFailures.err: %w", err)
+	}
+
+	return orders, nil
+}
+
+// Количество необработанных заказов, запрос информации о начислениях по которым
+// завершился неудачей не менее minFailures раз подряд
+func (pg *pgstorage) CountOrdersByAccrualFailures(ctx context.Context, minFailures int) (int64, error) {
+	var count int64
+	err := pg.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM orders
+		WHERE accrual_failures >= $1 AND status IN ('NEW', 'PROCESSING');`, minFailures).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("pg.countOrdersByAccrualFailures: %w", err)
+	}
+	return count, nil
 }
 
 func (pg *pgstorage) getOrderByNumber(ctx context.Context, orderNumber string) (*models.Order, error) {
@@ -132,6 +483,9 @@ func (pg *pgstorage) getOrderByNumber(ctx context.Context, orderNumber string) (
 
 	order := models.Order{}
 	err := row.Scan(&order.ID, &order.Number, &order.UserID, &order.Status, &order.Accrual, &order.UploadedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("pg.getOrderByNumber: %w", appErrors.ErrOrderNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("pg.getOrderByNumber: %w", err)
 	}
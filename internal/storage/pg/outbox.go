@@ -0,0 +1,133 @@
+package pg
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Ключ advisory блокировки выбора событий для доставки. События выбирает
+// только один экземпляр сервиса, что сохраняет порядок событий пользователя.
+const outboxRelayLockKey = 0x6f7574626f78
+
+// Выбор для доставки не более limit недоставленных событий в порядке их записи.
+// Выбираются события только тех пользователей, время попытки первого недоставленного
+// события которых наступило к now, поэтому событие в ожидании повтора задерживает
+// только события своего пользователя. Выбранные события закрепляются до leaseUntil:
+// до этого времени их пользователи не выбираются повторно.
+func (pg *pgstorage) ClaimOutboxEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pg.claimOutboxEvents.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1);`, outboxRelayLockKey).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("pg.claimOutboxEvents.lock: %w", err)
+	}
+	if !locked {
+		// События выбирает другой экземпляр сервиса
+		return nil, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH heads AS (
+			SELECT DISTINCT ON (user_id) user_id, next_attempt_at
+			FROM outbox_events
+			WHERE delivered_at IS NULL AND dead_at IS NULL
+			ORDER BY user_id, id
+		), claimed AS (
+			SELECT e.id
+			FROM outbox_events e
+			JOIN heads h ON h.user_id=e.user_id
+			WHERE e.delivered_at IS NULL AND e.dead_at IS NULL AND h.next_attempt_at <= $1
+			ORDER BY e.id
+			LIMIT $2
+		)
+		UPDATE outbox_events
+		SET next_attempt_at=$3
+		WHERE id IN (SELECT id FROM claimed)
+		RETURNING id, user_id, event_type, payload, created_at, attempts, next_attempt_at;`,
+		now.UTC(), limit, leaseUntil.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("pg.claimOutboxEvents.claimEvents: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.OutboxEvent{}
+	for rows.Next() {
+		event := models.OutboxEvent{}
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.UserID, &event.Type, &payload, &event.CreatedAt, &event.Attempts,
+			&event.NextAttemptAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.claimOutboxEvents.scanEvent: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.claimOutboxEvents.err: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("pg.claimOutboxEvents.commit: %w", err)
+	}
+
+	// UPDATE ... RETURNING не сохраняет порядок подзапроса
+	slices.SortFunc(events, func(a, b models.OutboxEvent) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return events, nil
+}
+
+// Сохранение результатов доставки выбранных событий
+func (pg *pgstorage) CompleteOutboxEvents(ctx context.Context, deliveries []models.OutboxDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.completeOutboxEvents.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, delivery := range deliveries {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE outbox_events
+			SET attempts=attempts+CASE WHEN $2='' THEN 0 ELSE 1 END,
+				last_error=CASE WHEN $1='delivered' THEN NULL ELSE COALESCE(NULLIF($2, ''), last_error) END,
+				next_attempt_at=$3,
+				delivered_at=CASE WHEN $1='delivered' THEN NOW() END,
+				dead_at=CASE WHEN $1='dead' THEN NOW() END
+			WHERE id=$4;`,
+			delivery.Status, delivery.Error, delivery.NextAttemptAt.UTC(), delivery.EventID)
+		if err != nil {
+			return fmt.Errorf("pg.completeOutboxEvents.updateEvent: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.completeOutboxEvents.commit: %w", err)
+	}
+
+	return nil
+}
+
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event models.OutboxEvent) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO outbox_events (user_id, event_type, payload)
+		VALUES ($1, $2, $3);`, event.UserID, event.Type, string(event.Payload))
+	if err != nil {
+		return fmt.Errorf("pg.insertOutboxEvent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (pg *pgstorage) AddPasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	err := pg.db.QueryRowContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;`,
+		token.UserID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pg.addPasswordResetToken: %w", err)
+	}
+	return nil
+}
+
+func (pg *pgstorage) GetUserByPasswordResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	dbUser := &models.User{}
+	row := pg.db.QueryRowContext(ctx, `
+		SELECT u.id, u.login, u.password, u.token_version
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash=$1 AND t.used_at IS NULL AND t.expires_at > NOW();`, tokenHash)
+	if err := row.Scan(&dbUser.ID, &dbUser.Login, &dbUser.Password, &dbUser.TokenVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("pg.getUserByPasswordResetToken: %w", appErrors.ErrPasswordResetTokenInvalid)
+		}
+		return nil, fmt.Errorf("pg.getUserByPasswordResetToken: %w", err)
+	}
+	return dbUser, nil
+}
+
+func (pg *pgstorage) ResetUserPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf("pg.resetUserPassword.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Отметка об использовании исключает повторный сброс тем же токеном
+	var userID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at=NOW()
+		WHERE token_hash=$1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id;`, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, fmt.Errorf("pg.resetUserPassword: %w", appErrors.ErrPasswordResetTokenInvalid)
+	}
+	if err != nil {
+		return -1, fmt.Errorf("pg.resetUserPassword.useToken: %w", err)
+	}
+
+	if err := setUserPassword(ctx, tx, userID, passwordHash); err != nil {
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, fmt.Errorf("pg.resetUserPassword.commit: %w", err)
+	}
+
+	return userID, nil
+}
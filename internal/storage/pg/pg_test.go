@@ -2,18 +2,21 @@ package pg
 
 import (
 	"context"
-	"database/sql"
 	"testing"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/ulixes-bloom/ya-gophermart/internal/app"
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
-	"github.com/ulixes-bloom/ya-gophermart/internal/security"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/pg/migrations"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/storagetest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gotest.tools/v3/assert"
 )
 
@@ -21,7 +24,7 @@ func TestStorage_CreateUser(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	storage, err := newPostgresStorage(ctx)
+	storage, err := newPostgresStorage(ctx, t)
 	require.NoError(t, err)
 
 	user := models.User{
@@ -41,7 +44,7 @@ func TestStorage_CreateUser(t *testing.T) {
 	// Проверка результата
 	assert.Equal(t, user.Login, dbUser.Login)
 	assert.Equal(t, userID, dbUser.ID)
-	assert.NilError(t, security.CheckPassword(user.Password, dbUser.Password))
+	assert.Equal(t, user.Password, dbUser.Password)
 
 	// Создание пользователя с уже существующим логином
 	_, err = storage.AddUser(ctx, user.Login, user.Password)
@@ -57,7 +60,7 @@ func TestStorage_WithdrawFromBalance(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	storage, err := newPostgresStorage(ctx)
+	storage, err := newPostgresStorage(ctx, t)
 	require.NoError(t, err)
 
 	user := models.User{
@@ -96,7 +99,7 @@ func TestStorage_WithdrawFromBalance(t *testing.T) {
 	// Регистрация заказа с начислнием бонусов
 	err = storage.RegisterOrder(ctx, userID, order.Number)
 	require.NoError(t, err)
-	err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{order})
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{order})
 	require.NoError(t, err)
 
 	// Получениие баланса из БД
@@ -121,7 +124,136 @@ func TestStorage_WithdrawFromBalance(t *testing.T) {
 	assert.Equal(t, dbBalance.Withdrawn, models.Money(200))
 }
 
-func newPostgresStorage(ctx context.Context) (*pgstorage, error) {
+func TestStorage_Tracing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Провайдер устанавливается до подключения к БД, которое получает tracer при открытии
+	spans := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	storage, err := newPostgresStorage(ctx, t)
+	require.NoError(t, err)
+
+	userID, err := storage.AddUser(ctx, "login", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 300},
+	})
+	require.NoError(t, err)
+
+	// Все запросы транзакций, включая BEGIN и COMMIT, продолжают трассу HTTP запроса
+	reqCtx, reqSpan := otel.Tracer("test").Start(ctx, "POST /api/user/balance/withdraw")
+	require.NoError(t, storage.WithdrawFromUserBalance(reqCtx, userID, "2377225624", models.Money(100)))
+	_, err = storage.AddUser(reqCtx, "other", "password")
+	require.NoError(t, err)
+	reqSpan.End()
+
+	operations := []string{}
+	for _, span := range spans.Ended() {
+		if span.Parent().SpanID() == reqSpan.SpanContext().SpanID() {
+			operations = append(operations, span.Name())
+		}
+	}
+	assert.DeepEqual(t, operations, []string{
+		"pg BEGIN", "pg UPDATE", "pg INSERT", "pg INSERT", "pg INSERT", "pg COMMIT",
+		"pg BEGIN", "pg INSERT", "pg INSERT", "pg COMMIT",
+	})
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, newConformanceStorage)
+}
+
+func TestStorage_BalanceDiscrepancies(t *testing.T) {
+	storagetest.RunBalanceDiscrepancies(t, newConformanceStorage,
+		func(ctx context.Context, storage app.Storage, userID int64, current models.Money) error {
+			_, err := storage.(*pgstorage).db.ExecContext(ctx,
+				`UPDATE balances SET current=$1 WHERE user_id=$2;`, current, userID)
+			return err
+		})
+}
+
+func TestStorage_OrdersOrder(t *testing.T) {
+	storagetest.RunOrdersOrder(t, newConformanceStorage,
+		func(ctx context.Context, storage app.Storage, number string, uploadedAt time.Time) error {
+			_, err := storage.(*pgstorage).db.ExecContext(ctx,
+				`UPDATE orders SET uploaded_at=$1 WHERE number=$2;`, uploadedAt, number)
+			return err
+		})
+}
+
+func TestStorage_Migrations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	storage, err := newPostgresStorage(ctx, t)
+	require.NoError(t, err)
+
+	migrator, err := migrations.New(storage.db)
+	require.NoError(t, err)
+
+	// После создания хранилища все миграции применены
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Assert(t, status.AppliedAt != nil)
+	}
+
+	// Откат всех миграций по одной
+	for range statuses {
+		require.NoError(t, migrator.Down(ctx))
+	}
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Assert(t, status.AppliedAt == nil)
+	}
+
+	// Повторное применение миграций и их идемпотентность
+	require.NoError(t, migrator.Up(ctx))
+	require.NoError(t, migrator.Up(ctx))
+	_, err = storage.AddUser(ctx, "login", "password")
+	require.NoError(t, err)
+}
+
+func TestStorage_TimestampsWithTimeZone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	storage, err := newPostgresStorage(ctx, t)
+	require.NoError(t, err)
+
+	// Время хранится с часовым поясом во всех таблицах, чтобы не зависеть от часового пояса сессии.
+	// Служебная таблица миграций создается мигратором и в проверку не входит.
+	rows, err := storage.db.QueryContext(ctx, `
+		SELECT table_name || '.' || column_name
+		FROM information_schema.columns
+		WHERE table_schema='public' AND data_type='timestamp without time zone'
+			AND table_name<>'schema_migrations';`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var column string
+		require.NoError(t, rows.Scan(&column))
+		columns = append(columns, column)
+	}
+	require.NoError(t, rows.Err())
+	assert.DeepEqual(t, columns, []string{})
+}
+
+func newConformanceStorage(ctx context.Context, t *testing.T) app.Storage {
+	storage, err := newPostgresStorage(ctx, t)
+	require.NoError(t, err)
+	return storage
+}
+
+func newPostgresStorage(ctx context.Context, t *testing.T) (*pgstorage, error) {
 	dbName := "gophermart"
 	dbUser := "user"
 	dbPassword := "password"
@@ -139,13 +271,19 @@ func newPostgresStorage(ctx context.Context) (*pgstorage, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Контейнер останавливается после завершения теста, контекст которого к этому моменту может быть отменен
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(context.Background()); err != nil {
+			t.Logf("failed to terminate postgres container: %v", err)
+		}
+	})
 
 	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := sql.Open("pgx", connStr)
+	db, err := OpenDB(connStr)
 	if err != nil {
 		return nil, err
 	}
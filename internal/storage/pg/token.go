@@ -0,0 +1,163 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+func (pg *pgstorage) AddRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	err := pg.db.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;`,
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pg.addRefreshToken: %w", err)
+	}
+	return nil
+}
+
+func (pg *pgstorage) RotateRefreshToken(ctx context.Context, tokenHash string, newToken *models.RefreshToken) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.rotateRefreshToken.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка токена исключает одновременную ротацию
+	var (
+		expired, used bool
+		userID        int64
+		familyID      string
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, family_id, expires_at <= NOW(), used_at IS NOT NULL OR revoked_at IS NOT NULL
+		FROM refresh_tokens
+		WHERE token_hash=$1
+		FOR UPDATE;`, tokenHash).Scan(&userID, &familyID, &expired, &used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("pg.rotateRefreshToken: %w", appErrors.ErrRefreshTokenInvalid)
+	}
+	if err != nil {
+		return fmt.Errorf("pg.rotateRefreshToken.selectToken: %w", err)
+	}
+	if expired {
+		return fmt.Errorf("pg.rotateRefreshToken: %w", appErrors.ErrRefreshTokenInvalid)
+	}
+
+	// Повторное использование токена: отзывается все семейство
+	if used {
+		if err := revokeRefreshTokenFamily(ctx, tx, familyID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("pg.rotateRefreshToken.commit: %w", err)
+		}
+		return fmt.Errorf("pg.rotateRefreshToken: %w", appErrors.ErrRefreshTokenReused)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET used_at=NOW()
+		WHERE token_hash=$1;`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("pg.rotateRefreshToken.markUsed: %w", err)
+	}
+
+	newToken.UserID = userID
+	newToken.FamilyID = familyID
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;`,
+		newToken.UserID, newToken.FamilyID, newToken.TokenHash, newToken.ExpiresAt).
+		Scan(&newToken.ID, &newToken.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pg.rotateRefreshToken.insertToken: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.rotateRefreshToken.commit: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *pgstorage) RevokeRefreshTokenFamily(ctx context.Context, userID int64, tokenHash string) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.revokeRefreshTokenFamily.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var familyID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT family_id
+		FROM refresh_tokens
+		WHERE token_hash=$1 AND user_id=$2;`, tokenHash, userID).Scan(&familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("pg.revokeRefreshTokenFamily: %w", appErrors.ErrRefreshTokenInvalid)
+	}
+	if err != nil {
+		return fmt.Errorf("pg.revokeRefreshTokenFamily.selectToken: %w", err)
+	}
+
+	if err := revokeRefreshTokenFamily(ctx, tx, familyID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.revokeRefreshTokenFamily.commit: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *pgstorage) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	// Удаление записей об истекших токенах: они отклоняются и без списка отзыва
+	_, err := pg.db.ExecContext(ctx, `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < NOW();`)
+	if err != nil {
+		return fmt.Errorf("pg.revokeAccessToken.deleteExpired: %w", err)
+	}
+
+	_, err = pg.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (token_id, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (token_id) DO NOTHING;`, tokenID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("pg.revokeAccessToken: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *pgstorage) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := pg.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id=$1);`, tokenID).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("pg.isAccessTokenRevoked: %w", err)
+	}
+	return revoked, nil
+}
+
+// Отзыв всех токенов семейства в рамках транзакции
+func revokeRefreshTokenFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at=NOW()
+		WHERE family_id=$1 AND revoked_at IS NULL;`, familyID)
+	if err != nil {
+		return fmt.Errorf("pg.revokeRefreshTokenFamily: %w", err)
+	}
+	return nil
+}
@@ -2,30 +2,26 @@ package pg
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
 	"github.com/ulixes-bloom/ya-gophermart/internal/models"
-	"github.com/ulixes-bloom/ya-gophermart/internal/security"
 )
 
-func (pg *pgstorage) AddUser(ctx context.Context, login, password string) (int64, error) {
-	tx, err := pg.db.Begin()
+func (pg *pgstorage) AddUser(ctx context.Context, login, passwordHash string) (int64, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return -1, fmt.Errorf("pg.addUser.beginTx: %w", err)
 	}
 	defer tx.Rollback()
 
-	hashPassword, err := security.HashPassword(password)
-	if err != nil {
-		return -1, fmt.Errorf("pg.addUser.hashPassword: %w", err)
-	}
-
 	var id int64
 	err = tx.QueryRowContext(ctx, `
 		INSERT INTO users (login, password)
 		VALUES ($1, $2)
-		RETURNING id;`, login, hashPassword).Scan(&id)
+		RETURNING id;`, login, passwordHash).Scan(&id)
 	if err != nil {
 		if isUniqueViolation(err, "users_login_key") {
 			return -1, fmt.Errorf(`pg.AddUser: %w: %s`, appErrors.ErrUserLoginAlreadyExists, err)
@@ -51,11 +47,103 @@ func (pg *pgstorage) AddUser(ctx context.Context, login, password string) (int64
 func (pg *pgstorage) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
 	dbUser := &models.User{}
 	row := pg.db.QueryRowContext(ctx, `
-		SELECT id, login, password
+		SELECT id, login, password, token_version
 		FROM users
 		WHERE login=$1;`, login)
-	if err := row.Scan(&dbUser.ID, &dbUser.Login, &dbUser.Password); err != nil {
+	if err := row.Scan(&dbUser.ID, &dbUser.Login, &dbUser.Password, &dbUser.TokenVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("pg.getUserByLogin: %w", appErrors.ErrUserNotFound)
+		}
 		return nil, fmt.Errorf("pg.getUserByLogin: %w", err)
 	}
 	return dbUser, nil
 }
+
+func (pg *pgstorage) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	dbUser := &models.User{}
+	row := pg.db.QueryRowContext(ctx, `
+		SELECT id, login, password, token_version
+		FROM users
+		WHERE id=$1;`, userID)
+	if err := row.Scan(&dbUser.ID, &dbUser.Login, &dbUser.Password, &dbUser.TokenVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("pg.getUserByID: %w", appErrors.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("pg.getUserByID: %w", err)
+	}
+	return dbUser, nil
+}
+
+func (pg *pgstorage) UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.updateUserPassword.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setUserPassword(ctx, tx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.updateUserPassword.commit: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *pgstorage) RehashUserPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	// Пароль мог быть изменен после проверки: новый хеш сохраняется, только если старый не изменился
+	var exists bool
+	err := pg.db.QueryRowContext(ctx, `
+		WITH updated AS (
+			UPDATE users
+			SET password=$3
+			WHERE id=$1 AND password=$2
+		)
+		SELECT EXISTS (SELECT 1 FROM users WHERE id=$1);`, userID, oldHash, newHash).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("pg.rehashUserPassword: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("pg.rehashUserPassword: %w", appErrors.ErrUserNotFound)
+	}
+	return nil
+}
+
+// Установка нового пароля в рамках транзакции: выданные ранее access токены,
+// refresh токены и токены сброса пароля пользователя перестают приниматься
+func setUserPassword(ctx context.Context, tx *sql.Tx, userID int64, passwordHash string) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET password=$2, token_version=token_version+1
+		WHERE id=$1;`, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("pg.setUserPassword: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("pg.setUserPassword: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("pg.setUserPassword: %w", appErrors.ErrUserNotFound)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at=NOW()
+		WHERE user_id=$1 AND revoked_at IS NULL;`, userID)
+	if err != nil {
+		return fmt.Errorf("pg.setUserPassword.revokeRefreshTokens: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at=NOW()
+		WHERE user_id=$1 AND used_at IS NULL;`, userID)
+	if err != nil {
+		return fmt.Errorf("pg.setUserPassword.invalidateResetTokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package pg
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Регистрация вебхука, если у пользователя их меньше maxWebhooks.
+// Заполняет ID и время создания вебхука.
+func (pg *pgstorage) AddWebhook(ctx context.Context, webhook *models.Webhook, maxWebhooks int) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.addWebhook.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка пользователя исключает превышение лимита конкурирующими запросами
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(w.id)
+		FROM (SELECT id FROM users WHERE id=$1 FOR UPDATE) u
+		LEFT JOIN webhooks w ON w.user_id=u.id;`, webhook.UserID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("pg.addWebhook.countWebhooks: %w", err)
+	}
+	if count >= maxWebhooks {
+		return appErrors.ErrTooManyWebhooks
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO webhooks (user_id, url, secret)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;`, webhook.UserID, webhook.URL, webhook.Secret).
+		Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pg.addWebhook.insertWebhook: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.addWebhook.commit: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *pgstorage) GetWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT id, url, secret, created_at
+		FROM webhooks
+		WHERE user_id=$1
+		ORDER BY id;`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getWebhooksByUser.selectWebhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook := models.Webhook{UserID: userID}
+		if err := rows.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("pg.getWebhooksByUser.scanWebhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getWebhooksByUser.err: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (pg *pgstorage) GetWebhook(ctx context.Context, webhookID int64) (*models.Webhook, error) {
+	webhook := models.Webhook{ID: webhookID}
+	err := pg.db.QueryRowContext(ctx, `
+		SELECT user_id, url, secret, created_at
+		FROM webhooks
+		WHERE id=$1;`, webhookID).
+		Scan(&webhook.UserID, &webhook.URL, &webhook.Secret, &webhook.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("pg.getWebhook: %w", appErrors.ErrWebhookNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pg.getWebhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// Удаление вебхука вместе с журналом его доставок
+func (pg *pgstorage) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	res, err := pg.db.ExecContext(ctx, `
+		DELETE FROM webhooks
+		WHERE id=$1;`, webhookID)
+	if err != nil {
+		return fmt.Errorf("pg.deleteWebhook: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("pg.deleteWebhook.rowsAffected: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("pg.deleteWebhook: %w", appErrors.ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// Постановка события в очередь доставки на все вебхуки пользователя.
+// Повторная постановка того же события доставки не дублирует.
+func (pg *pgstorage) AddWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error {
+	_, err := pg.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3
+		FROM webhooks
+		WHERE user_id=$4
+		ON CONFLICT (webhook_id, event_id) DO NOTHING;`,
+		event.ID, event.Type, string(event.Payload), event.UserID)
+	if err != nil {
+		return fmt.Errorf("pg.addWebhookDeliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Выбор для отправки не более limit ожидающих доставок, время попытки которых наступило к now.
+// Выбранные доставки закрепляются до leaseUntil одним запросом, поэтому несколько экземпляров
+// сервиса отправляют разные доставки, а блокировки не удерживаются на время отправки.
+func (pg *pgstorage) ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at=$3
+		FROM webhooks w
+		WHERE w.id=d.webhook_id AND d.id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status='pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			COALESCE(d.response_code, 0), COALESCE(d.last_error, ''), d.next_attempt_at, d.created_at,
+			w.url, w.secret;`, now.UTC(), limit, leaseUntil.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("pg.claimWebhookDeliveries.claimDeliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d := models.WebhookDelivery{}
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.ResponseCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("pg.claimWebhookDeliveries.scanDelivery: %w", err)
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.claimWebhookDeliveries.err: %w", err)
+	}
+
+	// UPDATE ... RETURNING не сохраняет порядок подзапроса
+	slices.SortFunc(deliveries, func(a, b models.WebhookDelivery) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return deliveries, nil
+}
+
+// Сохранение результатов попыток доставки. Доставки удаленных
+// во время отправки вебхуков не изменяются.
+func (pg *pgstorage) CompleteWebhookDeliveries(ctx context.Context, results []models.WebhookDeliveryResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pg.completeWebhookDeliveries.beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, result := range results {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status=$1,
+				attempts=attempts+1,
+				response_code=NULLIF($2, 0),
+				last_error=NULLIF($3, ''),
+				next_attempt_at=CASE WHEN $1='pending' THEN $4 ELSE next_attempt_at END,
+				delivered_at=CASE WHEN $1='delivered' THEN NOW() END
+			WHERE id=$5;`,
+			result.Status, result.ResponseCode, result.Error, result.NextAttemptAt.UTC(), result.DeliveryID)
+		if err != nil {
+			return fmt.Errorf("pg.completeWebhookDeliveries.updateDelivery: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg.completeWebhookDeliveries.commit: %w", err)
+	}
+
+	return nil
+}
+
+// Последние limit доставок на вебхук, начиная с новых
+func (pg *pgstorage) GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT id, event_id, event_type, payload, status, attempts, COALESCE(response_code, 0),
+			COALESCE(last_error, ''), next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id=$1
+		ORDER BY id DESC
+		LIMIT $2;`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("pg.getWebhookDeliveries.selectDeliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d := models.WebhookDelivery{WebhookID: webhookID}
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.ResponseCode,
+			&d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("pg.getWebhookDeliveries.scanDelivery: %w", err)
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg.getWebhookDeliveries.err: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,31 @@
+package pg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Условия WHERE с нумерованными параметрами запроса
+type whereBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// Добавление условия. Каждый %s в format заменяется на параметр со следующим значением из values.
+func (w *whereBuilder) add(format string, values ...any) {
+	placeholders := make([]any, 0, len(values))
+	for _, value := range values {
+		placeholders = append(placeholders, w.arg(value))
+	}
+	w.conditions = append(w.conditions, fmt.Sprintf(format, placeholders...))
+}
+
+// Добавление параметра запроса вне условий, например для LIMIT
+func (w *whereBuilder) arg(value any) string {
+	w.args = append(w.args, value)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *whereBuilder) String() string {
+	return strings.Join(w.conditions, " AND ")
+}
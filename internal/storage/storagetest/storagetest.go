@@ -0,0 +1,1211 @@
+// Package storagetest содержит общий набор тестов, проверяющих, что реализация
+// app.Storage соблюдает контракт хранилища. Набор запускается для каждого бэкенда.
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/app"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"gotest.tools/v3/assert"
+)
+
+// Фабрика пустого хранилища для одного теста
+type StorageFactory func(ctx context.Context, t *testing.T) app.Storage
+
+// Запуск набора тестов для реализации хранилища
+func Run(t *testing.T, newStorage StorageFactory) {
+	tests := []struct {
+		name string
+		test func(ctx context.Context, t *testing.T, storage app.Storage)
+	}{
+		{name: "CreateUser", test: testCreateUser},
+		{name: "RegisterOrder", test: testRegisterOrder},
+		{name: "RegisterOrders", test: testRegisterOrders},
+		{name: "GetOrdersByStatus", test: testGetOrdersByStatus},
+		{name: "GetOrdersCountByStatus", test: testGetOrdersCountByStatus},
+		{name: "GetOrdersPageByUser", test: testGetOrdersPageByUser},
+		{name: "OrderStatusHistory", test: testOrderStatusHistory},
+		{name: "SetOrdersAccrualAndUpdateBalance", test: testSetOrdersAccrualAndUpdateBalance},
+		{name: "WithdrawFromBalance", test: testWithdrawFromBalance},
+		{name: "GetWithdrawalsPageByUser", test: testGetWithdrawalsPageByUser},
+		{name: "Ledger", test: testLedger},
+		{name: "OutboxEvents", test: testOutboxEvents},
+		{name: "Webhooks", test: testWebhooks},
+		{name: "WebhookDeliveries", test: testWebhookDeliveries},
+		{name: "IdempotentAccrual", test: testIdempotentAccrual},
+		{name: "ConcurrentAccrual", test: testConcurrentAccrual},
+		{name: "AccrualFailures", test: testAccrualFailures},
+		{name: "RefreshTokenRotation", test: testRefreshTokenRotation},
+		{name: "RevokedAccessTokens", test: testRevokedAccessTokens},
+		{name: "LoginLockouts", test: testLoginLockouts},
+		{name: "UpdateUserPassword", test: testUpdateUserPassword},
+		{name: "PasswordResetTokens", test: testPasswordResetTokens},
+		{name: "RehashUserPassword", test: testRehashUserPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			storage := newStorage(ctx, t)
+			defer storage.Close()
+
+			tt.test(ctx, t, storage)
+		})
+	}
+}
+
+func testCreateUser(ctx context.Context, t *testing.T, storage app.Storage) {
+	user := models.User{
+		Login:    "login",
+		Password: "password",
+	}
+
+	// Создание пользователя
+	userID, err := storage.AddUser(ctx, user.Login, user.Password)
+	require.NoError(t, err)
+
+	// Поиск созданного пользователя
+	dbUser, err := storage.GetUserByLogin(ctx, user.Login)
+	require.NoError(t, err)
+	require.NotNil(t, dbUser)
+
+	assert.Equal(t, user.Login, dbUser.Login)
+	assert.Equal(t, userID, dbUser.ID)
+	assert.Equal(t, user.Password, dbUser.Password)
+
+	// У нового пользователя создается нулевой баланс
+	dbBalance, err := storage.GetBalanceByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, dbBalance.Current, models.Money(0))
+	assert.Equal(t, dbBalance.Withdrawn, models.Money(0))
+
+	// Создание пользователя с уже существующим логином
+	_, err = storage.AddUser(ctx, user.Login, user.Password)
+	assert.ErrorIs(t, err, appErrors.ErrUserLoginAlreadyExists)
+
+	// Поиск несуществующего пользователя
+	dbUser, err = storage.GetUserByLogin(ctx, "not_existing_login")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+	require.Nil(t, dbUser)
+}
+
+func testRegisterOrder(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "password")
+	require.NoError(t, err)
+
+	// Регистрация новых заказов
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+
+	// Повторная загрузка заказа тем же и другим пользователем
+	err = storage.RegisterOrder(ctx, userID, "12345678903")
+	assert.ErrorIs(t, err, appErrors.ErrOrderWasUploadedByCurrentUser)
+	err = storage.RegisterOrder(ctx, anotherUserID, "12345678903")
+	assert.ErrorIs(t, err, appErrors.ErrOrderWasUploadedByAnotherUser)
+
+	// Заказы возвращаются в порядке загрузки со статусом NEW
+	orders, err := storage.GetOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, orders[0].Number, "12345678903")
+	assert.Equal(t, orders[1].Number, "2377225624")
+	for _, order := range orders {
+		assert.Equal(t, order.Status, models.OrderStatusNew)
+		assert.Equal(t, order.Accrual, models.Money(0))
+	}
+
+	// У другого пользователя заказов нет
+	orders, err = storage.GetOrdersByUser(ctx, anotherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, len(orders), 0)
+}
+
+func testRegisterOrders(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another", "password")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, anotherUserID, "2377225624"))
+
+	// Повтор номера внутри пакета считается уже загруженным
+	results, err := storage.RegisterOrders(ctx, userID, []string{"79927398713", "12345678903", "2377225624", "79927398713"})
+	require.NoError(t, err)
+	assert.DeepEqual(t, results, []models.OrderUploadResult{
+		{Number: "79927398713", Status: models.OrderUploadAccepted},
+		{Number: "12345678903", Status: models.OrderUploadAlreadyUploaded},
+		{Number: "2377225624", Status: models.OrderUploadConflict},
+		{Number: "79927398713", Status: models.OrderUploadAlreadyUploaded},
+	})
+
+	order, err := storage.GetOrderByNumber(ctx, "79927398713")
+	require.NoError(t, err)
+	assert.Equal(t, order.UserID, userID)
+	assert.Equal(t, order.Status, models.OrderStatusNew)
+	history, err := storage.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, len(history), 1)
+
+	// Чужой заказ не меняется
+	order, err = storage.GetOrderByNumber(ctx, "2377225624")
+	require.NoError(t, err)
+	assert.Equal(t, order.UserID, anotherUserID)
+}
+
+func testGetOrdersByStatus(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "2377225624", UserID: userID, Status: models.OrderStatusInvalid},
+	})
+	require.NoError(t, err)
+
+	orders, err := storage.GetOrdersByStatus(ctx, app.NotProcessedOrderStatuses)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, orders[0].Number, "12345678903")
+	assert.Equal(t, orders[0].UserID, userID)
+
+	orders, err = storage.GetOrdersByStatus(ctx, []models.OrderStatus{models.OrderStatusInvalid})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, orders[0].Number, "2377225624")
+}
+
+func testGetOrdersCountByStatus(ctx context.Context, t *testing.T, storage app.Storage) {
+	counts, err := storage.GetOrdersCountByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(counts), 0)
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	for _, number := range []string{"12345678903", "2377225624", "79927398713"} {
+		require.NoError(t, storage.RegisterOrder(ctx, userID, number))
+	}
+
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "2377225624", UserID: userID, Status: models.OrderStatusInvalid},
+		{Number: "79927398713", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(100, 0)},
+	})
+	require.NoError(t, err)
+
+	counts, err = storage.GetOrdersCountByStatus(ctx)
+	require.NoError(t, err)
+	assert.DeepEqual(t, counts, map[models.OrderStatus]int64{
+		models.OrderStatusNew:       1,
+		models.OrderStatusInvalid:   1,
+		models.OrderStatusProcessed: 1,
+	})
+}
+
+func testGetOrdersPageByUser(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another", "password")
+	require.NoError(t, err)
+
+	numbers := []string{"1", "2", "3", "4", "5"}
+	for _, number := range numbers {
+		require.NoError(t, storage.RegisterOrder(ctx, userID, number))
+	}
+	require.NoError(t, storage.RegisterOrder(ctx, anotherUserID, "6"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "2", UserID: userID, Status: models.OrderStatusInvalid},
+		{Number: "4", UserID: userID, Status: models.OrderStatusInvalid},
+	})
+	require.NoError(t, err)
+
+	pageNumbers := func(orders []models.Order) []string {
+		res := []string{}
+		for _, order := range orders {
+			res = append(res, order.Number)
+		}
+		return res
+	}
+
+	// Постраничный обход в обоих направлениях
+	for _, descending := range []bool{false, true} {
+		query := &models.OrdersQuery{Descending: descending, Limit: 2}
+		visited := []string{}
+		for range numbers {
+			orders, err := storage.GetOrdersPageByUser(ctx, userID, query)
+			require.NoError(t, err)
+			if len(orders) == 0 {
+				break
+			}
+			assert.Assert(t, len(orders) <= query.Limit)
+			visited = append(visited, pageNumbers(orders)...)
+			query.After = models.NewOrderCursor(orders[len(orders)-1], descending)
+		}
+
+		expected := slices.Clone(numbers)
+		if descending {
+			slices.Reverse(expected)
+		}
+		assert.DeepEqual(t, visited, expected)
+	}
+
+	// Фильтр по статусу
+	orders, err := storage.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{
+		Statuses: []models.OrderStatus{models.OrderStatusInvalid},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	assert.DeepEqual(t, pageNumbers(orders), []string{"2", "4"})
+
+	// Фильтр по времени загрузки: начало включительно, конец не включительно
+	all, err := storage.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, len(numbers))
+	orders, err = storage.GetOrdersPageByUser(ctx, userID, &models.OrdersQuery{
+		UploadedFrom: &all[1].UploadedAt,
+		UploadedTo:   &all[3].UploadedAt,
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	assert.DeepEqual(t, pageNumbers(orders), []string{"2", "3"})
+}
+
+func testOrderStatusHistory(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	_, err = storage.GetOrderByNumber(ctx, "12345678903")
+	assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	order, err := storage.GetOrderByNumber(ctx, "12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, order.UserID, userID)
+	assert.Equal(t, order.Status, models.OrderStatusNew)
+
+	// Новый заказ получает статус NEW в момент загрузки
+	history, err := storage.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, history[0].Status, models.OrderStatusNew)
+	assert.Assert(t, history[0].ChangedAt.Equal(order.UploadedAt))
+
+	// Записываются только фактические смены статуса вместе с полученным статусом начисления
+	start := time.Now().Add(-time.Minute)
+	for _, update := range []models.Order{
+		{Status: models.OrderStatusNew, AccrualStatus: models.AccrualStatusRegistered},
+		{Status: models.OrderStatusProcessing, AccrualStatus: models.AccrualStatusProcessing},
+		{Status: models.OrderStatusProcessing, AccrualStatus: models.AccrualStatusProcessing},
+		{Status: models.OrderStatusProcessed, AccrualStatus: models.AccrualStatusProcessed, Accrual: 100},
+		{Status: models.OrderStatusInvalid, AccrualStatus: models.AccrualStatusInvalid},
+	} {
+		update.Number, update.UserID = "12345678903", userID
+		_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{update})
+		require.NoError(t, err)
+	}
+
+	history, err = storage.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	statuses := []models.OrderStatus{}
+	for i, change := range history {
+		statuses = append(statuses, change.Status)
+		if i > 0 {
+			assert.Assert(t, !change.ChangedAt.Before(history[i-1].ChangedAt))
+		}
+	}
+	assert.DeepEqual(t, statuses, []models.OrderStatus{
+		models.OrderStatusNew,
+		models.OrderStatusProcessing,
+		models.OrderStatusProcessed,
+	})
+	assert.Equal(t, history[0].AccrualStatus, models.AccrualStatus(""))
+	assert.Equal(t, history[1].AccrualStatus, models.AccrualStatusProcessing)
+	assert.Equal(t, history[2].AccrualStatus, models.AccrualStatusProcessed)
+
+	history, err = storage.GetOrderStatusHistory(ctx, order.ID+1)
+	require.NoError(t, err)
+	assert.Equal(t, len(history), 0)
+
+	// Переходы за период: начало включительно, конец не включительно
+	transitions, err := storage.GetOrderStatusTransitions(ctx, start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, transitions[0].Number, "12345678903")
+	assert.Equal(t, transitions[0].From, models.OrderStatusNew)
+	assert.Equal(t, transitions[0].To, models.OrderStatusProcessing)
+	assert.Equal(t, transitions[1].From, models.OrderStatusProcessing)
+	assert.Equal(t, transitions[1].To, models.OrderStatusProcessed)
+	assert.Equal(t, transitions[1].AccrualStatus, models.AccrualStatusProcessed)
+	assert.Assert(t, transitions[1].StartedAt.Equal(transitions[0].ChangedAt))
+	assert.Assert(t, transitions[0].UploadedAt.Equal(order.UploadedAt))
+
+	transitions, err = storage.GetOrderStatusTransitions(ctx, start, transitions[1].ChangedAt)
+	require.NoError(t, err)
+	assert.Equal(t, len(transitions), 1)
+}
+
+func testSetOrdersAccrualAndUpdateBalance(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 300},
+		{Number: "2377225624", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 150},
+	})
+	require.NoError(t, err)
+
+	// Начисления сохраняются в заказах
+	orders, err := storage.GetOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, orders[0].Status, models.OrderStatusProcessed)
+	assert.Equal(t, orders[0].Accrual, models.Money(300))
+	assert.Equal(t, orders[1].Status, models.OrderStatusProcessed)
+	assert.Equal(t, orders[1].Accrual, models.Money(150))
+
+	// и зачисляются на баланс пользователя
+	dbBalance, err := storage.GetBalanceByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, dbBalance.Current, models.Money(450))
+	assert.Equal(t, dbBalance.Withdrawn, models.Money(0))
+}
+
+func testWithdrawFromBalance(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	// Попытка списания со счета при недостаточном значении баланса
+	err = storage.WithdrawFromUserBalance(ctx, userID, "2377225624", models.Money(200))
+	assert.ErrorIs(t, err, appErrors.ErrNegativeBalance)
+
+	// Неудачное списание не меняет баланс и не попадает в историю
+	dbBalance, err := storage.GetBalanceByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, dbBalance.Current, models.Money(0))
+	assert.Equal(t, dbBalance.Withdrawn, models.Money(0))
+
+	withdrawals, err := storage.GetWithdrawalsByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(withdrawals), 0)
+
+	// Начисление бонусов за заказ
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 300},
+	})
+	require.NoError(t, err)
+
+	// Списание при достаточном значении баланса
+	err = storage.WithdrawFromUserBalance(ctx, userID, "2377225624", models.Money(200))
+	require.NoError(t, err)
+
+	dbBalance, err = storage.GetBalanceByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, dbBalance.Current, models.Money(100))
+	assert.Equal(t, dbBalance.Withdrawn, models.Money(200))
+
+	// Повторное списание в счет того же заказа невозможно
+	err = storage.WithdrawFromUserBalance(ctx, userID, "2377225624", models.Money(50))
+	require.Error(t, err)
+
+	withdrawals, err = storage.GetWithdrawalsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, withdrawals, 1)
+	assert.Equal(t, withdrawals[0].Order, "2377225624")
+	assert.Equal(t, withdrawals[0].Sum, models.Money(200))
+	assert.Assert(t, !withdrawals[0].ProcessedAt.IsZero())
+}
+
+func testGetWithdrawalsPageByUser(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another", "password")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, anotherUserID, "2377225624"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(100, 0)},
+		{Number: "2377225624", UserID: anotherUserID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(100, 0)},
+	})
+	require.NoError(t, err)
+
+	numbers := []string{"1", "2", "3", "4", "5"}
+	for i, number := range numbers {
+		require.NoError(t, storage.WithdrawFromUserBalance(ctx, userID, number, models.NewMoney(int64(i+1), 0)))
+	}
+	require.NoError(t, storage.WithdrawFromUserBalance(ctx, anotherUserID, "6", models.NewMoney(3, 0)))
+
+	pageNumbers := func(withdrawals []models.Withdrawal) []string {
+		res := []string{}
+		for _, withdrawal := range withdrawals {
+			res = append(res, withdrawal.Order)
+		}
+		return res
+	}
+
+	// Постраничный обход в обоих направлениях
+	for _, descending := range []bool{false, true} {
+		query := &models.WithdrawalsQuery{Descending: descending, Limit: 2}
+		visited := []string{}
+		for range numbers {
+			withdrawals, err := storage.GetWithdrawalsPageByUser(ctx, userID, query)
+			require.NoError(t, err)
+			if len(withdrawals) == 0 {
+				break
+			}
+			assert.Assert(t, len(withdrawals) <= query.Limit)
+			visited = append(visited, pageNumbers(withdrawals)...)
+			query.After = models.NewWithdrawalCursor(withdrawals[len(withdrawals)-1], descending)
+		}
+
+		expected := slices.Clone(numbers)
+		if descending {
+			slices.Reverse(expected)
+		}
+		assert.DeepEqual(t, visited, expected)
+	}
+
+	// Фильтр по сумме: обе границы включительно
+	minSum, maxSum := models.NewMoney(2, 0), models.NewMoney(4, 0)
+	sumQuery := &models.WithdrawalsQuery{MinSum: &minSum, MaxSum: &maxSum, Limit: 10}
+	withdrawals, err := storage.GetWithdrawalsPageByUser(ctx, userID, sumQuery)
+	require.NoError(t, err)
+	assert.DeepEqual(t, pageNumbers(withdrawals), []string{"2", "3", "4"})
+
+	// Итоги учитывают фильтры, но не позицию и размер страницы
+	sumQuery.Limit = 1
+	sumQuery.After = models.NewWithdrawalCursor(withdrawals[0], false)
+	summary, err := storage.GetWithdrawalsSummaryByUser(ctx, userID, sumQuery)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Total, models.NewMoney(9, 0))
+	assert.Equal(t, summary.Count, int64(3))
+
+	// Фильтр по времени обработки: начало включительно, конец не включительно
+	all, err := storage.GetWithdrawalsPageByUser(ctx, userID, &models.WithdrawalsQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, len(numbers))
+	timeQuery := &models.WithdrawalsQuery{
+		ProcessedFrom: &all[1].ProcessedAt,
+		ProcessedTo:   &all[3].ProcessedAt,
+		Limit:         10,
+	}
+	withdrawals, err = storage.GetWithdrawalsPageByUser(ctx, userID, timeQuery)
+	require.NoError(t, err)
+	assert.DeepEqual(t, pageNumbers(withdrawals), []string{"2", "3"})
+	summary, err = storage.GetWithdrawalsSummaryByUser(ctx, userID, timeQuery)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Total, models.NewMoney(5, 0))
+	assert.Equal(t, summary.Count, int64(2))
+
+	// Пустая выборка
+	var zero models.Money
+	summary, err = storage.GetWithdrawalsSummaryByUser(ctx, userID, &models.WithdrawalsQuery{MinSum: &zero, MaxSum: &zero})
+	require.NoError(t, err)
+	assert.Equal(t, summary.Total, models.Money(0))
+	assert.Equal(t, summary.Count, int64(0))
+}
+
+func testLedger(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "password")
+	require.NoError(t, err)
+
+	// У нового пользователя журнал пуст
+	entries, err := storage.GetLedgerByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	// Начисление и списание баллов
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(300, 50)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.WithdrawFromUserBalance(ctx, userID, "2377225624", models.NewMoney(100, 25)))
+
+	// Неудачное списание не попадает в журнал
+	err = storage.WithdrawFromUserBalance(ctx, userID, "79927398713", models.NewMoney(1000, 0))
+	assert.ErrorIs(t, err, appErrors.ErrNegativeBalance)
+
+	entries, err = storage.GetLedgerByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, entries[0].Kind, models.LedgerEntryAccrual)
+	assert.Equal(t, entries[0].DebitAccount, models.LedgerAccountAccruals)
+	assert.Equal(t, entries[0].CreditAccount, models.LedgerAccountUser)
+	assert.Equal(t, entries[0].Amount, models.NewMoney(300, 50))
+	assert.Equal(t, entries[0].OrderNumber, "12345678903")
+	assert.Assert(t, !entries[0].CreatedAt.IsZero())
+
+	assert.Equal(t, entries[1].Kind, models.LedgerEntryWithdrawal)
+	assert.Equal(t, entries[1].DebitAccount, models.LedgerAccountUser)
+	assert.Equal(t, entries[1].CreditAccount, models.LedgerAccountWithdrawals)
+	assert.Equal(t, entries[1].Amount, models.NewMoney(100, 25))
+	assert.Equal(t, entries[1].OrderNumber, "2377225624")
+
+	// Журнал другого пользователя не затронут
+	entries, err = storage.GetLedgerByUser(ctx, anotherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	// Балансы совпадают с журналом
+	discrepancies, err := storage.GetBalanceDiscrepancies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(discrepancies), 0)
+}
+
+// Изменение времени загрузки заказа. Публичного способа задать время загрузки нет,
+// поэтому каждый бэкенд делает это сам.
+type UploadTimeOverrider func(ctx context.Context, storage app.Storage, number string, uploadedAt time.Time) error
+
+// Проверка порядка заказов пользователя: по времени загрузки, а при равном времени — по порядку регистрации
+func RunOrdersOrder(t *testing.T, newStorage StorageFactory, overrideUploadTime UploadTimeOverrider) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	storage := newStorage(ctx, t)
+	defer storage.Close()
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	for _, number := range []string{"12345678903", "2377225624", "79927398713"} {
+		require.NoError(t, storage.RegisterOrder(ctx, userID, number))
+	}
+
+	// Порядок времени загрузки не совпадает с порядком регистрации
+	uploadedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, overrideUploadTime(ctx, storage, "12345678903", uploadedAt.Add(time.Minute)))
+	require.NoError(t, overrideUploadTime(ctx, storage, "2377225624", uploadedAt.Add(time.Minute)))
+	require.NoError(t, overrideUploadTime(ctx, storage, "79927398713", uploadedAt))
+
+	orders, err := storage.GetOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	numbers := make([]string, 0, len(orders))
+	for _, order := range orders {
+		numbers = append(numbers, order.Number)
+	}
+	assert.DeepEqual(t, numbers, []string{"79927398713", "12345678903", "2377225624"})
+}
+
+// Изменение текущего баланса пользователя в обход журнала операций.
+// Публичного способа рассогласовать баланс нет, поэтому каждый бэкенд делает это сам.
+type BalanceOverrider func(ctx context.Context, storage app.Storage, userID int64, current models.Money) error
+
+// Проверка обнаружения расхождений баланса с журналом операций
+func RunBalanceDiscrepancies(t *testing.T, newStorage StorageFactory, overrideBalance BalanceOverrider) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	storage := newStorage(ctx, t)
+	defer storage.Close()
+
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: models.NewMoney(300, 0)},
+	})
+	require.NoError(t, err)
+
+	// Изменение баланса в обход журнала
+	require.NoError(t, overrideBalance(ctx, storage, userID, models.NewMoney(500, 0)))
+
+	discrepancies, err := storage.GetBalanceDiscrepancies(ctx)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, discrepancies[0].UserID, userID)
+	assert.Equal(t, discrepancies[0].Balance.Current, models.NewMoney(500, 0))
+	assert.Equal(t, discrepancies[0].Ledger.Current, models.NewMoney(300, 0))
+}
+
+func testOutboxEvents(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	// Неизменяющие операции событий не создают
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	err = storage.RegisterOrder(ctx, userID, "12345678903")
+	assert.ErrorIs(t, err, appErrors.ErrOrderWasUploadedByCurrentUser)
+	_, err = storage.RegisterOrders(ctx, userID, []string{"12345678903", "2377225624"})
+	require.NoError(t, err)
+	for _, status := range []models.OrderStatus{models.OrderStatusProcessing, models.OrderStatusProcessed} {
+		_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+			{Number: "12345678903", UserID: userID, Status: status, Accrual: models.NewMoney(300, 50)},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, storage.WithdrawFromUserBalance(ctx, userID, "79927398713", models.NewMoney(100, 0)))
+	err = storage.WithdrawFromUserBalance(ctx, userID, "4561261212345467", models.NewMoney(1000, 0))
+	assert.ErrorIs(t, err, appErrors.ErrNegativeBalance)
+
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, anotherUserID, "4561261212345467"))
+
+	now := time.Now().Add(time.Second)
+	claim := func(now time.Time, limit int) []models.OutboxEvent {
+		t.Helper()
+		events, err := storage.ClaimOutboxEvents(ctx, now, now.Add(time.Minute), limit)
+		require.NoError(t, err)
+		return events
+	}
+
+	// События передаются в порядке записи
+	events := claim(now, 10)
+	require.Equal(t, len(events), 6)
+	types := []models.OutboxEventType{}
+	for _, event := range events[:5] {
+		assert.Equal(t, event.UserID, userID)
+		assert.Equal(t, event.Attempts, 0)
+		assert.Assert(t, !event.CreatedAt.IsZero())
+		types = append(types, event.Type)
+	}
+	assert.DeepEqual(t, types, []models.OutboxEventType{
+		models.OutboxEventOrderRegistered,
+		models.OutboxEventOrderRegistered,
+		models.OutboxEventOrderStatusChanged,
+		models.OutboxEventOrderCredited,
+		models.OutboxEventBalanceWithdrawn,
+	})
+	assert.Equal(t, events[5].UserID, anotherUserID)
+	credited := models.OrderEventPayload{}
+	require.NoError(t, json.Unmarshal(events[3].Payload, &credited))
+	assert.DeepEqual(t, credited, models.OrderEventPayload{
+		Number:  "12345678903",
+		Status:  models.OrderStatusProcessed,
+		Accrual: models.NewMoney(300, 50),
+	})
+
+	// Выбранные события повторно не выбираются до окончания аренды
+	assert.Equal(t, len(claim(now, 10)), 0)
+
+	// Неудачная доставка откладывает все события пользователя,
+	// события без попытки доставки освобождаются
+	backoff := now.Add(time.Hour)
+	deliveries := []models.OutboxDelivery{
+		{EventID: events[0].ID, Status: models.OutboxEventDelivered},
+		{EventID: events[1].ID, Status: models.OutboxEventPending, Error: "unavailable", NextAttemptAt: backoff},
+	}
+	for _, event := range events[2:] {
+		deliveries = append(deliveries,
+			models.OutboxDelivery{EventID: event.ID, Status: models.OutboxEventPending, NextAttemptAt: now})
+	}
+	require.NoError(t, storage.CompleteOutboxEvents(ctx, deliveries))
+
+	claimed := claim(now, 10)
+	require.Equal(t, len(claimed), 1)
+	assert.Equal(t, claimed[0].ID, events[5].ID)
+	require.NoError(t, storage.CompleteOutboxEvents(ctx, []models.OutboxDelivery{
+		{EventID: events[5].ID, Status: models.OutboxEventDelivered},
+	}))
+
+	// После задержки доставляются оставшиеся события, начиная с недоставленного
+	claimed = claim(backoff, 2)
+	require.Equal(t, len(claimed), 2)
+	assert.Equal(t, claimed[0].ID, events[1].ID)
+	assert.Equal(t, claimed[0].Attempts, 1)
+	assert.Equal(t, claimed[1].ID, events[2].ID)
+	assert.Equal(t, claimed[1].Attempts, 0)
+
+	// Событие, доставка которого прекращена, не задерживает следующие события
+	require.NoError(t, storage.CompleteOutboxEvents(ctx, []models.OutboxDelivery{
+		{EventID: events[1].ID, Status: models.OutboxEventDead, Error: "unavailable"},
+		{EventID: events[2].ID, Status: models.OutboxEventDelivered},
+	}))
+	claimed = claim(backoff.Add(time.Hour), 10)
+	require.Equal(t, len(claimed), 2)
+	assert.Equal(t, claimed[0].ID, events[3].ID)
+	assert.Equal(t, claimed[1].ID, events[4].ID)
+
+	// Доставленные события повторно не передаются
+	require.NoError(t, storage.CompleteOutboxEvents(ctx, []models.OutboxDelivery{
+		{EventID: events[3].ID, Status: models.OutboxEventDelivered},
+		{EventID: events[4].ID, Status: models.OutboxEventDelivered},
+	}))
+	assert.Equal(t, len(claim(backoff.Add(time.Hour), 10)), 0)
+}
+
+func testWebhooks(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "password")
+	require.NoError(t, err)
+
+	// Вебхуки регистрируются до достижения лимита
+	const maxWebhooks = 2
+	for i := range maxWebhooks {
+		webhook := &models.Webhook{UserID: userID, URL: fmt.Sprintf("https://example.com/%d", i), Secret: "secret"}
+		require.NoError(t, storage.AddWebhook(ctx, webhook, maxWebhooks))
+		assert.Assert(t, webhook.ID != 0)
+		assert.Assert(t, !webhook.CreatedAt.IsZero())
+	}
+	err = storage.AddWebhook(ctx, &models.Webhook{UserID: userID, URL: "https://example.com"}, maxWebhooks)
+	assert.ErrorIs(t, err, appErrors.ErrTooManyWebhooks)
+
+	// Лимит считается для каждого пользователя отдельно
+	require.NoError(t, storage.AddWebhook(ctx, &models.Webhook{UserID: anotherUserID, URL: "https://example.com"}, maxWebhooks))
+
+	webhooks, err := storage.GetWebhooksByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, maxWebhooks)
+	assert.Equal(t, webhooks[0].URL, "https://example.com/0")
+	assert.Equal(t, webhooks[0].Secret, "secret")
+
+	webhook, err := storage.GetWebhook(ctx, webhooks[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.UserID, userID)
+	assert.Equal(t, webhook.URL, "https://example.com/1")
+
+	// Удаленный вебхук не находится
+	require.NoError(t, storage.DeleteWebhook(ctx, webhook.ID))
+	_, err = storage.GetWebhook(ctx, webhook.ID)
+	assert.ErrorIs(t, err, appErrors.ErrWebhookNotFound)
+	err = storage.DeleteWebhook(ctx, webhook.ID)
+	assert.ErrorIs(t, err, appErrors.ErrWebhookNotFound)
+
+	webhooks, err = storage.GetWebhooksByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(webhooks), 1)
+}
+
+func testWebhookDeliveries(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	anotherUserID, err := storage.AddUser(ctx, "another_user", "password")
+	require.NoError(t, err)
+
+	first := &models.Webhook{UserID: userID, URL: "https://example.com/first", Secret: "first"}
+	require.NoError(t, storage.AddWebhook(ctx, first, 10))
+	second := &models.Webhook{UserID: userID, URL: "https://example.com/second", Secret: "second"}
+	require.NoError(t, storage.AddWebhook(ctx, second, 10))
+	require.NoError(t, storage.AddWebhook(ctx, &models.Webhook{UserID: anotherUserID, URL: "https://example.com"}, 10))
+
+	// Событие ставится в очередь на каждый вебхук пользователя один раз
+	event := models.NewOrderStatusEvent(models.Order{UserID: userID, Number: "12345678903", Status: models.OrderStatusProcessing})
+	event.ID = 1
+	require.NoError(t, storage.AddWebhookDeliveries(ctx, event))
+	require.NoError(t, storage.AddWebhookDeliveries(ctx, event))
+
+	claim := func(now time.Time) []models.WebhookDelivery {
+		t.Helper()
+		deliveries, err := storage.ClaimWebhookDeliveries(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		return deliveries
+	}
+
+	now := time.Now().Add(time.Second)
+	claimed := claim(now)
+	require.Len(t, claimed, 2)
+	for _, d := range claimed {
+		assert.Equal(t, d.EventID, event.ID)
+		assert.Equal(t, d.EventType, models.OutboxEventOrderStatusChanged)
+		assert.Equal(t, d.Attempts, 0)
+		if d.WebhookID == second.ID {
+			assert.Equal(t, d.URL, second.URL)
+			assert.Equal(t, d.Secret, second.Secret)
+		}
+	}
+
+	// Выбранные доставки повторно не выбираются до окончания аренды
+	assert.Equal(t, len(claim(now)), 0)
+
+	// Первый вебхук принимает доставку, второй откладывает на минуту
+	retryAt := now.Add(2 * time.Minute)
+	results := []models.WebhookDeliveryResult{}
+	for _, d := range claimed {
+		if d.WebhookID == first.ID {
+			results = append(results,
+				models.WebhookDeliveryResult{DeliveryID: d.ID, Status: models.WebhookDeliveryDelivered, ResponseCode: 200})
+			continue
+		}
+		results = append(results, models.WebhookDeliveryResult{
+			DeliveryID: d.ID, Status: models.WebhookDeliveryPending, ResponseCode: 500,
+			Error: "unexpected status code 500", NextAttemptAt: retryAt,
+		})
+	}
+	require.NoError(t, storage.CompleteWebhookDeliveries(ctx, results))
+
+	// Отложенная доставка не выбирается до наступления времени попытки
+	assert.Equal(t, len(claim(now.Add(time.Minute))), 0)
+	claimed = claim(retryAt)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, claimed[0].WebhookID, second.ID)
+	assert.Equal(t, claimed[0].Attempts, 1)
+	assert.Equal(t, claimed[0].LastError, "unexpected status code 500")
+	require.NoError(t, storage.CompleteWebhookDeliveries(ctx, []models.WebhookDeliveryResult{
+		{DeliveryID: claimed[0].ID, Status: models.WebhookDeliveryDead, Error: "timeout"},
+	}))
+	assert.Equal(t, len(claim(retryAt.Add(time.Hour))), 0)
+
+	// Журнал доставок вебхука
+	deliveries, err := storage.GetWebhookDeliveries(ctx, first.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, deliveries[0].Status, models.WebhookDeliveryDelivered)
+	assert.Equal(t, deliveries[0].Attempts, 1)
+	assert.Equal(t, deliveries[0].ResponseCode, 200)
+	assert.Assert(t, deliveries[0].DeliveredAt != nil)
+
+	deliveries, err = storage.GetWebhookDeliveries(ctx, second.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, deliveries[0].Status, models.WebhookDeliveryDead)
+	assert.Equal(t, deliveries[0].Attempts, 2)
+	assert.Equal(t, deliveries[0].LastError, "timeout")
+	assert.Assert(t, deliveries[0].DeliveredAt == nil)
+	payload := models.OrderEventPayload{}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	assert.Equal(t, payload.Status, models.OrderStatusProcessing)
+
+	// Журнал удаляется вместе с вебхуком
+	require.NoError(t, storage.DeleteWebhook(ctx, second.ID))
+	deliveries, err = storage.GetWebhookDeliveries(ctx, second.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, len(deliveries), 0)
+}
+
+func testIdempotentAccrual(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+
+	steps := []struct {
+		status          models.OrderStatus
+		accrual         models.Money
+		expectedChanged int
+		expectedStatus  models.OrderStatus
+		expectedCurrent models.Money
+	}{
+		// NEW -> PROCESSING
+		{models.OrderStatusProcessing, 0, 1, models.OrderStatusProcessing, 0},
+		// Статус не изменился
+		{models.OrderStatusProcessing, 0, 0, models.OrderStatusProcessing, 0},
+		// PROCESSING -> PROCESSED с начислением
+		{models.OrderStatusProcessed, 500, 1, models.OrderStatusProcessed, 500},
+		// Повторное получение конечного статуса не приводит к повторному начислению
+		{models.OrderStatusProcessed, 500, 0, models.OrderStatusProcessed, 500},
+		// Конечный статус не может быть изменен
+		{models.OrderStatusInvalid, 0, 0, models.OrderStatusProcessed, 500},
+		{models.OrderStatusNew, 0, 0, models.OrderStatusProcessed, 500},
+	}
+
+	for _, step := range steps {
+		changedOrders, err := storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+			{Number: "12345678903", UserID: userID, Status: step.status, Accrual: step.accrual},
+		})
+		require.NoError(t, err)
+		require.Len(t, changedOrders, step.expectedChanged)
+		if step.expectedChanged > 0 {
+			assert.Equal(t, changedOrders[0].Number, "12345678903")
+			assert.Equal(t, changedOrders[0].UserID, userID)
+			assert.Equal(t, changedOrders[0].Status, step.status)
+		}
+
+		orders, err := storage.GetOrdersByUser(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, orders[0].Status, step.expectedStatus)
+
+		dbBalance, err := storage.GetBalanceByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, dbBalance.Current, step.expectedCurrent)
+	}
+
+	entries, err := storage.GetLedgerByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), 1)
+}
+
+func testConcurrentAccrual(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+
+	orders := []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 300},
+		{Number: "2377225624", UserID: userID, Status: models.OrderStatusProcessed, Accrual: 200},
+	}
+
+	// Одновременное сохранение одних и тех же начислений несколькими обработчиками
+	const updaters = 10
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changed int
+	)
+	for range updaters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			changedOrders, err := storage.SetOrdersAccrualAndUpdateBalance(ctx, orders)
+			assert.NilError(t, err)
+
+			mu.Lock()
+			changed += len(changedOrders)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Каждый заказ изменен и оплачен ровно один раз
+	assert.Equal(t, changed, len(orders))
+
+	dbBalance, err := storage.GetBalanceByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, dbBalance.Current, models.Money(500))
+
+	entries, err := storage.GetLedgerByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), len(orders))
+}
+
+func testAccrualFailures(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "12345678903"))
+	require.NoError(t, storage.RegisterOrder(ctx, userID, "2377225624"))
+
+	// Неудачи учитываются подряд для каждого заказа
+	for i := 1; i <= 3; i++ {
+		failedOrders, err := storage.UpdateOrdersAccrualFailures(ctx, []models.OrderAccrualFailure{
+			{Number: "12345678903", Error: "accrual service unavailable"},
+			{Number: "2377225624", Error: "order not registered"},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, failedOrders, 2)
+		for _, order := range failedOrders {
+			assert.Equal(t, order.AccrualFailures, i)
+			assert.Equal(t, order.UserID, userID)
+		}
+	}
+
+	// Успешный запрос сбрасывает счетчик
+	failedOrders, err := storage.UpdateOrdersAccrualFailures(ctx, nil, []string{"2377225624"})
+	require.NoError(t, err)
+	assert.Equal(t, len(failedOrders), 0)
+
+	orders, err := storage.GetOrdersByAccrualFailures(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, orders[0].Number, "12345678903")
+	assert.Equal(t, orders[0].AccrualFailures, 3)
+	assert.Equal(t, orders[0].LastAccrualError, "accrual service unavailable")
+
+	count, err := storage.CountOrdersByAccrualFailures(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, count, int64(1))
+	count, err = storage.CountOrdersByAccrualFailures(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, count, int64(0))
+
+	// Обработанные заказы не считаются проблемными
+	_, err = storage.SetOrdersAccrualAndUpdateBalance(ctx, []models.Order{
+		{Number: "12345678903", UserID: userID, Status: models.OrderStatusInvalid},
+	})
+	require.NoError(t, err)
+
+	orders, err = storage.GetOrdersByAccrualFailures(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, len(orders), 0)
+	count, err = storage.CountOrdersByAccrualFailures(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, count, int64(0))
+}
+
+func testRefreshTokenRotation(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, storage.AddRefreshToken(ctx, &models.RefreshToken{
+		UserID: userID, FamilyID: "family", TokenHash: "hash-1", ExpiresAt: expiresAt,
+	}))
+
+	// Ротация выдает токен того же семейства
+	rotated := &models.RefreshToken{TokenHash: "hash-2", ExpiresAt: expiresAt}
+	require.NoError(t, storage.RotateRefreshToken(ctx, "hash-1", rotated))
+	assert.Equal(t, rotated.UserID, userID)
+	assert.Equal(t, rotated.FamilyID, "family")
+
+	// Повторное использование отзывает все семейство, включая новый токен
+	err = storage.RotateRefreshToken(ctx, "hash-1", &models.RefreshToken{TokenHash: "hash-3", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+	err = storage.RotateRefreshToken(ctx, "hash-2", &models.RefreshToken{TokenHash: "hash-4", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+
+	// Неизвестный и истекший токены недействительны
+	err = storage.RotateRefreshToken(ctx, "unknown", &models.RefreshToken{TokenHash: "hash-5", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenInvalid)
+
+	require.NoError(t, storage.AddRefreshToken(ctx, &models.RefreshToken{
+		UserID: userID, FamilyID: "expired", TokenHash: "hash-6", ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	err = storage.RotateRefreshToken(ctx, "hash-6", &models.RefreshToken{TokenHash: "hash-7", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenInvalid)
+
+	// Отзыв семейства доступен только владельцу токена
+	require.NoError(t, storage.AddRefreshToken(ctx, &models.RefreshToken{
+		UserID: userID, FamilyID: "logout", TokenHash: "hash-8", ExpiresAt: expiresAt,
+	}))
+	err = storage.RevokeRefreshTokenFamily(ctx, userID+1, "hash-8")
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenInvalid)
+	require.NoError(t, storage.RevokeRefreshTokenFamily(ctx, userID, "hash-8"))
+	err = storage.RotateRefreshToken(ctx, "hash-8", &models.RefreshToken{TokenHash: "hash-9", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+}
+
+func testRevokedAccessTokens(ctx context.Context, t *testing.T, storage app.Storage) {
+	revoked, err := storage.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.Equal(t, revoked, false)
+
+	require.NoError(t, storage.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Hour)))
+	// Повторный отзыв не является ошибкой
+	require.NoError(t, storage.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Hour)))
+
+	revoked, err = storage.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.Equal(t, revoked, true)
+}
+
+func testLoginLockouts(ctx context.Context, t *testing.T, storage app.Storage) {
+	lockedUntil := time.Now().Add(time.Minute).Truncate(time.Second)
+	require.NoError(t, storage.AddLoginLockout(ctx, models.LoginLockout{
+		Reason:      models.LoginLockoutReasonPasswordReset,
+		SubjectType: models.LoginLockoutSubjectLogin, Subject: "user", Failures: 5, LockedUntil: lockedUntil,
+	}))
+	require.NoError(t, storage.AddLoginLockout(ctx, models.LoginLockout{
+		Reason:      models.LoginLockoutReasonLogin,
+		SubjectType: models.LoginLockoutSubjectIP, Subject: "192.0.2.1", Failures: 20, LockedUntil: lockedUntil,
+	}))
+
+	lockouts, err := storage.GetLoginLockouts(ctx, models.LoginLockoutSubjectLogin, "user")
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, lockouts[0].Reason, models.LoginLockoutReasonPasswordReset)
+	assert.Equal(t, lockouts[0].Failures, 5)
+	assert.Assert(t, lockouts[0].LockedUntil.Equal(lockedUntil))
+	assert.Assert(t, !lockouts[0].CreatedAt.IsZero())
+
+	lockouts, err = storage.GetLoginLockouts(ctx, models.LoginLockoutSubjectIP, "user")
+	require.NoError(t, err)
+	assert.Equal(t, len(lockouts), 0)
+}
+
+func testUpdateUserPassword(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	user, err := storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Login, "user")
+	assert.Equal(t, user.TokenVersion, int64(0))
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, storage.AddRefreshToken(ctx, &models.RefreshToken{
+		UserID: userID, FamilyID: "family", TokenHash: "hash-1", ExpiresAt: expiresAt,
+	}))
+
+	require.NoError(t, storage.UpdateUserPassword(ctx, userID, "new-hash"))
+
+	// Хеш пароля заменяется, версия токенов увеличивается
+	user, err = storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, "new-hash")
+	assert.Equal(t, user.TokenVersion, int64(1))
+
+	// Refresh токены пользователя отозваны
+	err = storage.RotateRefreshToken(ctx, "hash-1", &models.RefreshToken{TokenHash: "hash-2", ExpiresAt: expiresAt})
+	assert.ErrorIs(t, err, appErrors.ErrRefreshTokenReused)
+
+	err = storage.UpdateUserPassword(ctx, userID+1, "new-hash")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+	_, err = storage.GetUserByID(ctx, userID+1)
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+}
+
+func testPasswordResetTokens(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "password")
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		require.NoError(t, storage.AddPasswordResetToken(ctx, &models.PasswordResetToken{
+			UserID: userID, TokenHash: hash, ExpiresAt: expiresAt,
+		}))
+	}
+	require.NoError(t, storage.AddPasswordResetToken(ctx, &models.PasswordResetToken{
+		UserID: userID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+
+	user, err := storage.GetUserByPasswordResetToken(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	// Неизвестный и истекший токены недействительны
+	for _, hash := range []string{"unknown", "expired"} {
+		_, err = storage.GetUserByPasswordResetToken(ctx, hash)
+		assert.ErrorIs(t, err, appErrors.ErrPasswordResetTokenInvalid)
+		_, err = storage.ResetUserPassword(ctx, hash, "new-hash")
+		assert.ErrorIs(t, err, appErrors.ErrPasswordResetTokenInvalid)
+	}
+
+	resetUserID, err := storage.ResetUserPassword(ctx, "hash-1", "new-hash")
+	require.NoError(t, err)
+	assert.Equal(t, resetUserID, userID)
+
+	user, err = storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, "new-hash")
+	assert.Equal(t, user.TokenVersion, int64(1))
+
+	// Использованный токен и остальные токены пользователя больше не действуют
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		_, err = storage.ResetUserPassword(ctx, hash, "other-hash")
+		assert.ErrorIs(t, err, appErrors.ErrPasswordResetTokenInvalid)
+	}
+}
+
+func testRehashUserPassword(ctx context.Context, t *testing.T, storage app.Storage) {
+	userID, err := storage.AddUser(ctx, "user", "old-hash")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RehashUserPassword(ctx, userID, "old-hash", "new-hash"))
+
+	// Перехеширование не отзывает токены
+	user, err := storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, "new-hash")
+	assert.Equal(t, user.TokenVersion, int64(0))
+
+	// Хеш, измененный после проверки пароля, не перезаписывается
+	require.NoError(t, storage.RehashUserPassword(ctx, userID, "old-hash", "other-hash"))
+	user, err = storage.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, "new-hash")
+
+	err = storage.RehashUserPassword(ctx, userID+1, "old-hash", "new-hash")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+}
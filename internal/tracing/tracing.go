@@ -0,0 +1,70 @@
+// Package tracing настраивает трассировку OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+)
+
+const serviceName = "gophermart"
+
+// Остановка трассировки с отправкой накопленных span'ов
+type Shutdown func(ctx context.Context) error
+
+// Настройка глобального TracerProvider экспортером, заданным параметром TracingExporter.
+// Контекст трассировки передается в заголовках W3C traceparent и tracestate
+// независимо от экспортера, поэтому сервис не разрывает трассы вызывающих систем.
+func Setup(ctx context.Context, conf *config.Config) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch conf.TracingExporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		stdoutExporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("tracing.setup.stdout: %w", err)
+		}
+		exporter = stdoutExporter
+	case "otlp":
+		otlpExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.TracingEndpoint))
+		if err != nil {
+			return nil, fmt.Errorf("tracing.setup.otlp: %w", err)
+		}
+		exporter = otlpExporter
+	default:
+		return nil, fmt.Errorf("tracing.setup: unsupported exporter %q", conf.TracingExporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing.setup.resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
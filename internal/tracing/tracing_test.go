@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulixes-bloom/ya-gophermart/internal/config"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	tests := []struct {
+		name        string
+		exporter    string
+		expectedErr bool
+	}{
+		{name: "None Case", exporter: "none"},
+		{name: "Stdout Case", exporter: "stdout"},
+		{name: "Otlp Case", exporter: "otlp"},
+		{name: "Unsupported Case", exporter: "jaeger", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.GetDefault()
+			conf.TracingExporter = tt.exporter
+
+			shutdown, err := Setup(context.Background(), conf)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+)
+
+// Разрешение имени хоста в IP адреса
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Адреса, запросы на которые направлены в сеть самого сервиса: loopback,
+// частные, link-local (включая адреса метаданных облака), multicast и неуказанный
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// Проверка адреса вебхука при регистрации: абсолютный http(s) адрес, имя хоста которого
+// разрешается только в публичные IP, если частные адреса не разрешены параметром AllowPrivate.
+// Адрес проверяется повторно при каждом подключении, так как после регистрации
+// имя может быть перенаправлено на другой IP.
+func (d *Dispatcher) ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return appErrors.ErrInvalidWebhookURL
+	}
+	if d.conf.AllowPrivate {
+		return nil
+	}
+
+	addrs := []net.IPAddr{{IP: net.ParseIP(u.Hostname())}}
+	if addrs[0].IP == nil {
+		addrs, err = d.resolver.LookupIPAddr(ctx, u.Hostname())
+		if err != nil {
+			return fmt.Errorf("webhook.validateURL.lookup: %w: %w", appErrors.ErrInvalidWebhookURL, err)
+		}
+	}
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return fmt.Errorf("webhook.validateURL: %w: %w", appErrors.ErrInvalidWebhookURL,
+				appErrors.ErrPrivateWebhookAddress)
+		}
+	}
+	return nil
+}
+
+// Проверка IP, к которому выполняется подключение, после разрешения имени
+func denyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook.denyPrivateAddress: %w", err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIP(ip) {
+		return fmt.Errorf("webhook.denyPrivateAddress: %w: %s", appErrors.ErrPrivateWebhookAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Package webhook доставляет пользователям уведомления о заказах на зарегистрированные
+// ими адреса. События outbox раскладываются по вебхукам пользователя, после чего каждая
+// доставка отправляется подписанным POST запросом с повторами по экспоненциальной задержке.
+// Доставка, не удавшаяся за MaxAttempts попыток, прекращается и остается в журнале.
+// Запросы на частные адреса сети сервиса и перенаправления не выполняются.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+)
+
+// Ограничение показателя степени, чтобы задержка не переполнялась
+const maxBackoffShift = 30
+
+// Время на сохранение результатов доставки после отмены контекста
+const completeTimeout = 5 * time.Second
+
+type (
+	Config struct {
+		MaxAttempts int           // попыток до прекращения доставки
+		Backoff     time.Duration // задержка перед второй попыткой, удваивается с каждой следующей
+		MaxBackoff  time.Duration // максимальная задержка между попытками
+		Timeout     time.Duration // время ожидания ответа вебхука
+		BatchSize   int           // максимальное количество доставок за один запуск
+		Concurrency int           // количество одновременно отправляемых запросов
+		Lease       time.Duration // время, на которое выбранные доставки закрепляются за экземпляром сервиса
+
+		// Разрешить вебхуки на частных адресах (loopback, локальная сеть). Только для локальной разработки.
+		AllowPrivate bool
+	}
+
+	// Хранилище вебхуков и доставок
+	Store interface {
+		AddWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error
+		ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+		CompleteWebhookDeliveries(ctx context.Context, results []models.WebhookDeliveryResult) error
+	}
+
+	// Постановка событий outbox в очередь доставки на вебхуки пользователя.
+	// Используется как публикатор outbox.
+	Fanout struct {
+		store Store
+	}
+
+	Dispatcher struct {
+		store    Store
+		client   *http.Client
+		resolver Resolver
+		conf     Config
+		now      func() time.Time
+	}
+
+	// Тело запроса на вебхук
+	callback struct {
+		ID        int64                  `json:"id"` // ID события, одинаковый во всех попытках
+		Type      models.OutboxEventType `json:"type"`
+		Payload   json.RawMessage        `json:"payload"`
+		CreatedAt time.Time              `json:"created_at"`
+	}
+)
+
+// События, о которых уведомляются вебхуки
+var webhookEvents = map[models.OutboxEventType]bool{
+	models.OutboxEventOrderStatusChanged: true,
+	models.OutboxEventOrderCredited:      true,
+}
+
+func NewFanout(store Store) *Fanout {
+	return &Fanout{store: store}
+}
+
+func (f *Fanout) Publish(ctx context.Context, event models.OutboxEvent) error {
+	if !webhookEvents[event.Type] {
+		return nil
+	}
+	if err := f.store.AddWebhookDeliveries(ctx, event); err != nil {
+		return fmt.Errorf("webhook.fanout.publish: %w", err)
+	}
+	return nil
+}
+
+// Прокси из окружения не используется: подключение к нему обошло бы проверку адреса вебхука
+func NewDispatcher(store Store, conf Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: conf.Timeout}
+	if !conf.AllowPrivate {
+		dialer.Control = denyPrivateAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   conf.Timeout,
+			// Ответ с перенаправлением считается ответом вебхука
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		resolver: net.DefaultResolver,
+		conf:     conf,
+		now:      time.Now,
+	}
+}
+
+// Отправка доставок, время попытки которых наступило. Доставки выбираются и результаты
+// сохраняются короткими запросами, отправка выполняется между ними и ограничена половиной
+// аренды. Доставки, отправить которые не успели, повторяются по окончании аренды.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	now := d.now()
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, now, now.Add(d.conf.Lease), d.conf.BatchSize)
+	if err != nil {
+		return fmt.Errorf("webhook.deliverDue.claim: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	sendCtx, cancelSend := context.WithTimeout(ctx, d.conf.Lease/2)
+	results := d.deliver(sendCtx, deliveries)
+	cancelSend()
+
+	// Результаты сохраняются и после отмены контекста, чтобы не отправлять доставки повторно
+	completeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), completeTimeout)
+	defer cancel()
+
+	if err := d.store.CompleteWebhookDeliveries(completeCtx, results); err != nil {
+		return fmt.Errorf("webhook.deliverDue.complete: %w", err)
+	}
+	return nil
+}
+
+// Отправка доставок не более чем Concurrency запросами одновременно
+func (d *Dispatcher) deliver(ctx context.Context, deliveries []models.WebhookDelivery) []models.WebhookDeliveryResult {
+	attempted := make([]*models.WebhookDeliveryResult, len(deliveries))
+	sem := make(chan struct{}, d.conf.Concurrency)
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result := d.attempt(ctx, delivery)
+			attempted[i] = &result
+		}()
+	}
+	wg.Wait()
+
+	results := make([]models.WebhookDeliveryResult, 0, len(deliveries))
+	for _, result := range attempted {
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery) models.WebhookDeliveryResult {
+	result := models.WebhookDeliveryResult{DeliveryID: delivery.ID, Status: models.WebhookDeliveryDelivered}
+	code, err := d.send(ctx, delivery)
+	result.ResponseCode = code
+	if err != nil {
+		result.Error = truncate(err.Error(), models.WebhookDeliveryErrorMaxBytes)
+		attempt := delivery.Attempts + 1
+		if attempt >= d.conf.MaxAttempts {
+			result.Status = models.WebhookDeliveryDead
+			log.Warn().
+				Err(err).
+				Int64("delivery_id", delivery.ID).
+				Int64("webhook_id", delivery.WebhookID).
+				Int("attempts", attempt).
+				Msg("webhook delivery moved to dead letters")
+		} else {
+			result.Status = models.WebhookDeliveryPending
+			result.NextAttemptAt = d.now().Add(d.backoff(attempt))
+		}
+	}
+	return result
+}
+
+// Отправка одной доставки. Возвращает код ответа, если ответ получен.
+func (d *Dispatcher) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(callback{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		Payload:   delivery.Payload,
+		CreatedAt: delivery.CreatedAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("webhook.send.marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("webhook.send.newRequest: %w", err)
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-Id", strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set("Event-Type", string(delivery.EventType))
+	req.Header.Set("Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("Webhook-Signature", "v1="+Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook.send.do: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Задержка перед попыткой, следующей за attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	shift := min(attempt-1, maxBackoffShift)
+	delay := d.conf.Backoff << shift
+	if delay <= 0 || delay > d.conf.MaxBackoff {
+		return d.conf.MaxBackoff
+	}
+	return delay
+}
+
+// Подпись тела запроса: HMAC-SHA256 строки "<timestamp>.<body>" в hex.
+// Время в подписи позволяет получателю отклонять повторно отправленные старые запросы.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	return s[:maxBytes]
+}
@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appErrors "github.com/ulixes-bloom/ya-gophermart/internal/errors"
+	"github.com/ulixes-bloom/ya-gophermart/internal/models"
+	"github.com/ulixes-bloom/ya-gophermart/internal/storage/memory"
+)
+
+var testConfig = Config{
+	MaxAttempts: 3,
+	Backoff:     10 * time.Second,
+	MaxBackoff:  15 * time.Second,
+	Timeout:     time.Second,
+	BatchSize:   10,
+	Concurrency: 2,
+	Lease:       time.Minute,
+
+	// Тестовые вебхуки принимают запросы на loopback адресе
+	AllowPrivate: true,
+}
+
+func TestDispatcher_DeliverDue(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewStorage()
+
+	// Вебхук отвечает ошибкой, пока failing не сброшен, и проверяет подпись запроса
+	var failing atomic.Bool
+	failing.Store(true)
+	received := make(chan callback, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		timestamp, err := strconv.ParseInt(req.Header.Get("Webhook-Timestamp"), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, "v1="+Sign("secret", timestamp, body), req.Header.Get("Webhook-Signature"))
+
+		if failing.Load() {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		cb := callback{}
+		assert.NoError(t, json.Unmarshal(body, &cb))
+		received <- cb
+	}))
+	defer srv.Close()
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	webhook := &models.Webhook{UserID: userID, URL: srv.URL, Secret: "secret"}
+	require.NoError(t, storage.AddWebhook(ctx, webhook, models.MaxUserWebhooks))
+
+	// Регистрация заказа вебхукам не отправляется, смена статуса отправляется
+	fanout := NewFanout(storage)
+	registered := models.NewOrderRegisteredEvent(userID, "12345678903")
+	registered.ID = 1
+	require.NoError(t, fanout.Publish(ctx, registered))
+	changed := models.NewOrderStatusEvent(models.Order{UserID: userID, Number: "12345678903", Status: models.OrderStatusInvalid})
+	changed.ID = 2
+	require.NoError(t, fanout.Publish(ctx, changed))
+
+	now := time.Now().Add(time.Second)
+	d := NewDispatcher(storage, testConfig)
+	d.now = func() time.Time { return now }
+
+	// Неудачные попытки повторяются с растущей задержкой
+	for _, delay := range []time.Duration{10 * time.Second, 15 * time.Second} {
+		require.NoError(t, d.DeliverDue(ctx))
+		deliveries, err := storage.GetWebhookDeliveries(ctx, webhook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, models.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].ResponseCode)
+		assert.Equal(t, now.Add(delay), deliveries[0].NextAttemptAt)
+
+		// До наступления времени попытки доставка не отправляется
+		require.NoError(t, d.DeliverDue(ctx))
+		now = now.Add(delay)
+	}
+
+	// После MaxAttempts попыток доставка прекращается
+	require.NoError(t, d.DeliverDue(ctx))
+	deliveries, err := storage.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryDead, deliveries[0].Status)
+	assert.Equal(t, testConfig.MaxAttempts, deliveries[0].Attempts)
+
+	// Успешная доставка
+	failing.Store(false)
+	credited := models.NewOrderStatusEvent(models.Order{
+		UserID: userID, Number: "2377225624", Status: models.OrderStatusProcessed, Accrual: models.NewMoney(500, 0),
+	})
+	credited.ID = 3
+	require.NoError(t, fanout.Publish(ctx, credited))
+	now = now.Add(time.Hour)
+	require.NoError(t, d.DeliverDue(ctx))
+
+	require.Len(t, received, 1)
+	cb := <-received
+	assert.Equal(t, credited.ID, cb.ID)
+	assert.Equal(t, models.OutboxEventOrderCredited, cb.Type)
+	assert.JSONEq(t, string(credited.Payload), string(cb.Payload))
+
+	deliveries, err = storage.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.WebhookDeliveryDelivered, deliveries[0].Status)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+}
+
+// Доставка на вебхук test server и ее состояние после одной попытки
+func deliverOnce(t *testing.T, conf Config, handler http.HandlerFunc) models.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+	storage := memory.NewStorage()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	userID, err := storage.AddUser(ctx, "user", "hash")
+	require.NoError(t, err)
+	webhook := &models.Webhook{UserID: userID, URL: srv.URL, Secret: "secret"}
+	require.NoError(t, storage.AddWebhook(ctx, webhook, models.MaxUserWebhooks))
+	event := models.NewOrderStatusEvent(models.Order{UserID: userID, Number: "12345678903", Status: models.OrderStatusInvalid})
+	event.ID = 1
+	require.NoError(t, NewFanout(storage).Publish(ctx, event))
+
+	d := NewDispatcher(storage, conf)
+	now := time.Now().Add(time.Second)
+	d.now = func() time.Time { return now }
+	require.NoError(t, d.DeliverDue(ctx))
+
+	deliveries, err := storage.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+func TestDispatcher_PrivateAddress(t *testing.T) {
+	conf := testConfig
+	conf.AllowPrivate = false
+
+	var requests atomic.Int32
+	delivery := deliverOnce(t, conf, func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+	})
+
+	// Подключение к loopback адресу отклоняется до отправки запроса
+	assert.Equal(t, int32(0), requests.Load())
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 0, delivery.ResponseCode)
+	assert.Contains(t, delivery.LastError, appErrors.ErrPrivateWebhookAddress.Error())
+}
+
+func TestDispatcher_Redirect(t *testing.T) {
+	var redirected atomic.Bool
+	delivery := deliverOnce(t, testConfig, func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/internal" {
+			redirected.Store(true)
+			return
+		}
+		http.Redirect(rw, req, "/internal", http.StatusTemporaryRedirect)
+	})
+
+	// Перенаправление не выполняется и считается неудачной попыткой
+	assert.False(t, redirected.Load())
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusTemporaryRedirect, delivery.ResponseCode)
+}
+
+// Разрешение имен из заданного списка
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	addrs := []net.IPAddr{}
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestDispatcher_ValidateURL(t *testing.T) {
+	d := NewDispatcher(nil, Config{})
+	d.resolver = fakeResolver{
+		"example.com":  {"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"},
+		"internal.com": {"93.184.215.14", "10.0.0.1"},
+		"localhost":    {"127.0.0.1", "::1"},
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{name: "Public Host Case", url: "https://example.com/hook"},
+		{name: "Public IP Case", url: "http://93.184.215.14:8080/hook"},
+		{name: "Not Absolute Case", url: "example.com/hook", wantErr: appErrors.ErrInvalidWebhookURL},
+		{name: "Unsupported Scheme Case", url: "ftp://example.com/hook", wantErr: appErrors.ErrInvalidWebhookURL},
+		{name: "Unknown Host Case", url: "https://unknown.com/hook", wantErr: appErrors.ErrInvalidWebhookURL},
+		{name: "Localhost Case", url: "http://localhost/hook", wantErr: appErrors.ErrPrivateWebhookAddress},
+		{name: "Private Address Case", url: "https://internal.com/hook", wantErr: appErrors.ErrPrivateWebhookAddress},
+		{name: "Loopback IPv6 Case", url: "http://[::1]/hook", wantErr: appErrors.ErrPrivateWebhookAddress},
+		{name: "Metadata Address Case", url: "http://169.254.169.254/latest", wantErr: appErrors.ErrPrivateWebhookAddress},
+		{name: "Unspecified Address Case", url: "http://0.0.0.0/hook", wantErr: appErrors.ErrPrivateWebhookAddress},
+		{name: "Private Allowed Case", url: "http://localhost/hook", allowPrivate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.conf.AllowPrivate = tt.allowPrivate
+			err := d.ValidateURL(context.Background(), tt.url)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, appErrors.ErrInvalidWebhookURL)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil, Config{Backoff: time.Second, MaxBackoff: time.Hour})
+
+	tests := []struct {
+		name     string
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "First Attempt Case", attempt: 1, expected: time.Second},
+		{name: "Doubled Case", attempt: 4, expected: 8 * time.Second},
+		{name: "Capped Case", attempt: 20, expected: time.Hour},
+		{name: "Overflow Case", attempt: 100, expected: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, d.backoff(tt.attempt))
+		})
+	}
+}
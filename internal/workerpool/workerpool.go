@@ -3,8 +3,10 @@ package workerpool
 import (
 	"context"
 	"sync"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulixes-bloom/ya-gophermart/internal/metrics"
 )
 
 type jobCh[T any] chan T
@@ -20,9 +22,13 @@ type pool[T any] struct {
 	results      jobResCh[T]    // канал с результатами работы всех job
 	errors       jobErrCh[T]    // канал с ошибками работы job
 	once         sync.Once      // гарантирует, что каналы закроются только один раз
+
+	queueDepth  prometheus.Gauge    // job'ы, ожидающие свободного worker'а
+	jobDuration prometheus.Observer // длительность обработки job'ы
 }
 
-func New[T any](ctx context.Context, numOfWorkers, jobChSize int, jobHandler jobHandler[T]) *pool[T] {
+// Метрики pool'а учитываются с меткой pool=name, чтобы не смешивать разные pool'ы
+func New[T any](ctx context.Context, name string, numOfWorkers, jobChSize int, jobHandler jobHandler[T]) *pool[T] {
 	jobCh := make(chan T, jobChSize)
 	results := make(chan T, jobChSize)
 	errors := make(chan error, jobChSize)
@@ -33,6 +39,8 @@ func New[T any](ctx context.Context, numOfWorkers, jobChSize int, jobHandler job
 		jobHandler:   jobHandler,
 		results:      results,
 		errors:       errors,
+		queueDepth:   metrics.WorkerPoolQueueDepth.WithLabelValues(name),
+		jobDuration:  metrics.WorkerPoolJobDuration.WithLabelValues(name),
 	}
 
 	// Запуск worker'ов
@@ -46,6 +54,7 @@ func New[T any](ctx context.Context, numOfWorkers, jobChSize int, jobHandler job
 // Добавление новой job'ы в worker pool
 func (p *pool[T]) Submit(job T) {
 	p.wg.Add(1)
+	p.queueDepth.Inc()
 	p.jobCh <- job
 }
 
@@ -60,29 +69,23 @@ func (p *pool[T]) StopAndWait() {
 	})
 }
 
-// Запуск worker'a
+// Запуск worker'a. Worker обрабатывает job'ы до закрытия канала, в том числе
+// после отмены контекста: обработчик получает отмененный контекст и должен
+// быстро завершиться ошибкой. Так каждая добавленная job'а получает результат,
+// а Submit и StopAndWait не блокируются навсегда.
 func (p *pool[T]) startWorker(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			// Завершение работы при отмене контекста
-			log.Debug().Msg("Worker pool stopping due to context cancellation")
-			return
-		case job, ok := <-p.jobCh:
-			if !ok {
-				// Если канал job'ов закрыт, завершаем worker'a
-				return
-			}
-
-			res, err := p.jobHandler(ctx, job)
-			if err != nil {
-				p.errors <- err
-			} else {
-				p.results <- res
-			}
-
-			p.wg.Done()
+	for job := range p.jobCh {
+		p.queueDepth.Dec()
+		start := time.Now()
+		res, err := p.jobHandler(ctx, job)
+		p.jobDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			p.errors <- err
+		} else {
+			p.results <- res
 		}
+
+		p.wg.Done()
 	}
 }
 
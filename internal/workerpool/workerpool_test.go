@@ -0,0 +1,62 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_ContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler := func(ctx context.Context, job int) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return job, nil
+	}
+	wp := New(ctx, "test", 2, 4, handler)
+
+	var (
+		wg      sync.WaitGroup
+		results int
+		errs    int
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range wp.Results() {
+			results++
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range wp.Errors() {
+			errs++
+		}
+	}()
+
+	// Количество job'ов превышает размер буферов пула
+	const jobsCount = 20
+	done := make(chan struct{})
+	go func() {
+		for i := range jobsCount {
+			wp.Submit(i)
+		}
+		wp.StopAndWait()
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker pool hangs after context cancellation")
+	}
+	assert.Equal(t, 0, results)
+	assert.Equal(t, jobsCount, errs)
+}